@@ -1,53 +1,698 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/FabianKramm/kube-problem/pkg/alertmanager"
+	"github.com/FabianKramm/kube-problem/pkg/api"
+	"github.com/FabianKramm/kube-problem/pkg/cloudevents"
+	"github.com/FabianKramm/kube-problem/pkg/config"
+	"github.com/FabianKramm/kube-problem/pkg/discord"
+	"github.com/FabianKramm/kube-problem/pkg/email"
+	"github.com/FabianKramm/kube-problem/pkg/googlechat"
+	"github.com/FabianKramm/kube-problem/pkg/health"
 	"github.com/FabianKramm/kube-problem/pkg/kube"
+	"github.com/FabianKramm/kube-problem/pkg/leader"
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/maintenance"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/opsgenie"
+	"github.com/FabianKramm/kube-problem/pkg/pagerduty"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+	"github.com/FabianKramm/kube-problem/pkg/pubsub"
 	"github.com/FabianKramm/kube-problem/pkg/runner"
 	"github.com/FabianKramm/kube-problem/pkg/slack"
+	"github.com/FabianKramm/kube-problem/pkg/sns"
+	"github.com/FabianKramm/kube-problem/pkg/state"
+	"github.com/FabianKramm/kube-problem/pkg/teams"
+	"github.com/FabianKramm/kube-problem/pkg/version"
+	"github.com/FabianKramm/kube-problem/pkg/webhook"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
 func main() {
-	// Try to get a cluster client
-	client, err := kube.GetInClusterClient()
+	printVersion := flag.Bool("version", false, "Print the version and exit")
+	checkOnce := flag.Bool("check-once", false, "Run a single check cycle and exit with 1 if problems were found (same as CHECK_ONCE=true)")
+	flag.Parse()
+	if *printVersion {
+		fmt.Println(version.String())
+		return
+	}
+
+	// Load the config file, environment variables take precedence
+	configPath := os.Getenv("CONFIG_FILE")
+	configRequired := configPath != ""
+	if !configRequired {
+		configPath = config.DefaultPath
+	}
+
+	cfg, err := config.Load(configPath, configRequired)
 	if err != nil {
+		log.Fatal("Error loading config file", "error", err)
+	}
+
+	err = cfg.Apply()
+	if err != nil {
+		log.Fatal("Error applying config file", "error", err)
+	}
+	if *checkOnce {
+		os.Setenv("CHECK_ONCE", "true")
+	}
+
+	// Configure the logger
+	err = log.Configure(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+	if err != nil {
+		log.Fatal("Error configuring logger", "error", err)
+	}
+	log.Info("Starting kube-problem", "version", version.Version, "commit", version.Commit, "build_date", version.BuildDate)
+
+	// Validate the configuration
+	errs := config.Validate()
+	for _, err := range errs {
+		log.Error("Invalid configuration", "error", err)
+	}
+	if len(errs) > 0 {
+		log.Fatal("Found invalid configuration values, exiting", "count", len(errs))
+	}
+
+	// Try to get a cluster client, with multiple clusters it is only used for leader election
+	client, err := kube.GetInClusterClient()
+	if err != nil && os.Getenv("KUBECONFIGS") == "" {
 		var defaultClientErr error
 		client, defaultClientErr = kube.GetDefaultClient()
 		if defaultClientErr != nil {
-			log.Fatal(err)
+			log.Fatal("Error creating kube client", "error", defaultClientErr, "inClusterError", err)
 		}
 
-		log.Println("Using kube config client")
-	} else {
-		log.Println(("Using in cluster kube client"))
+		log.Info("Using kube config client")
+	} else if err == nil {
+		log.Info("Using in cluster kube client")
 	}
 
-	// Create a new slack client
-	slackClient, err := slack.NewClient(os.Getenv("SLACK_TOKEN"), os.Getenv("SLACK_CHANNEL"))
+	// Create a client per watched cluster
+	clusters := clusterList{{client: client}}
+	if os.Getenv("KUBECONFIGS") != "" {
+		clusters, err = getKubeConfigClusters(os.Getenv("KUBECONFIGS"))
+		if err != nil {
+			log.Fatal("Error creating kube clients for KUBECONFIGS", "error", err)
+		}
+	}
+
+	// Start the metrics server
+	metricsPort := os.Getenv("METRICS_PORT")
+	if metricsPort == "" {
+		metricsPort = "8080"
+	}
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", prometheus.Handler())
+	go func() {
+		log.Info("Serving metrics", "port", metricsPort)
+		log.Fatal("Error serving metrics", "error", http.ListenAndServe(":"+metricsPort, metricsMux))
+	}()
+
+	// Create the notifiers
+	notifier, slackClient, err := createNotifier()
 	if err != nil {
-		log.Fatalf("Error creating slack client: %v", err)
+		log.Fatal("Error creating notifiers", "error", err)
 	}
 
-	// Verify the client is working
-	slackChannel, err := slackClient.GetChannelInfo()
+	// Parse the pod label selector
+	podSelector, err := labels.Parse(os.Getenv("WATCH_LABEL_SELECTOR"))
 	if err != nil {
-		log.Fatalf("Error getting slack channel info: %v", err)
+		log.Fatal("Error parsing WATCH_LABEL_SELECTOR", "error", err)
+	}
+	if !podSelector.Empty() {
+		log.Info("Only watching pods with matching labels", "selector", podSelector.String())
 	}
-	log.Printf("Using slack channel '%s' for alerts", slackChannel.Name)
 
-	// Create the runner
-	runner, err := runner.NewRunner(client, slackClient, os.Getenv("WATCH_NODES") != "false", strings.Split(os.Getenv("WATCH_NAMESPACES"), ","))
+	nodeSelector, err := labels.Parse(os.Getenv("WATCH_NODE_SELECTOR"))
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal("Error parsing WATCH_NODE_SELECTOR", "error", err)
 	}
 
-	// Start the runner
-	err = runner.Start()
+	// Persist the problems in the pod's namespace
+	var stateStore *state.Store
+	if os.Getenv("POD_NAMESPACE") != "" && os.Getenv("KUBECONFIGS") != "" {
+		log.Warn("Persisting state is not supported with multiple clusters")
+	} else if os.Getenv("POD_NAMESPACE") != "" {
+		stateStore = state.NewStore(client, os.Getenv("POD_NAMESPACE"))
+		log.Info("Persisting state in configmap", "namespace", os.Getenv("POD_NAMESPACE"), "configmap", state.ConfigMapName)
+	}
+
+	// Load the greetings of the slack messages from a configmap and refresh them periodically
+	if os.Getenv("GREETING_CONFIGMAP") != "" {
+		namespace, name := os.Getenv("POD_NAMESPACE"), os.Getenv("GREETING_CONFIGMAP")
+		if splitted := strings.SplitN(name, "/", 2); len(splitted) == 2 {
+			namespace, name = splitted[0], splitted[1]
+		}
+		if namespace == "" {
+			log.Fatal("GREETING_CONFIGMAP requires the POD_NAMESPACE environment variable or the format namespace/name")
+		}
+
+		log.Info("Loading greetings from configmap", "namespace", namespace, "configmap", name)
+		go watchGreetings(clusters[0].client, namespace, name)
+	}
+
+	// Parse the maintenance windows
+	maintenanceWindows, err := maintenance.Parse(os.Getenv("MAINTENANCE_WINDOWS"))
 	if err != nil {
-		log.Fatalf("Error in runner: %v", err)
+		log.Fatal("Error parsing MAINTENANCE_WINDOWS", "error", err)
+	}
+	if len(maintenanceWindows) > 0 {
+		log.Info("Suppressing alerts during maintenance windows", "windows", maintenanceWindows.String())
 	}
+
+	watchNamespaces, excludedNamespaces, err := parseWatchNamespaces(os.Getenv("WATCH_NAMESPACES"))
+	if err != nil {
+		log.Fatal("Error parsing WATCH_NAMESPACES", "error", err)
+	}
+
+	// Create a runner per cluster
+	for _, cluster := range clusters {
+		var clusterNotifier notify.Notifier = notifier
+		if cluster.name != "" {
+			clusterNotifier = &notify.ClusterNotifier{Cluster: cluster.name, Notifier: notifier}
+		}
+
+		cluster.runner, err = runner.NewRunner(cluster.client, clusterNotifier, os.Getenv("WATCH_NODES") != "false", watchNamespaces, excludedNamespaces, nodeSelector, podSelector, stateStore, maintenanceWindows)
+		if err != nil {
+			log.Fatal("Error creating runner", "cluster", cluster.name, "error", err)
+		}
+	}
+
+	// Start the slack interactive components callback server
+	if slackClient != nil && slackClient.Interactive {
+		callbackPort := os.Getenv("SLACK_CALLBACK_PORT")
+		if callbackPort == "" {
+			callbackPort = "3000"
+		}
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/slack/callback", slackClient.NewCallbackHandler(os.Getenv("SLACK_SIGNING_SECRET"), clusters.acknowledge))
+
+			log.Info("Serving slack callbacks", "port", callbackPort)
+			log.Fatal("Error serving slack callbacks", "error", http.ListenAndServe(":"+callbackPort, mux))
+		}()
+	}
+
+	// Start the api server, which shares the server with the metrics if the ports are the same
+	apiPort := os.Getenv("API_PORT")
+	if apiPort == "" {
+		apiPort = "8080"
+	}
+	apiHandler := api.NewHandler(clusters.problems, clusters.history)
+	if apiPort == metricsPort {
+		metricsMux.Handle("/problems", apiHandler)
+		metricsMux.Handle("/problems/", apiHandler)
+		log.Info("Serving api", "port", apiPort)
+	} else {
+		go func() {
+			log.Info("Serving api", "port", apiPort)
+			log.Fatal("Error serving api", "error", http.ListenAndServe(":"+apiPort, apiHandler))
+		}()
+	}
+
+	// Start the alertmanager webhook receiver, alerts are handled by the runner of the cluster in the cluster label
+	if os.Getenv("ALERTMANAGER_RECEIVER_PORT") != "" {
+		receiverPort := os.Getenv("ALERTMANAGER_RECEIVER_PORT")
+		go func() {
+			handler := alertmanager.NewHandler(func(alert alertmanager.Alert) error {
+				return clusters.runnerFor(alert.Labels["cluster"]).ReportAlert(alert)
+			}, func(alert alertmanager.Alert) error {
+				return clusters.runnerFor(alert.Labels["cluster"]).ResolveAlert(alert)
+			})
+
+			log.Info("Serving alertmanager webhook receiver", "port", receiverPort)
+			log.Fatal("Error serving alertmanager webhook receiver", "error", http.ListenAndServe(":"+receiverPort, handler))
+		}()
+	}
+
+	// With leader election only the leader runs the checks, so the replicas are ready as soon as they take part in
+	// the election, otherwise the standby replicas would never become ready
+	var elector *leader.Elector
+	ready := clusters.ready
+	if os.Getenv("LEADER_ELECTION_ENABLED") == "true" && os.Getenv("CHECK_ONCE") != "true" {
+		if os.Getenv("POD_NAME") == "" || os.Getenv("POD_NAMESPACE") == "" {
+			log.Fatal("Leader election requires the POD_NAME and POD_NAMESPACE environment variables")
+		} else if client == nil {
+			log.Fatal("Leader election with multiple clusters requires running in a cluster")
+		}
+
+		elector = leader.NewElector(client, os.Getenv("POD_NAMESPACE"), os.Getenv("POD_NAME"))
+		ready = elector.Running
+	}
+
+	// Start the health server
+	healthPort := os.Getenv("HEALTH_PORT")
+	if healthPort == "" {
+		healthPort = "9090"
+	}
+	go func() {
+		log.Info("Serving health checks", "port", healthPort)
+		log.Fatal("Error serving health checks", "error", http.ListenAndServe(":"+healthPort, health.NewHandler(ready)))
+	}()
+
+	// Stop the runner on SIGTERM or SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	// Start the runners, with leader election only the leader runs them
+	if os.Getenv("CHECK_ONCE") == "true" {
+		err = clusters.start(ctx)
+		if err != nil {
+			log.Fatal("Error in runner", "error", err)
+		}
+
+		problems := clusters.problems()
+		for id, problem := range problems {
+			log.Info("Found problem", "id", id, "type", problem.Type, "message", problem.Message)
+		}
+		if len(problems) > 0 {
+			log.Error("Check found problems", "count", len(problems))
+			os.Exit(1)
+		}
+
+		log.Info("Check found no problems")
+		return
+	} else if elector != nil {
+		elector.Run(ctx, func(ctx context.Context) {
+			err := clusters.start(ctx)
+			if err != nil {
+				log.Fatal("Error in runner", "error", err)
+			}
+		}, func() {
+			// Restart to rejoin the election with a fresh runner state
+			log.Fatal("Lost leadership, exiting")
+		})
+	} else {
+		err = clusters.start(ctx)
+		if err != nil {
+			log.Fatal("Error in runner", "error", err)
+		}
+	}
+
+	log.Info("Shutdown complete")
+}
+
+// greetingsKey is the key of the newline separated greetings in the greetings configmap
+const greetingsKey = "greetings"
+
+const greetingRefreshInterval = time.Minute * 10
+
+// watchGreetings loads the greetings from the configmap every greetingRefreshInterval
+func watchGreetings(client kube.Client, namespace, name string) {
+	for {
+		loadGreetings(client, namespace, name)
+		time.Sleep(greetingRefreshInterval)
+	}
+}
+
+// loadGreetings sets the newline separated greetings of the configmap's greetings key. If the configmap or the key
+// does not exist the built-in greetings are used, on other errors the current greetings are kept
+func loadGreetings(client kube.Client, namespace, name string) {
+	configMap, err := client.Client().CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			log.Warn("Greetings configmap not found, using built-in greetings", "namespace", namespace, "configmap", name)
+			slack.SetGreetings(nil)
+			return
+		}
+
+		log.Warn("Error loading greetings configmap", "namespace", namespace, "configmap", name, "error", err)
+		return
+	}
+
+	greetings := []string{}
+	for _, greeting := range strings.Split(configMap.Data[greetingsKey], "\n") {
+		greeting = strings.TrimSpace(greeting)
+		if greeting != "" {
+			greetings = append(greetings, greeting)
+		}
+	}
+	if len(greetings) == 0 {
+		log.Warn("Greetings configmap has no greetings, using built-in greetings", "namespace", namespace, "configmap", name, "key", greetingsKey)
+	}
+
+	slack.SetGreetings(greetings)
+}
+
+// cluster is a watched cluster, the name is empty if only a single cluster is watched
+type cluster struct {
+	name   string
+	client kube.Client
+	runner *runner.Runner
+}
+
+type clusterList []*cluster
+
+// getKubeConfigClusters creates a cluster for every kube config in the comma separated list. Entries are either
+// a path or name=path, by default the cluster is named after the current context of the kube config
+func getKubeConfigClusters(value string) (clusterList, error) {
+	clusters := clusterList{}
+	names := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, path := "", entry
+		if splitted := strings.SplitN(entry, "=", 2); len(splitted) == 2 {
+			name, path = strings.TrimSpace(splitted[0]), strings.TrimSpace(splitted[1])
+		}
+
+		client, context, err := kube.GetKubeConfigClient(path)
+		if err != nil {
+			return nil, fmt.Errorf("kube config %s: %v", path, err)
+		}
+		if name == "" {
+			name = context
+		}
+		if name == "" {
+			return nil, fmt.Errorf("kube config %s has no current context, use name=%s to name the cluster", path, path)
+		} else if names[name] {
+			return nil, fmt.Errorf("cluster name %s is used multiple times, use name=path to name the clusters", name)
+		}
+
+		names[name] = true
+		clusters = append(clusters, &cluster{name: name, client: client})
+		log.Info("Watching cluster", "cluster", name, "kubeconfig", path)
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no kube config found")
+	}
+
+	return clusters, nil
+}
+
+// start runs the runners of all clusters and returns the first error
+func (c clusterList) start(ctx context.Context) error {
+	errs := make(chan error, len(c))
+	for _, watched := range c {
+		go func(watched *cluster) {
+			err := watched.runner.Start(ctx)
+			if err != nil && watched.name != "" {
+				err = fmt.Errorf("cluster %s: %v", watched.name, err)
+			}
+
+			errs <- err
+		}(watched)
+	}
+
+	for range c {
+		err := <-errs
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// acknowledge acknowledges the problem in the runner of its cluster, which is the prefix of the problem id
+func (c clusterList) acknowledge(problemID string) error {
+	for _, cluster := range c {
+		if cluster.name == "" {
+			return cluster.runner.Acknowledge(problemID)
+		} else if strings.HasPrefix(problemID, cluster.name+"/") {
+			return cluster.runner.Acknowledge(strings.TrimPrefix(problemID, cluster.name+"/"))
+		}
+	}
+
+	return fmt.Errorf("cluster of problem %s not found", problemID)
+}
+
+// problems returns the active problems of all clusters, with multiple clusters the ids are prefixed with the cluster
+func (c clusterList) problems() map[string]*api.Problem {
+	problems := make(map[string]*api.Problem)
+	for _, cluster := range c {
+		for id, problem := range cluster.runner.Problems() {
+			if cluster.name != "" {
+				id = cluster.name + "/" + id
+				problem.Cluster = cluster.name
+			}
+
+			problems[id] = problem
+		}
+	}
+
+	return problems
+}
+
+// history returns the resolved problems of all clusters, the most recently resolved problem first
+func (c clusterList) history() []*api.ResolvedProblem {
+	history := []*api.ResolvedProblem{}
+	for _, cluster := range c {
+		for _, problem := range cluster.runner.History() {
+			resolved := *problem
+			resolved.Cluster = cluster.name
+			history = append(history, &resolved)
+		}
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Resolved.After(history[j].Resolved)
+	})
+	return history
+}
+
+// runnerFor returns the runner of the cluster with the given name or the runner of the first cluster
+func (c clusterList) runnerFor(name string) *runner.Runner {
+	for _, cluster := range c {
+		if cluster.name != "" && cluster.name == name {
+			return cluster.runner
+		}
+	}
+
+	return c[0].runner
+}
+
+// ready returns true if the runners of all clusters are ready
+func (c clusterList) ready() bool {
+	for _, cluster := range c {
+		if !cluster.runner.Ready() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseWatchNamespaces parses the comma separated namespaces, * or an empty value means all namespaces.
+// Namespaces prefixed with - are excluded, which is only allowed together with *
+func parseWatchNamespaces(value string) ([]string, []string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return []string{metav1.NamespaceAll}, nil, nil
+	}
+
+	all := false
+	namespaces := []string{}
+	excluded := []string{}
+	for _, namespace := range strings.Split(value, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "*" {
+			all = true
+		} else if strings.HasPrefix(namespace, "-") {
+			excluded = append(excluded, strings.TrimSpace(strings.TrimPrefix(namespace, "-")))
+		} else if namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	if all {
+		if len(namespaces) > 0 {
+			return nil, nil, fmt.Errorf("namespaces %s can't be combined with *, use -namespace to exclude namespaces instead", strings.Join(namespaces, ","))
+		}
+
+		return []string{metav1.NamespaceAll}, excluded, nil
+	} else if len(excluded) > 0 {
+		return nil, nil, fmt.Errorf("excluded namespaces %s require * to watch all other namespaces, e.g. *,-%s", strings.Join(excluded, ","), excluded[0])
+	}
+
+	return namespaces, nil, nil
+}
+
+func createNotifier() (notify.MultiNotifier, *slack.Client, error) {
+	var slackClient *slack.Client
+	notifier := notify.MultiNotifier{}
+	if os.Getenv("PAGERDUTY_ROUTING_KEY") != "" {
+		pagerdutyClient, err := pagerduty.NewClient(os.Getenv("PAGERDUTY_ROUTING_KEY"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating pagerduty client: %v", err)
+		}
+
+		log.Info("Using pagerduty for alerts")
+		notifier = append(notifier, pagerdutyClient)
+	}
+
+	if os.Getenv("OPSGENIE_API_KEY") != "" {
+		opsgenieClient, err := opsgenie.NewClient(os.Getenv("OPSGENIE_API_KEY"), os.Getenv("OPSGENIE_TEAM"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating opsgenie client: %v", err)
+		}
+
+		log.Info("Using opsgenie for alerts", "team", opsgenieClient.Team)
+		notifier = append(notifier, opsgenieClient)
+	}
+
+	if os.Getenv("TEAMS_WEBHOOK_URL") != "" {
+		teamsClient, err := teams.NewClient(os.Getenv("TEAMS_WEBHOOK_URL"), os.Getenv("TEAMS_MENTION_USER"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating teams client: %v", err)
+		}
+
+		log.Info("Using microsoft teams for alerts")
+		notifier = append(notifier, teamsClient)
+	}
+
+	if os.Getenv("CLOUDEVENTS_SINK") != "" {
+		cloudeventsClient, err := cloudevents.NewClient(os.Getenv("CLOUDEVENTS_SINK"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating cloudevents client: %v", err)
+		}
+
+		log.Info("Sending cloudevents", "sink", os.Getenv("CLOUDEVENTS_SINK"))
+		notifier = append(notifier, cloudeventsClient)
+	}
+
+	if os.Getenv("GOOGLE_CHAT_WEBHOOK") != "" {
+		googleChatClient, err := googlechat.NewClient(os.Getenv("GOOGLE_CHAT_WEBHOOK"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating google chat client: %v", err)
+		}
+
+		log.Info("Using google chat for alerts")
+		notifier = append(notifier, googleChatClient)
+	}
+
+	if os.Getenv("DISCORD_WEBHOOK_URL") != "" {
+		discordClient, err := discord.NewClient(os.Getenv("DISCORD_WEBHOOK_URL"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating discord client: %v", err)
+		}
+
+		log.Info("Using discord for alerts")
+		notifier = append(notifier, discordClient)
+	}
+
+	if os.Getenv("WEBHOOK_URL") != "" {
+		webhookClient, err := webhook.NewClient(os.Getenv("WEBHOOK_URL"), os.Getenv("WEBHOOK_HEADERS"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating webhook client: %v", err)
+		}
+
+		log.Info("Sending alerts to webhook", "url", os.Getenv("WEBHOOK_URL"))
+		notifier = append(notifier, webhookClient)
+	}
+
+	if os.Getenv("PUBSUB_TOPIC_ID") != "" {
+		pubsubClient, err := pubsub.NewClient(os.Getenv("PUBSUB_PROJECT_ID"), os.Getenv("PUBSUB_TOPIC_ID"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating pub/sub client: %v", err)
+		}
+
+		log.Info("Publishing alerts to pub/sub", "project", pubsubClient.ProjectID, "topic", pubsubClient.TopicID)
+		notifier = append(notifier, pubsubClient)
+	}
+
+	if os.Getenv("SNS_TOPIC_ARN") != "" {
+		snsClient, err := sns.NewClient(os.Getenv("SNS_TOPIC_ARN"), os.Getenv("AWS_REGION"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating sns client: %v", err)
+		}
+
+		log.Info("Publishing alerts to sns", "topic", snsClient.TopicARN, "region", snsClient.Region)
+		notifier = append(notifier, snsClient)
+	}
+
+	if os.Getenv("SMTP_HOST") != "" {
+		smtpNotifier, err := email.NewSMTPNotifier(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"), os.Getenv("SMTP_TO"), os.Getenv("SMTP_TLS") == "true")
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating smtp notifier: %v", err)
+		}
+
+		log.Info("Sending alerts by email", "host", smtpNotifier.Host, "recipients", strings.Join(smtpNotifier.To, ","))
+		notifier = append(notifier, smtpNotifier)
+	}
+
+	// Slack is used if it is configured or no other notifier is configured
+	if os.Getenv("SLACK_TOKEN") != "" || len(notifier) == 0 {
+		var err error
+		slackClient, err = slack.NewClient(os.Getenv("SLACK_TOKEN"), os.Getenv("SLACK_CHANNEL"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating slack client: %v", err)
+		}
+
+		// Verify the client is working
+		if os.Getenv("DRY_RUN") == "true" && os.Getenv("DRY_RUN_SKIP_SLACK_VERIFY") == "true" {
+			log.Info("Dry run: skipping verification of slack channel", "channel", slackClient.Channel)
+		} else {
+			slackChannel, err := slackClient.GetChannelInfo()
+			if err != nil {
+				return nil, nil, fmt.Errorf("Error getting slack channel info: %v", err)
+			}
+
+			log.Info("Using slack channel for alerts", "channel", slackChannel.Name)
+		}
+
+		slackClient.RichFormat = os.Getenv("SLACK_RICH_FORMAT") == "true"
+		slackClient.Interactive = os.Getenv("SLACK_SIGNING_SECRET") != ""
+		threshold := slack.DefaultCircuitBreakerThreshold
+		if os.Getenv("SLACK_CIRCUIT_BREAKER_THRESHOLD") != "" {
+			threshold, err = strconv.Atoi(os.Getenv("SLACK_CIRCUIT_BREAKER_THRESHOLD"))
+			if err != nil || threshold < 1 {
+				return nil, nil, fmt.Errorf("Error parsing SLACK_CIRCUIT_BREAKER_THRESHOLD: expected a number greater than 0")
+			}
+		}
+		timeout := slack.DefaultCircuitBreakerTimeout
+		if os.Getenv("SLACK_CIRCUIT_BREAKER_TIMEOUT") != "" {
+			timeout, err = time.ParseDuration(os.Getenv("SLACK_CIRCUIT_BREAKER_TIMEOUT"))
+			if err != nil || timeout <= 0 {
+				return nil, nil, fmt.Errorf("Error parsing SLACK_CIRCUIT_BREAKER_TIMEOUT: expected a duration like 30s")
+			}
+		}
+		slackClient.SetCircuitBreaker(threshold, timeout)
+
+		retryMax := slack.DefaultRetryMax
+		if os.Getenv("SLACK_RETRY_MAX") != "" {
+			retryMax, err = strconv.Atoi(os.Getenv("SLACK_RETRY_MAX"))
+			if err != nil || retryMax < 1 {
+				return nil, nil, fmt.Errorf("Error parsing SLACK_RETRY_MAX: expected a number greater than 0")
+			}
+		}
+		retryBase := slack.DefaultRetryBase
+		if os.Getenv("SLACK_RETRY_BASE_MS") != "" {
+			retryBaseMs, err := strconv.Atoi(os.Getenv("SLACK_RETRY_BASE_MS"))
+			if err != nil || retryBaseMs < 1 {
+				return nil, nil, fmt.Errorf("Error parsing SLACK_RETRY_BASE_MS: expected a number greater than 0")
+			}
+			retryBase = time.Duration(retryBaseMs) * time.Millisecond
+		}
+		retryMaxBackoff := slack.DefaultRetryMaxBackoff
+		if os.Getenv("SLACK_RETRY_MAX_MS") != "" {
+			retryMaxMs, err := strconv.Atoi(os.Getenv("SLACK_RETRY_MAX_MS"))
+			if err != nil || retryMaxMs < 1 {
+				return nil, nil, fmt.Errorf("Error parsing SLACK_RETRY_MAX_MS: expected a number greater than 0")
+			}
+			retryMaxBackoff = time.Duration(retryMaxMs) * time.Millisecond
+		}
+		slackClient.SetRetry(retryMax, retryBase, retryMaxBackoff)
+		notifier = append(notifier, slackClient)
+	}
+
+	return notifier, slackClient, nil
 }
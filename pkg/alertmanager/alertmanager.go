@@ -0,0 +1,86 @@
+package alertmanager
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+)
+
+const (
+	statusFiring   = "firing"
+	statusResolved = "resolved"
+)
+
+// Alert is a single alert of an alertmanager webhook payload
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Message returns the summary, description or message annotation of the alert
+func (a Alert) Message() string {
+	for _, annotation := range []string{"summary", "description", "message"} {
+		if a.Annotations[annotation] != "" {
+			return a.Annotations[annotation]
+		}
+	}
+
+	return "Alert " + a.Labels["alertname"] + " is firing"
+}
+
+// webhookPayload is the payload alertmanager posts to webhook receivers (version 4)
+type webhookPayload struct {
+	Version  string  `json:"version"`
+	GroupKey string  `json:"groupKey"`
+	Status   string  `json:"status"`
+	Receiver string  `json:"receiver"`
+	Alerts   []Alert `json:"alerts"`
+}
+
+// NewHandler creates a new http handler that receives alertmanager webhooks at POST /alertmanager. The report
+// function is called for every firing alert and the resolve function for every resolved alert
+func NewHandler(report func(alert Alert) error, resolve func(alert Alert) error) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/alertmanager", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload := &webhookPayload{}
+		err := json.NewDecoder(req.Body).Decode(payload)
+		if err != nil {
+			http.Error(w, "invalid alertmanager payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, alert := range payload.Alerts {
+			switch alert.Status {
+			case statusFiring:
+				err = report(alert)
+			case statusResolved:
+				err = resolve(alert)
+			default:
+				log.Warn("Ignoring alertmanager alert with unknown status", "status", alert.Status, "alertname", alert.Labels["alertname"])
+				continue
+			}
+			if err != nil {
+				// Alertmanager retries the whole notification, which is fine because reporting is idempotent
+				log.Error("Error handling alertmanager alert", "alertname", alert.Labels["alertname"], "error", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return mux
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Problem is an active problem as returned by the api
+type Problem struct {
+	ID        string `json:"id"`
+	Cluster   string `json:"cluster,omitempty"`
+	Type      string `json:"type"`
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+
+	Message string    `json:"message"`
+	Occured time.Time `json:"occured"`
+
+	Reported        bool `json:"reported"`
+	OccuredCounter  int  `json:"occuredCounter"`
+	ResolvedCounter int  `json:"resolvedCounter"`
+}
+
+// ResolvedProblem is a resolved problem as returned by the history api
+type ResolvedProblem struct {
+	Problem
+
+	Resolved time.Time `json:"resolved"`
+}
+
+// NewHandler creates a new http handler that serves GET /problems, GET /problems/history and GET /problems/{id}.
+// The problems function is used to retrieve the currently active problems and the history function to retrieve
+// the last resolved problems
+func NewHandler(problems func() map[string]*Problem, history func() []*ResolvedProblem) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/problems", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, problems())
+	})
+	mux.HandleFunc("/problems/history", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, history())
+	})
+	mux.HandleFunc("/problems/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Problem ids can contain slashes, so everything after the prefix is the id
+		problem, ok := problems()[strings.TrimPrefix(req.URL.Path, "/problems/")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "problem not found"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, problem)
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, obj interface{}) {
+	out, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(out)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testProblems() map[string]*Problem {
+	return map[string]*Problem{
+		"default/pod/status": {
+			ID:        "default/pod/status",
+			Type:      "PodStatus",
+			Kind:      "Pod",
+			Name:      "pod",
+			Namespace: "default",
+
+			Message: "Pod default/pod has critical status CrashLoopBackOff",
+			Occured: time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC),
+
+			Reported:       true,
+			OccuredCounter: 3,
+		},
+		"node/condition": {
+			ID:   "node/condition",
+			Type: "NodeCondition",
+			Kind: "Node",
+			Name: "node",
+
+			Message: "Node node is not ready",
+			Occured: time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func testHistory() []*ResolvedProblem {
+	return []*ResolvedProblem{
+		{
+			Problem: Problem{
+				ID:   "node/condition",
+				Type: "NodeCondition",
+				Kind: "Node",
+				Name: "node",
+
+				Message: "Node node is not ready",
+				Occured: time.Date(2020, 6, 6, 1, 0, 0, 0, time.UTC),
+			},
+			Resolved: time.Date(2020, 6, 6, 1, 30, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestListProblems(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewHandler(testProblems, testHistory).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/problems", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", recorder.Code)
+	} else if recorder.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected content type application/json, got %s", recorder.Header().Get("Content-Type"))
+	}
+
+	problems := map[string]*Problem{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &problems)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := testProblems()
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %d", len(expected), len(problems))
+	}
+	for id, problem := range expected {
+		if problems[id] == nil || *problems[id] != *problem {
+			t.Fatalf("Expected problem %#v, got %#v", problem, problems[id])
+		}
+	}
+}
+
+func TestGetProblem(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		expectedStatusCode int
+		expectedID         string
+	}{
+		{
+			name:               "id with slashes",
+			method:             http.MethodGet,
+			path:               "/problems/default/pod/status",
+			expectedStatusCode: http.StatusOK,
+			expectedID:         "default/pod/status",
+		},
+		{
+			name:               "node problem",
+			method:             http.MethodGet,
+			path:               "/problems/node/condition",
+			expectedStatusCode: http.StatusOK,
+			expectedID:         "node/condition",
+		},
+		{
+			name:               "not found",
+			method:             http.MethodGet,
+			path:               "/problems/default/other/status",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "method not allowed",
+			method:             http.MethodDelete,
+			path:               "/problems/node/condition",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		NewHandler(testProblems, testHistory).ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+
+		if recorder.Code != test.expectedStatusCode {
+			t.Fatalf("%s: expected status code %d, got %d", test.name, test.expectedStatusCode, recorder.Code)
+		} else if test.expectedID == "" {
+			continue
+		}
+
+		problem := &Problem{}
+		err := json.Unmarshal(recorder.Body.Bytes(), problem)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		} else if problem.ID != test.expectedID {
+			t.Fatalf("%s: expected problem %s, got %s", test.name, test.expectedID, problem.ID)
+		}
+	}
+}
+
+func TestListHistory(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewHandler(testProblems, testHistory).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/problems/history", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", recorder.Code)
+	}
+
+	history := []*ResolvedProblem{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &history)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := testHistory()
+	if len(history) != len(expected) {
+		t.Fatalf("Expected %d resolved problems, got %d", len(expected), len(history))
+	} else if *history[0] != *expected[0] {
+		t.Fatalf("Expected resolved problem %#v, got %#v", expected[0], history[0])
+	}
+}
@@ -0,0 +1,132 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+)
+
+const specVersion = "1.0"
+const eventSource = "/kube-problem"
+
+const (
+	eventTypeAlert   = "com.github.fabiankramm.kube-problem.problem.alert"
+	eventTypeResolve = "com.github.fabiankramm.kube-problem.problem.resolve"
+)
+
+// Client sends problems as cloudevents in structured json mode to a http sink
+type Client struct {
+	SinkURL string
+
+	httpClient *http.Client
+}
+
+// CloudEvent is a cloudevent as defined in the cloudevents v1.0 specification
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	Type            string    `json:"type"`
+	Source          string    `json:"source"`
+	ID              string    `json:"id"`
+	Time            time.Time `json:"time"`
+	Subject         string    `json:"subject,omitempty"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            *Data     `json:"data"`
+}
+
+// Data is the payload of a cloudevent
+type Data struct {
+	ProblemID   string    `json:"problemId"`
+	ProblemType string    `json:"problemType"`
+	Severity    string    `json:"severity"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Message     string    `json:"message"`
+	Occured     time.Time `json:"occured"`
+}
+
+// NewClient creates a new cloudevents client to use
+func NewClient(sinkURL string) (*Client, error) {
+	if sinkURL == "" {
+		return nil, errors.New("No cloudevents sink provided. Is env variable CLOUDEVENTS_SINK set?")
+	}
+
+	return &Client{
+		SinkURL:    sinkURL,
+		httpClient: &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+// Alert sends an alert event for the problem
+func (c *Client) Alert(p notify.Problem) error {
+	return c.sendEvent(newCloudEvent(eventTypeAlert, p))
+}
+
+// Resolve sends a resolve event for the problem
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.sendEvent(newCloudEvent(eventTypeResolve, p))
+}
+
+func newCloudEvent(eventType string, p notify.Problem) *CloudEvent {
+	now := time.Now()
+	return &CloudEvent{
+		SpecVersion: specVersion,
+		Type:        eventType,
+		Source:      eventSource,
+		// The id has to be unique per event, so alert and resolve of the same problem differ
+		ID:              p.ID + "-" + strconv.FormatInt(now.UnixNano(), 10),
+		Time:            now,
+		Subject:         p.Resource(),
+		DataContentType: "application/json",
+		Data: &Data{
+			ProblemID:   p.ID,
+			ProblemType: p.Type,
+			Severity:    p.Severity,
+			Kind:        p.Kind,
+			Name:        p.Name,
+			Namespace:   p.Namespace,
+			Message:     p.Message,
+			Occured:     p.Occured,
+		},
+	}
+}
+
+// sendEvent posts the event to the sink
+func (c *Client) sendEvent(e *CloudEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(nil, retry.DefaultPolicy, "sending to cloudevents sink", func() (bool, error) {
+		return c.post(body)
+	})
+}
+
+func (c *Client) post(body []byte) (bool, error) {
+	resp, err := c.httpClient.Post(c.SinkURL, "application/cloudevents+json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("cloudevents sink returned status code %d: %s", resp.StatusCode, string(out))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, err
+	}
+
+	return false, err
+}
@@ -0,0 +1,148 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "default/pod/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+	Occured:   time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC),
+}
+
+// newTestClient returns a client that posts to a test server, which records the raw events. The first failures
+// requests are answered with a 503
+func newTestClient(t *testing.T, failures int) (*Client, func() []map[string]interface{}) {
+	var (
+		events      []map[string]interface{}
+		eventsMutex sync.Mutex
+		requests    int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		eventsMutex.Lock()
+		defer eventsMutex.Unlock()
+
+		requests++
+		if requests <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/cloudevents+json" {
+			t.Errorf("Unexpected request %s with content type %s", req.Method, req.Header.Get("Content-Type"))
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		event := map[string]interface{}{}
+		err = json.Unmarshal(body, &event)
+		if err != nil {
+			t.Error(err)
+		}
+
+		events = append(events, event)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() []map[string]interface{} {
+		eventsMutex.Lock()
+		defer eventsMutex.Unlock()
+
+		return events
+	}
+}
+
+// validateEvent checks the event against the required and optional context attributes of the cloudevents v1.0
+// specification
+func validateEvent(t *testing.T, event map[string]interface{}) {
+	for _, attribute := range []string{"specversion", "id", "source", "type"} {
+		if value, ok := event[attribute].(string); !ok || value == "" {
+			t.Fatalf("Required attribute %s is missing or not a non-empty string in %v", attribute, event)
+		}
+	}
+	if event["specversion"] != "1.0" {
+		t.Fatalf("Expected specversion 1.0, got %v", event["specversion"])
+	}
+
+	eventTime, ok := event["time"].(string)
+	if !ok {
+		t.Fatalf("Expected attribute time to be a string, got %v", event["time"])
+	} else if _, err := time.Parse(time.RFC3339, eventTime); err != nil {
+		t.Fatalf("Expected attribute time to be a RFC 3339 timestamp: %v", err)
+	} else if event["datacontenttype"] != "application/json" {
+		t.Fatalf("Expected datacontenttype application/json, got %v", event["datacontenttype"])
+	} else if _, ok := event["data"].(map[string]interface{}); !ok {
+		t.Fatalf("Expected data to be a json object, got %v", event["data"])
+	}
+}
+
+func TestEventShape(t *testing.T) {
+	client, events := newTestClient(t, 0)
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Resolve(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := events()
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(sent))
+	}
+
+	for i, expectedType := range []string{eventTypeAlert, eventTypeResolve} {
+		event := sent[i]
+		validateEvent(t, event)
+		if event["type"] != expectedType {
+			t.Fatalf("Expected type %s, got %v", expectedType, event["type"])
+		} else if event["source"] != eventSource || event["subject"] != "Pod 'pod' in namespace 'default'" {
+			t.Fatalf("Unexpected source %v or subject %v", event["source"], event["subject"])
+		}
+
+		data := event["data"].(map[string]interface{})
+		if data["problemId"] != testProblem.ID || data["problemType"] != testProblem.Type || data["message"] != testProblem.Message {
+			t.Fatalf("Unexpected data %v", data)
+		} else if data["occured"] != "2020-06-06T02:00:00Z" {
+			t.Fatalf("Unexpected occured time %v", data["occured"])
+		}
+	}
+
+	if sent[0]["id"] == sent[1]["id"] {
+		t.Fatal("Expected alert and resolve events to have different ids")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	client, events := newTestClient(t, 2)
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events()) != 1 {
+		t.Fatalf("Expected the event to be delivered after two failures, got %d events", len(events()))
+	}
+}
@@ -0,0 +1,381 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/FabianKramm/kube-problem/pkg/runner"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultPath is the path the config file is loaded from if CONFIG_FILE is not set
+const DefaultPath = "/etc/kube-problem/config.yaml"
+
+// Config is the structure of the config file. Every field corresponds to an environment variable (see the env tag),
+// environment variables that are set take precedence over the values from the file
+type Config struct {
+	Log Log `yaml:"log"`
+	// KubeContext is the kube config context that is used outside of a cluster
+	KubeContext string `yaml:"kubeContext" env:"KUBE_CONTEXT"`
+	// KubeConfigs are the kube configs of the clusters that are watched instead of the current cluster
+	KubeConfigs []string `yaml:"kubeConfigs" env:"KUBECONFIGS" sep:","`
+	DryRun      DryRun   `yaml:"dryRun"`
+
+	Watch         Watch         `yaml:"watch"`
+	Checks        Checks        `yaml:"checks"`
+	KubernetesAPI KubernetesAPI `yaml:"kubernetesApi"`
+	Thresholds    Thresholds    `yaml:"thresholds"`
+	Timeouts      Timeouts      `yaml:"timeouts"`
+
+	// Severities maps problem types to critical, warning or info
+	Severities map[string]string `yaml:"severities"`
+	// ControlPlaneSeverity overwrites the severity of problems of control plane nodes
+	ControlPlaneSeverity string `yaml:"controlPlaneSeverity" env:"CONTROL_PLANE_SEVERITY" check:"severity"`
+	// CriticalPriorityClasses are the priority classes of pods whose problems are critical
+	CriticalPriorityClasses []string `yaml:"criticalPriorityClasses" env:"CRITICAL_PRIORITY_CLASSES" sep:","`
+	// QuietPeriods maps problem types to durations (e.g. 15m)
+	QuietPeriods map[string]string `yaml:"quietPeriods"`
+	// ProblemTTLs maps problem types to the duration after which their problems are removed (e.g. 60m)
+	ProblemTTLs map[string]string `yaml:"problemTTLs"`
+
+	MaintenanceWindows []string `yaml:"maintenanceWindows" env:"MAINTENANCE_WINDOWS" sep:"," check:"windows"`
+	AlertBatching      string   `yaml:"alertBatching" env:"ALERT_BATCHING" check:"bool"`
+	NodeGroupLabel     string   `yaml:"nodeGroupLabel" env:"NODE_GROUP_LABEL"`
+	ProblemHistorySize string   `yaml:"problemHistorySize" env:"PROBLEM_HISTORY_SIZE" check:"count"`
+	CheckOnce          string   `yaml:"checkOnce" env:"CHECK_ONCE" check:"bool"`
+
+	Slack       Slack       `yaml:"slack"`
+	PagerDuty   PagerDuty   `yaml:"pagerduty"`
+	OpsGenie    OpsGenie    `yaml:"opsgenie"`
+	Teams       Teams       `yaml:"teams"`
+	GoogleChat  GoogleChat  `yaml:"googleChat"`
+	Discord     Discord     `yaml:"discord"`
+	Webhook     Webhook     `yaml:"webhook"`
+	CloudEvents CloudEvents `yaml:"cloudEvents"`
+	PubSub      PubSub      `yaml:"pubsub"`
+	SNS         SNS         `yaml:"sns"`
+	SMTP        SMTP        `yaml:"smtp"`
+
+	Digest         Digest         `yaml:"digest"`
+	Ports          Ports          `yaml:"ports"`
+	LeaderElection LeaderElection `yaml:"leaderElection"`
+}
+
+// Log configures the logging
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" check:"logLevel"`
+	Format string `yaml:"format" env:"LOG_FORMAT" check:"logFormat"`
+}
+
+// DryRun configures the dry run mode
+type DryRun struct {
+	Enabled         string `yaml:"enabled" env:"DRY_RUN" check:"bool"`
+	SkipSlackVerify string `yaml:"skipSlackVerify" env:"DRY_RUN_SKIP_SLACK_VERIFY" check:"bool"`
+}
+
+// Watch configures which resources are watched
+type Watch struct {
+	Nodes               string            `yaml:"nodes" env:"WATCH_NODES" check:"bool"`
+	NodeSelector        string            `yaml:"nodeSelector" env:"WATCH_NODE_SELECTOR" check:"selector"`
+	Namespaces          []string          `yaml:"namespaces" env:"WATCH_NAMESPACES" sep:","`
+	LabelSelector       string            `yaml:"labelSelector" env:"WATCH_LABEL_SELECTOR" check:"selector"`
+	OptInAnnotation     string            `yaml:"optInAnnotation" env:"OPT_IN_ANNOTATION"`
+	CRDs                []string          `yaml:"crds" env:"WATCH_CRDS" sep:"," check:"crds"`
+	EventReasons        []string          `yaml:"eventReasons" env:"WATCH_EVENT_REASONS" sep:","`
+	IgnoreEventReasons  []string          `yaml:"ignoreEventReasons" env:"IGNORE_EVENT_REASONS" sep:","`
+	NamespaceWorkers    string            `yaml:"namespaceWorkers" env:"NAMESPACE_WORKERS" check:"count"`
+	NamespaceStatus     string            `yaml:"namespaceStatus" env:"WATCH_NAMESPACE_STATUS" check:"bool"`
+	NamespaceIntervals  map[string]string `yaml:"namespaceIntervals" env:"NAMESPACE_INTERVALS" sep:"," check:"namespaceIntervals"`
+	ReconnectMaxBackoff string            `yaml:"reconnectMaxBackoff" env:"WATCH_RECONNECT_MAX_BACKOFF" check:"duration"`
+}
+
+// Checks configures the optional checks
+type Checks struct {
+	ResourceLimits       string   `yaml:"resourceLimits" env:"CHECK_RESOURCE_LIMITS" check:"bool"`
+	LatestTag            string   `yaml:"latestTag" env:"CHECK_LATEST_TAG" check:"bool"`
+	IncludePodLogs       string   `yaml:"includePodLogs" env:"INCLUDE_POD_LOGS" check:"bool"`
+	PodLogLines          string   `yaml:"podLogLines" env:"POD_LOG_LINES" check:"count"`
+	MissingProbes        string   `yaml:"missingProbes" env:"CHECK_MISSING_PROBES" check:"bool"`
+	ResourceRatios       string   `yaml:"resourceRatios" env:"CHECK_RESOURCE_RATIOS" check:"bool"`
+	TLSSecrets           string   `yaml:"tlsSecrets" env:"CHECK_TLS_SECRETS" check:"bool"`
+	MinMemRatio          string   `yaml:"minMemRatio" env:"MIN_MEM_RATIO" check:"factor"`
+	MinCPURatio          string   `yaml:"minCpuRatio" env:"MIN_CPU_RATIO" check:"factor"`
+	ProbeCheckNamespaces []string `yaml:"probeCheckNamespaces" env:"PROBE_CHECK_NAMESPACES" sep:","`
+}
+
+// KubernetesAPI configures the retries of kubernetes api requests
+type KubernetesAPI struct {
+	MaxRetries  string `yaml:"maxRetries" env:"API_MAX_RETRIES" check:"count"`
+	RetryBaseMs string `yaml:"retryBaseMs" env:"API_RETRY_BASE_MS" check:"count"`
+}
+
+// Thresholds configures how often a problem has to occur and the resource usage ratios that are a problem
+type Thresholds struct {
+	NodeCondition    string `yaml:"nodeCondition" env:"NODE_CONDITION_THRESHOLD" check:"count"`
+	NodePressure     string `yaml:"nodePressure" env:"NODE_PRESSURE_THRESHOLD" check:"count"`
+	PodStatus        string `yaml:"podStatus" env:"POD_STATUS_THRESHOLD" check:"count"`
+	PodRestarts      string `yaml:"podRestarts" env:"POD_RESTARTS_THRESHOLD" check:"count"`
+	PodPending       string `yaml:"podPending" env:"POD_PENDING_THRESHOLD" check:"count"`
+	PodNoLimits      string `yaml:"podNoLimits" env:"POD_NO_LIMITS_THRESHOLD" check:"count"`
+	PodNoProbe       string `yaml:"podNoProbe" env:"POD_NO_PROBE_THRESHOLD" check:"count"`
+	CPUThrottling    string `yaml:"cpuThrottling" env:"CPU_THROTTLE_THRESHOLD_COUNT" check:"count"`
+	PodLatestTag     string `yaml:"podLatestTag" env:"POD_LATEST_TAG_THRESHOLD" check:"count"`
+	NoReadyEndpoints string `yaml:"noReadyEndpoints" env:"NO_READY_ENDPOINTS_THRESHOLD" check:"count"`
+
+	NodePressureResolve string `yaml:"nodePressureResolve" env:"NODE_PRESSURE_RESOLVE_THRESHOLD" check:"count"`
+	PodStatusResolve    string `yaml:"podStatusResolve" env:"POD_STATUS_RESOLVE_THRESHOLD" check:"count"`
+	PodPendingResolve   string `yaml:"podPendingResolve" env:"POD_PENDING_RESOLVE_THRESHOLD" check:"count"`
+
+	NodeCPU             string `yaml:"nodeCpu" env:"NODE_CPU_THRESHOLD" check:"ratio"`
+	NodeMemory          string `yaml:"nodeMemory" env:"NODE_MEM_THRESHOLD" check:"ratio"`
+	NodeDisk            string `yaml:"nodeDisk" env:"NODE_DISK_THRESHOLD" check:"ratio"`
+	NodeAllocatableSkew string `yaml:"nodeAllocatableSkew" env:"NODE_ALLOCATABLE_SKEW_THRESHOLD" check:"ratio"`
+	Quota               string `yaml:"quota" env:"QUOTA_ALERT_THRESHOLD" check:"ratio"`
+
+	CertWarnDays string `yaml:"certWarnDays" env:"CERT_WARN_DAYS" check:"count"`
+
+	OOMKillRate string `yaml:"oomKillRate" env:"OOMKILL_RATE_THRESHOLD" check:"count"`
+	NodeFlap    string `yaml:"nodeFlap" env:"NODE_FLAP_COUNT" check:"count"`
+}
+
+// Timeouts configures how long a problem has to exist before it is reported
+type Timeouts struct {
+	DeploymentStall      string `yaml:"deploymentStall" env:"DEPLOYMENT_STALL_TIMEOUT" check:"duration"`
+	StatefulSetDegraded  string `yaml:"statefulSetDegraded" env:"STATEFULSET_DEGRADED_TIMEOUT" check:"duration"`
+	DaemonSetUnavailable string `yaml:"daemonSetUnavailable" env:"DAEMONSET_UNAVAIL_TIMEOUT" check:"duration"`
+	HPAAtMax             string `yaml:"hpaAtMax" env:"HPA_AT_MAX_TIMEOUT" check:"duration"`
+	PVCPending           string `yaml:"pvcPending" env:"PVC_PENDING_TIMEOUT" check:"duration"`
+	PodTerminationBuffer string `yaml:"podTerminationBuffer" env:"POD_TERMINATION_BUFFER" check:"duration"`
+	PodUnready           string `yaml:"podUnready" env:"POD_UNREADY_TIMEOUT" check:"duration"`
+	NodeHeartbeat        string `yaml:"nodeHeartbeat" env:"NODE_HEARTBEAT_TIMEOUT" check:"duration"`
+	CronJobMaxDuration   string `yaml:"cronJobMaxDuration" env:"CRONJOB_MAX_DURATION" check:"duration"`
+	NamespaceTerminating string `yaml:"namespaceTerminating" env:"NS_TERMINATING_TIMEOUT" check:"duration"`
+	OOMKillRateWindow    string `yaml:"oomKillRateWindow" env:"OOMKILL_RATE_WINDOW" check:"duration"`
+	NodeFlapWindow       string `yaml:"nodeFlapWindow" env:"NODE_FLAP_WINDOW" check:"duration"`
+	Acknowledge          string `yaml:"acknowledge" env:"ACKNOWLEDGE_DURATION" check:"duration"`
+}
+
+// Slack configures the slack notifier
+type Slack struct {
+	Token                   string            `yaml:"token" env:"SLACK_TOKEN"`
+	Channel                 string            `yaml:"channel" env:"SLACK_CHANNEL"`
+	RichFormat              string            `yaml:"richFormat" env:"SLACK_RICH_FORMAT" check:"bool"`
+	Threads                 string            `yaml:"threads" env:"SLACK_THREADS" check:"bool"`
+	ResolveInThread         string            `yaml:"resolveInThread" env:"RESOLVE_IN_THREAD" check:"bool"`
+	SigningSecret           string            `yaml:"signingSecret" env:"SLACK_SIGNING_SECRET"`
+	CallbackPort            string            `yaml:"callbackPort" env:"SLACK_CALLBACK_PORT" check:"port"`
+	Routing                 map[string]string `yaml:"routing" env:"SLACK_ROUTING" sep:";" check:"routing"`
+	CircuitBreakerThreshold string            `yaml:"circuitBreakerThreshold" env:"SLACK_CIRCUIT_BREAKER_THRESHOLD" check:"count"`
+	CircuitBreakerTimeout   string            `yaml:"circuitBreakerTimeout" env:"SLACK_CIRCUIT_BREAKER_TIMEOUT" check:"duration"`
+	RetryMax                string            `yaml:"retryMax" env:"SLACK_RETRY_MAX" check:"count"`
+	RetryBaseMs             string            `yaml:"retryBaseMs" env:"SLACK_RETRY_BASE_MS" check:"count"`
+	RetryMaxMs              string            `yaml:"retryMaxMs" env:"SLACK_RETRY_MAX_MS" check:"count"`
+	GreetingConfigMap       string            `yaml:"greetingConfigMap" env:"GREETING_CONFIGMAP"`
+}
+
+// PagerDuty configures the pagerduty notifier
+type PagerDuty struct {
+	RoutingKey string `yaml:"routingKey" env:"PAGERDUTY_ROUTING_KEY"`
+}
+
+// OpsGenie configures the opsgenie notifier
+type OpsGenie struct {
+	APIKey string `yaml:"apiKey" env:"OPSGENIE_API_KEY"`
+	Team   string `yaml:"team" env:"OPSGENIE_TEAM"`
+}
+
+// Teams configures the microsoft teams notifier
+type Teams struct {
+	WebhookURL  string `yaml:"webhookUrl" env:"TEAMS_WEBHOOK_URL"`
+	MentionUser string `yaml:"mentionUser" env:"TEAMS_MENTION_USER"`
+}
+
+// GoogleChat configures the google chat notifier
+type GoogleChat struct {
+	Webhook string `yaml:"webhook" env:"GOOGLE_CHAT_WEBHOOK"`
+}
+
+// Discord configures the discord notifier
+type Discord struct {
+	WebhookURL string `yaml:"webhookUrl" env:"DISCORD_WEBHOOK_URL"`
+}
+
+// Webhook configures the generic webhook notifier
+type Webhook struct {
+	URL     string            `yaml:"url" env:"WEBHOOK_URL"`
+	Headers map[string]string `yaml:"headers" env:"WEBHOOK_HEADERS" sep:","`
+}
+
+// CloudEvents configures the cloudevents notifier
+type CloudEvents struct {
+	Sink string `yaml:"sink" env:"CLOUDEVENTS_SINK"`
+}
+
+// PubSub configures the google cloud pub/sub notifier
+type PubSub struct {
+	ProjectID string `yaml:"projectId" env:"PUBSUB_PROJECT_ID"`
+	TopicID   string `yaml:"topicId" env:"PUBSUB_TOPIC_ID"`
+}
+
+// SNS configures the aws sns notifier
+type SNS struct {
+	TopicARN string `yaml:"topicArn" env:"SNS_TOPIC_ARN"`
+	Region   string `yaml:"region" env:"AWS_REGION"`
+}
+
+// SMTP configures the email notifier
+type SMTP struct {
+	Host     string   `yaml:"host" env:"SMTP_HOST"`
+	Port     string   `yaml:"port" env:"SMTP_PORT" check:"port"`
+	User     string   `yaml:"user" env:"SMTP_USER"`
+	Password string   `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string   `yaml:"from" env:"SMTP_FROM"`
+	To       []string `yaml:"to" env:"SMTP_TO" sep:","`
+	TLS      string   `yaml:"tls" env:"SMTP_TLS" check:"bool"`
+}
+
+// Digest configures the weekly digest
+type Digest struct {
+	Enabled string `yaml:"enabled" env:"ENABLE_DIGEST" check:"bool"`
+	Day     string `yaml:"day" env:"DIGEST_DAY" check:"weekday"`
+	Hour    string `yaml:"hour" env:"DIGEST_HOUR" check:"hour"`
+}
+
+// Ports configures the ports of the http servers
+type Ports struct {
+	Metrics string `yaml:"metrics" env:"METRICS_PORT" check:"port"`
+	API     string `yaml:"api" env:"API_PORT" check:"port"`
+	Health  string `yaml:"health" env:"HEALTH_PORT" check:"port"`
+
+	AlertmanagerReceiver string `yaml:"alertmanagerReceiver" env:"ALERTMANAGER_RECEIVER_PORT" check:"port"`
+}
+
+// LeaderElection configures the leader election
+type LeaderElection struct {
+	Enabled string `yaml:"enabled" env:"LEADER_ELECTION_ENABLED" check:"bool"`
+}
+
+// Load reads the config file from the given path. A missing file is only an error if required is true
+func Load(path string, required bool) (*Config, error) {
+	config := &Config{}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return config, nil
+		}
+
+		return nil, fmt.Errorf("Error reading config file %s: %v", path, err)
+	}
+
+	err = yaml.UnmarshalStrict(out, config)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing config file %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// Apply sets the environment variables of all values from the config file that are not already set in the environment
+func (c *Config) Apply() error {
+	values, err := c.envValues()
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+
+		err := os.Setenv(name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envValues returns the values of all set fields by their environment variable name
+func (c *Config) envValues() (map[string]string, error) {
+	values := make(map[string]string)
+	collectEnvValues(reflect.ValueOf(c).Elem(), values)
+
+	for problemType, value := range c.Severities {
+		if !isProblemType(problemType) {
+			return nil, fmt.Errorf("Unknown problem type %s in severities (expected one of %s)", problemType, strings.Join(runner.ProblemTypes(), ", "))
+		}
+
+		values[runner.SeverityEnvName(problemType)] = value
+	}
+	for problemType, value := range c.QuietPeriods {
+		name, ok := runner.QuietPeriodEnvName(problemType)
+		if !ok {
+			return nil, fmt.Errorf("Unknown problem type %s in quietPeriods", problemType)
+		}
+
+		values[name] = value
+	}
+	for problemType, value := range c.ProblemTTLs {
+		if !isProblemType(problemType) {
+			return nil, fmt.Errorf("Unknown problem type %s in problemTTLs (expected one of %s)", problemType, strings.Join(runner.ProblemTypes(), ", "))
+		}
+
+		values[runner.ProblemTTLEnvName(problemType)] = value
+	}
+
+	return values, nil
+}
+
+func collectEnvValues(v reflect.Value, values map[string]string) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("env")
+		if field.Type.Kind() == reflect.Struct {
+			collectEnvValues(v.Field(i), values)
+			continue
+		} else if name == "" {
+			continue
+		}
+
+		value := ""
+		switch field.Type.Kind() {
+		case reflect.String:
+			value = v.Field(i).String()
+		case reflect.Slice:
+			value = strings.Join(v.Field(i).Interface().([]string), field.Tag.Get("sep"))
+		case reflect.Map:
+			value = joinPairs(v.Field(i).Interface().(map[string]string), field.Tag.Get("sep"))
+		}
+
+		if value != "" {
+			values[name] = value
+		}
+	}
+}
+
+// joinPairs joins the map to sorted key=value pairs
+func joinPairs(m map[string]string, sep string) string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, sep)
+}
+
+func isProblemType(name string) bool {
+	for _, problemType := range runner.ProblemTypes() {
+		if problemType == name {
+			return true
+		}
+	}
+
+	return false
+}
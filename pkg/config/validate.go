@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/maintenance"
+	"github.com/FabianKramm/kube-problem/pkg/runner"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// checks validate a non empty value of the given kind
+var checks = map[string]func(value string) error{
+	"bool": func(value string) error {
+		if value != "true" && value != "false" {
+			return fmt.Errorf("expected true or false")
+		}
+		return nil
+	},
+	"count": func(value string) error {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return fmt.Errorf("expected a number greater than 0")
+		}
+		return nil
+	},
+	"ratio": func(value string) error {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return fmt.Errorf("expected a value between 0.0 and 1.0")
+		}
+		return nil
+	},
+	"factor": func(value string) error {
+		factor, err := strconv.ParseFloat(value, 64)
+		if err != nil || factor <= 0 {
+			return fmt.Errorf("expected a number greater than 0")
+		}
+		return nil
+	},
+	"duration": func(value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("expected a duration like 5m")
+		}
+		return nil
+	},
+	"port": func(value string) error {
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("expected a port between 1 and 65535")
+		}
+		return nil
+	},
+	"hour": func(value string) error {
+		hour, err := strconv.Atoi(value)
+		if err != nil || hour < 0 || hour > 23 {
+			return fmt.Errorf("expected a number between 0 and 23")
+		}
+		return nil
+	},
+	"weekday": func(value string) error {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(weekday.String(), value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a weekday like Monday")
+	},
+	"logLevel": func(value string) error {
+		switch strings.ToLower(value) {
+		case "debug", "info", "warn", "warning", "error":
+			return nil
+		}
+		return fmt.Errorf("expected debug, info, warn or error")
+	},
+	"logFormat": func(value string) error {
+		switch strings.ToLower(value) {
+		case "text", "json":
+			return nil
+		}
+		return fmt.Errorf("expected text or json")
+	},
+	"selector": func(value string) error {
+		_, err := labels.Parse(value)
+		return err
+	},
+	"windows": func(value string) error {
+		_, err := maintenance.Parse(value)
+		return err
+	},
+	"routing": func(value string) error {
+		return runner.ValidateSlackRouting(value)
+	},
+	"crds": func(value string) error {
+		for _, tuple := range strings.Split(value, ",") {
+			splitted := strings.Split(strings.TrimSpace(tuple), "/")
+			if len(splitted) != 3 || splitted[1] == "" || splitted[2] == "" {
+				return fmt.Errorf("expected group/version/resource instead of '%s'", tuple)
+			}
+		}
+		return nil
+	},
+	"namespaceIntervals": func(value string) error {
+		for _, pair := range strings.Split(value, ",") {
+			splitted := strings.Split(strings.TrimSpace(pair), "=")
+			if len(splitted) != 2 {
+				return fmt.Errorf("expected namespace=seconds instead of '%s'", pair)
+			}
+
+			seconds, err := strconv.Atoi(strings.TrimSpace(splitted[1]))
+			if err != nil || seconds < 1 || seconds > 3600 {
+				return fmt.Errorf("expected seconds between 1 and 3600 instead of '%s'", pair)
+			}
+		}
+		return nil
+	},
+	"severity": func(value string) error {
+		switch strings.ToLower(value) {
+		case "critical", "warning", "info":
+			return nil
+		}
+		return fmt.Errorf("expected critical, warning or info")
+	},
+}
+
+// Validate checks the configuration in the environment (after the config file was applied) and returns
+// an error for every invalid value
+func Validate() []error {
+	errs := []error{}
+	validateFields(reflect.TypeOf(Config{}), &errs)
+
+	for _, problemType := range runner.ProblemTypes() {
+		validateEnv(runner.SeverityEnvName(problemType), "severity", &errs)
+		validateEnv(runner.ProblemTTLEnvName(problemType), "duration", &errs)
+		if name, ok := runner.QuietPeriodEnvName(problemType); ok {
+			validateEnv(name, "duration", &errs)
+		}
+	}
+
+	return errs
+}
+
+func validateFields(t reflect.Type, errs *[]error) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			validateFields(field.Type, errs)
+		} else if field.Tag.Get("env") != "" && field.Tag.Get("check") != "" {
+			validateEnv(field.Tag.Get("env"), field.Tag.Get("check"), errs)
+		}
+	}
+}
+
+func validateEnv(name, check string, errs *[]error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+
+	err := checks[check](value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("Invalid value '%s' for %s: %v", value, name, err))
+	}
+}
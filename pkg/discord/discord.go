@@ -0,0 +1,130 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+)
+
+const (
+	colorProblem = 0xFF0000
+	colorResolve = 0x00FF00
+)
+
+// maxFieldLength is the maximum length of an embed field value accepted by discord
+const maxFieldLength = 1024
+
+// Client is the discord webhook client struct
+type Client struct {
+	WebhookURL string
+
+	httpClient *http.Client
+}
+
+type message struct {
+	Username string  `json:"username"`
+	Content  string  `json:"content"`
+	Embeds   []embed `json:"embeds"`
+}
+
+type embed struct {
+	Title     string       `json:"title"`
+	Color     int          `json:"color"`
+	Fields    []embedField `json:"fields"`
+	Timestamp string       `json:"timestamp"`
+}
+
+type embedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// NewClient creates a new discord client to use
+func NewClient(webhookURL string) (*Client, error) {
+	if webhookURL == "" {
+		return nil, errors.New("No discord webhook url provided. Is env variable DISCORD_WEBHOOK_URL set?")
+	}
+
+	return &Client{
+		WebhookURL: webhookURL,
+		httpClient: &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+// Alert sends a red problem embed to the webhook
+func (c *Client) Alert(p notify.Problem) error {
+	return c.sendMessage(newMessage(fmt.Sprintf("Problem with %s (%s)", p.Resource(), p.Severity), colorProblem, p))
+}
+
+// Resolve sends a green resolve embed to the webhook
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.sendMessage(newMessage(fmt.Sprintf("Resolved problem with %s", p.Resource()), colorResolve, p))
+}
+
+func newMessage(title string, color int, p notify.Problem) *message {
+	fields := []embedField{
+		{Name: "Resource", Value: fmt.Sprintf("%s/%s", p.Kind, p.Name), Inline: true},
+	}
+	if p.Namespace != "" {
+		fields = append(fields, embedField{Name: "Namespace", Value: p.Namespace, Inline: true})
+	}
+
+	problemMessage := p.Message
+	if len(problemMessage) > maxFieldLength {
+		problemMessage = problemMessage[:maxFieldLength-3] + "..."
+	}
+	fields = append(fields, embedField{Name: "Message", Value: problemMessage})
+
+	return &message{
+		Username: "kube-problem",
+		Content:  title,
+		Embeds: []embed{
+			{
+				Title:     title,
+				Color:     color,
+				Fields:    fields,
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	}
+}
+
+// sendMessage posts the message to the webhook. Rate limited requests are retried with an increasing backoff
+func (c *Client) sendMessage(m *message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(nil, retry.DefaultPolicy, "sending to discord", func() (bool, error) {
+		return c.post(body)
+	})
+}
+
+func (c *Client) post(body []byte) (bool, error) {
+	resp, err := c.httpClient.Post(c.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("discord returned status code %d: %s", resp.StatusCode, string(out))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, err
+	}
+
+	return false, err
+}
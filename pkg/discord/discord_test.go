@@ -0,0 +1,140 @@
+package discord
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "pod/default/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Severity:  "critical",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+}
+
+// newTestClient returns a client that posts to a mock discord webhook, which records the decoded messages
+func newTestClient(t *testing.T) (*Client, func() []message) {
+	var (
+		messages      []message
+		messagesMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s with content type %s", req.Method, req.Header.Get("Content-Type"))
+		}
+
+		msg := message{}
+		err := json.NewDecoder(req.Body).Decode(&msg)
+		if err != nil {
+			t.Error(err)
+		}
+
+		messagesMutex.Lock()
+		messages = append(messages, msg)
+		messagesMutex.Unlock()
+
+		// Discord answers webhook executions without ?wait=true with 204
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() []message {
+		messagesMutex.Lock()
+		defer messagesMutex.Unlock()
+
+		return messages
+	}
+}
+
+func TestEmbedPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		send          func(c *Client) error
+		expectedTitle string
+		expectedColor int
+	}{
+		{
+			name: "alert",
+			send: func(c *Client) error {
+				return c.Alert(testProblem)
+			},
+			expectedTitle: "Problem with Pod 'pod' in namespace 'default' (critical)",
+			expectedColor: 0xFF0000,
+		},
+		{
+			name: "resolve",
+			send: func(c *Client) error {
+				return c.Resolve(testProblem)
+			},
+			expectedTitle: "Resolved problem with Pod 'pod' in namespace 'default'",
+			expectedColor: 0x00FF00,
+		},
+	}
+
+	for _, test := range tests {
+		client, messages := newTestClient(t)
+		err := test.send(client)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		sent := messages()
+		if len(sent) != 1 || len(sent[0].Embeds) != 1 {
+			t.Fatalf("%s: expected a single message with a single embed, got %#v", test.name, sent)
+		} else if sent[0].Username != "kube-problem" || sent[0].Content != test.expectedTitle {
+			t.Fatalf("%s: unexpected username %s or content %s", test.name, sent[0].Username, sent[0].Content)
+		}
+
+		embed := sent[0].Embeds[0]
+		if embed.Title != test.expectedTitle || embed.Color != test.expectedColor {
+			t.Fatalf("%s: expected title %s with color %x, got %s with color %x", test.name, test.expectedTitle, test.expectedColor, embed.Title, embed.Color)
+		} else if _, err := time.Parse(time.RFC3339, embed.Timestamp); err != nil {
+			t.Fatalf("%s: expected a RFC 3339 timestamp: %v", test.name, err)
+		}
+
+		expectedFields := []embedField{
+			{Name: "Resource", Value: "Pod/pod", Inline: true},
+			{Name: "Namespace", Value: "default", Inline: true},
+			{Name: "Message", Value: testProblem.Message},
+		}
+		if len(embed.Fields) != len(expectedFields) {
+			t.Fatalf("%s: expected fields %#v, got %#v", test.name, expectedFields, embed.Fields)
+		}
+		for i := range expectedFields {
+			if embed.Fields[i] != expectedFields[i] {
+				t.Fatalf("%s: expected field %#v, got %#v", test.name, expectedFields[i], embed.Fields[i])
+			}
+		}
+	}
+}
+
+func TestLongMessage(t *testing.T) {
+	client, messages := newTestClient(t)
+	err := client.Alert(notify.Problem{ID: "node/condition", Kind: "Node", Name: "node", Message: strings.Repeat("a", 2000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := messages()[0].Embeds[0].Fields
+	if len(fields) != 2 {
+		t.Fatalf("Expected no namespace field for a node, got %#v", fields)
+	} else if len(fields[1].Value) != maxFieldLength || !strings.HasSuffix(fields[1].Value, "...") {
+		t.Fatalf("Expected the message to be truncated to %d characters, got %d", maxFieldLength, len(fields[1].Value))
+	}
+}
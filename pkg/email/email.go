@@ -0,0 +1,158 @@
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+// SMTPNotifier sends problems as plain text emails through a smtp server
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	From     string
+	To       []string
+
+	// TLS connects with implicit tls (usually port 465), otherwise STARTTLS is used if the server supports it
+	TLS bool
+}
+
+// NewSMTPNotifier creates a new smtp notifier to use, to is a comma separated list of recipients
+func NewSMTPNotifier(host, port, user, password, from, to string, useTLS bool) (*SMTPNotifier, error) {
+	if host == "" {
+		return nil, errors.New("No smtp host provided. Is env variable SMTP_HOST set?")
+	} else if from == "" {
+		return nil, errors.New("No sender provided. Is env variable SMTP_FROM set?")
+	}
+
+	recipients := []string{}
+	for _, recipient := range strings.Split(to, ",") {
+		recipient = strings.TrimSpace(recipient)
+		if recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("No recipients provided. Is env variable SMTP_TO set?")
+	}
+
+	if port == "" {
+		port = "587"
+		if useTLS {
+			port = "465"
+		}
+	}
+
+	return &SMTPNotifier{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		Password: password,
+		From:     from,
+		To:       recipients,
+		TLS:      useTLS,
+	}, nil
+}
+
+// Alert sends a problem email
+func (s *SMTPNotifier) Alert(p notify.Problem) error {
+	body := fmt.Sprintf("There seems to be a problem with %s:\n\n%s\n\nType: %s\nSeverity: %s\nOccured: %s\n", p.Resource(), p.Message, p.Type, p.Severity, p.Occured.Format(time.RFC1123))
+	return s.send(subject(p.Severity, p), body)
+}
+
+// Resolve sends a resolve email
+func (s *SMTPNotifier) Resolve(p notify.Problem) error {
+	body := fmt.Sprintf("Good news, the problem with %s is resolved:\n\n%s\n", p.Resource(), p.Message)
+	return s.send(subject("resolved", p), body)
+}
+
+// subject returns the subject line [kube-problem] {prefix} - {kind}/{name} in {namespace}
+func subject(prefix string, p notify.Problem) string {
+	subject := fmt.Sprintf("[kube-problem] %s - %s/%s", prefix, p.Kind, p.Name)
+	if p.Namespace != "" {
+		subject += " in " + p.Namespace
+	}
+
+	return subject
+}
+
+func (s *SMTPNotifier) send(subject, body string) error {
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", stripNewlines(subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	var auth smtp.Auth
+	if s.User != "" {
+		auth = smtp.PlainAuth("", s.User, s.Password, s.Host)
+	}
+
+	addr := net.JoinHostPort(s.Host, s.Port)
+	if !s.TLS {
+		return smtp.SendMail(addr, auth, s.From, s.To, msg.Bytes())
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second * 30}, "tcp", addr, &tls.Config{ServerName: s.Host})
+	if err != nil {
+		return fmt.Errorf("Error connecting to smtp server %s: %v", addr, err)
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(s.From)
+	if err != nil {
+		return err
+	}
+	for _, recipient := range s.To {
+		err = client.Rcpt(recipient)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(msg.Bytes())
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func stripNewlines(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,282 @@
+package email
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "default/pod/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Severity:  "critical",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+	Occured:   time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC),
+}
+
+// mail is a mail received by the mock smtp server
+type mail struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// mockSMTPServer is a minimal in-process smtp server that records the received mails
+type mockSMTPServer struct {
+	listener net.Listener
+
+	mailsMutex sync.Mutex
+	mails      []mail
+}
+
+func newMockSMTPServer(t *testing.T) *mockSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &mockSMTPServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *mockSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	current := mail{}
+	reply("220 localhost ESMTP mock")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			fields := strings.Fields(line)
+			if len(fields) != 3 || fields[1] != "PLAIN" {
+				reply("504 unsupported authentication mechanism")
+				continue
+			}
+
+			decoded, _ := base64.StdEncoding.DecodeString(fields[2])
+			current.auth = string(decoded)
+			reply("235 authenticated")
+		case "MAIL":
+			current.from = strings.Trim(strings.TrimPrefix(line[len("MAIL "):], "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			current.to = append(current.to, strings.Trim(strings.TrimPrefix(line[len("RCPT "):], "TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+
+			data := &strings.Builder{}
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				} else if dataLine == ".\r\n" {
+					break
+				}
+
+				data.WriteString(dataLine)
+			}
+
+			current.data = data.String()
+			s.mailsMutex.Lock()
+			s.mails = append(s.mails, current)
+			s.mailsMutex.Unlock()
+
+			current = mail{}
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *mockSMTPServer) receivedMails() []mail {
+	s.mailsMutex.Lock()
+	defer s.mailsMutex.Unlock()
+
+	return s.mails
+}
+
+func (s *mockSMTPServer) newNotifier(t *testing.T, user, password string) *SMTPNotifier {
+	host, port, err := net.SplitHostPort(s.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier, err := NewSMTPNotifier(host, port, user, password, "kube-problem@example.com", "oncall@example.com, team@example.com", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return notifier
+}
+
+// headers returns the headers of the raw mail data
+func headers(data string) map[string]string {
+	headers := make(map[string]string)
+	for _, line := range strings.Split(strings.SplitN(data, "\r\n\r\n", 2)[0], "\r\n") {
+		splitted := strings.SplitN(line, ": ", 2)
+		if len(splitted) == 2 {
+			headers[splitted[0]] = splitted[1]
+		}
+	}
+
+	return headers
+}
+
+func TestAlertMail(t *testing.T) {
+	server := newMockSMTPServer(t)
+	err := server.newNotifier(t, "", "").Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mails := server.receivedMails()
+	if len(mails) != 1 {
+		t.Fatalf("Expected 1 mail, got %d", len(mails))
+	}
+
+	mail := mails[0]
+	if mail.from != "kube-problem@example.com" {
+		t.Fatalf("Unexpected sender %s", mail.from)
+	} else if strings.Join(mail.to, ",") != "oncall@example.com,team@example.com" {
+		t.Fatalf("Unexpected recipients %v", mail.to)
+	} else if mail.auth != "" {
+		t.Fatalf("Expected no authentication without a user, got %s", mail.auth)
+	}
+
+	mailHeaders := headers(mail.data)
+	if mailHeaders["Subject"] != "[kube-problem] critical - Pod/pod in default" {
+		t.Fatalf("Unexpected subject %s", mailHeaders["Subject"])
+	} else if mailHeaders["To"] != "oncall@example.com, team@example.com" {
+		t.Fatalf("Unexpected to header %s", mailHeaders["To"])
+	} else if mailHeaders["Content-Type"] != "text/plain; charset=\"utf-8\"" {
+		t.Fatalf("Unexpected content type %s", mailHeaders["Content-Type"])
+	} else if !strings.Contains(mail.data, "There seems to be a problem with Pod 'pod' in namespace 'default':\r\n\r\nPod has critical status 'CrashLoopBackOff'") {
+		t.Fatalf("Unexpected body:\n%s", mail.data)
+	}
+}
+
+func TestResolveMail(t *testing.T) {
+	server := newMockSMTPServer(t)
+	err := server.newNotifier(t, "user", "password").Resolve(notify.Problem{ID: "node/condition", Kind: "Node", Name: "node", Message: "Node is not ready"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mails := server.receivedMails()
+	if len(mails) != 1 {
+		t.Fatalf("Expected 1 mail, got %d", len(mails))
+	} else if mails[0].auth != "\x00user\x00password" {
+		t.Fatalf("Expected plain authentication with the smtp user, got %q", mails[0].auth)
+	} else if subject := headers(mails[0].data)["Subject"]; subject != "[kube-problem] resolved - Node/node" {
+		t.Fatalf("Unexpected subject %s", subject)
+	}
+}
+
+func TestNewSMTPNotifier(t *testing.T) {
+	tests := []struct {
+		name         string
+		host         string
+		port         string
+		from         string
+		to           string
+		useTLS       bool
+		expectedPort string
+		expectedErr  bool
+	}{
+		{
+			name:         "default port",
+			host:         "smtp.example.com",
+			from:         "kube-problem@example.com",
+			to:           "oncall@example.com",
+			expectedPort: "587",
+		},
+		{
+			name:         "default tls port",
+			host:         "smtp.example.com",
+			from:         "kube-problem@example.com",
+			to:           "oncall@example.com",
+			useTLS:       true,
+			expectedPort: "465",
+		},
+		{
+			name:         "custom port",
+			host:         "smtp.example.com",
+			port:         "2525",
+			from:         "kube-problem@example.com",
+			to:           "oncall@example.com",
+			expectedPort: "2525",
+		},
+		{
+			name:        "missing host",
+			from:        "kube-problem@example.com",
+			to:          "oncall@example.com",
+			expectedErr: true,
+		},
+		{
+			name:        "missing recipients",
+			host:        "smtp.example.com",
+			from:        "kube-problem@example.com",
+			to:          " , ",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		notifier, err := NewSMTPNotifier(test.host, test.port, "", "", test.from, test.to, test.useTLS)
+		if test.expectedErr {
+			if err == nil {
+				t.Fatalf("%s: expected an error", test.name)
+			}
+
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if notifier.Port != test.expectedPort {
+			t.Fatalf("%s: expected port %s, got %s", test.name, test.expectedPort, notifier.Port)
+		}
+	}
+}
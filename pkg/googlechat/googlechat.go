@@ -0,0 +1,144 @@
+package googlechat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+)
+
+const (
+	colorProblem = "#d93025"
+	colorResolve = "#188038"
+)
+
+// Client is the google chat client struct
+type Client struct {
+	WebhookURL string
+
+	httpClient *http.Client
+}
+
+type message struct {
+	Text    string   `json:"text"`
+	CardsV2 []cardV2 `json:"cardsV2"`
+}
+
+type cardV2 struct {
+	CardID string `json:"cardId"`
+	Card   card   `json:"card"`
+}
+
+type card struct {
+	Header   cardHeader    `json:"header"`
+	Sections []cardSection `json:"sections"`
+}
+
+type cardHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+type cardSection struct {
+	Widgets []cardWidget `json:"widgets"`
+}
+
+type cardWidget struct {
+	TextParagraph *textParagraph `json:"textParagraph,omitempty"`
+}
+
+type textParagraph struct {
+	Text string `json:"text"`
+}
+
+// NewClient creates a new google chat client to use
+func NewClient(webhookURL string) (*Client, error) {
+	if webhookURL == "" {
+		return nil, errors.New("No google chat webhook url provided. Is env variable GOOGLE_CHAT_WEBHOOK set?")
+	}
+
+	return &Client{
+		WebhookURL: webhookURL,
+		httpClient: &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+// Alert sends a red problem card to the space
+func (c *Client) Alert(p notify.Problem) error {
+	return c.sendMessage(newMessage(p, fmt.Sprintf("There seems to be a problem with %s", p.Resource()), "Problem", colorProblem))
+}
+
+// Resolve sends a green resolve card to the space
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.sendMessage(newMessage(p, fmt.Sprintf("The problem with %s is resolved", p.Resource()), "Resolved", colorResolve))
+}
+
+// newMessage creates a card message. Card headers cannot be colored, so the status line below the header is
+func newMessage(p notify.Problem, title, status, color string) *message {
+	return &message{
+		Text: title,
+		CardsV2: []cardV2{
+			{
+				CardID: p.ID,
+				Card: card{
+					Header: cardHeader{
+						Title:    title,
+						Subtitle: p.Type,
+					},
+					Sections: []cardSection{
+						{
+							Widgets: []cardWidget{
+								{TextParagraph: &textParagraph{Text: fmt.Sprintf("<font color=\"%s\"><b>%s</b></font>", color, status)}},
+								{TextParagraph: &textParagraph{Text: html.EscapeString(p.Message)}},
+							},
+						},
+						{
+							Widgets: []cardWidget{
+								{TextParagraph: &textParagraph{Text: fmt.Sprintf("<font color=\"#80868b\">Occured at %s</font>", p.Occured.Format(time.RFC3339))}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// sendMessage posts the message to the webhook. Rate limited requests are retried with an increasing backoff
+func (c *Client) sendMessage(m *message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(nil, retry.DefaultPolicy, "sending to google chat", func() (bool, error) {
+		return c.post(body)
+	})
+}
+
+func (c *Client) post(body []byte) (bool, error) {
+	resp, err := c.httpClient.Post(c.WebhookURL, "application/json; charset=UTF-8", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("google chat returned status code %d: %s", resp.StatusCode, string(out))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, err
+	}
+
+	return false, err
+}
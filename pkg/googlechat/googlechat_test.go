@@ -0,0 +1,153 @@
+package googlechat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "default/pod/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Message:   "Pod has critical status <CrashLoopBackOff>",
+	Occured:   time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC),
+}
+
+// newTestClient returns a client that posts to a mock google chat server, which records the decoded messages.
+// The responses are answered with the given status codes in order and with 200 afterwards
+func newTestClient(t *testing.T, statusCodes ...int) (*Client, func() (int, []message)) {
+	var (
+		messages      []message
+		requests      int
+		messagesMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		messagesMutex.Lock()
+		defer messagesMutex.Unlock()
+
+		requests++
+		if requests <= len(statusCodes) {
+			w.WriteHeader(statusCodes[requests-1])
+			return
+		}
+
+		if req.Method != http.MethodPost || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+			t.Errorf("Unexpected request %s with content type %s", req.Method, req.Header.Get("Content-Type"))
+		}
+
+		msg := message{}
+		err := json.NewDecoder(req.Body).Decode(&msg)
+		if err != nil {
+			t.Error(err)
+		}
+
+		messages = append(messages, msg)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() (int, []message) {
+		messagesMutex.Lock()
+		defer messagesMutex.Unlock()
+
+		return requests, messages
+	}
+}
+
+func TestCardPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		send          func(c *Client) error
+		expectedTitle string
+		expectedColor string
+	}{
+		{
+			name: "alert",
+			send: func(c *Client) error {
+				return c.Alert(testProblem)
+			},
+			expectedTitle: "There seems to be a problem with Pod 'pod' in namespace 'default'",
+			expectedColor: colorProblem,
+		},
+		{
+			name: "resolve",
+			send: func(c *Client) error {
+				return c.Resolve(testProblem)
+			},
+			expectedTitle: "The problem with Pod 'pod' in namespace 'default' is resolved",
+			expectedColor: colorResolve,
+		},
+	}
+
+	for _, test := range tests {
+		client, messages := newTestClient(t)
+		err := test.send(client)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		_, sent := messages()
+		if len(sent) != 1 || len(sent[0].CardsV2) != 1 {
+			t.Fatalf("%s: expected a single message with a single card, got %#v", test.name, sent)
+		}
+
+		msg := sent[0]
+		card := msg.CardsV2[0].Card
+		if msg.Text != test.expectedTitle || card.Header.Title != test.expectedTitle || card.Header.Subtitle != "PodStatus" {
+			t.Fatalf("%s: unexpected text %s or header %#v", test.name, msg.Text, card.Header)
+		} else if msg.CardsV2[0].CardID != testProblem.ID {
+			t.Fatalf("%s: unexpected card id %s", test.name, msg.CardsV2[0].CardID)
+		} else if len(card.Sections) != 2 || len(card.Sections[0].Widgets) != 2 || len(card.Sections[1].Widgets) != 1 {
+			t.Fatalf("%s: expected a body and a footer section, got %#v", test.name, card.Sections)
+		}
+
+		status := card.Sections[0].Widgets[0].TextParagraph.Text
+		if !strings.Contains(status, test.expectedColor) {
+			t.Fatalf("%s: expected the status line to be colored %s, got %s", test.name, test.expectedColor, status)
+		} else if text := card.Sections[0].Widgets[1].TextParagraph.Text; text != "Pod has critical status &lt;CrashLoopBackOff&gt;" {
+			t.Fatalf("%s: expected the escaped problem message, got %s", test.name, text)
+		} else if footer := card.Sections[1].Widgets[0].TextParagraph.Text; !strings.Contains(footer, "2020-06-06T02:00:00Z") {
+			t.Fatalf("%s: expected the timestamp in the footer, got %s", test.name, footer)
+		}
+	}
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	client, messages := newTestClient(t, http.StatusTooManyRequests)
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests, sent := messages()
+	if requests != 2 || len(sent) != 1 {
+		t.Fatalf("Expected the message to be sent after a rate limited request, got %d requests and %d messages", requests, len(sent))
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	client, messages := newTestClient(t, http.StatusBadRequest)
+	err := client.Alert(testProblem)
+	if err == nil {
+		t.Fatal("Expected an error for a bad request")
+	}
+
+	requests, _ := messages()
+	if requests != 1 {
+		t.Fatalf("Expected a bad request not to be retried, got %d requests", requests)
+	}
+}
@@ -0,0 +1,34 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FabianKramm/kube-problem/pkg/version"
+)
+
+// NewHandler creates a new http handler that serves /healthz, /readyz and the build metadata at /version.
+// The ready function is used to determine if the process is ready
+func NewHandler(ready func() bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		if !ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("not ready"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/version", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(version.Get())
+	})
+
+	return mux
+}
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadyz(t *testing.T) {
+	var ready int32
+	server := httptest.NewServer(NewHandler(func() bool {
+		return atomic.LoadInt32(&ready) == 1
+	}))
+	defer server.Close()
+
+	expectStatus := func(path string, expected int) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("Expected status %d for %s, got %d", expected, path, resp.StatusCode)
+		}
+	}
+
+	expectStatus("/healthz", http.StatusOK)
+	expectStatus("/readyz", http.StatusServiceUnavailable)
+
+	// The first check cycle completed
+	atomic.StoreInt32(&ready, 1)
+	expectStatus("/healthz", http.StatusOK)
+	expectStatus("/readyz", http.StatusOK)
+}
@@ -1,64 +1,103 @@
 package kube
 
 import (
+	"os"
+
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-// Client is the kubernetes client that holds the rest config and clientset
+// Client is the kubernetes client that holds the rest config, clientset and dynamic client
 type Client interface {
 	Config() *rest.Config
-	Client() *kubernetes.Clientset
+	Client() kubernetes.Interface
+	Dynamic() dynamic.Interface
 }
 
 type client struct {
-	config *rest.Config
-	client *kubernetes.Clientset
+	config  *rest.Config
+	client  kubernetes.Interface
+	dynamic dynamic.Interface
 }
 
 func (c *client) Config() *rest.Config {
 	return c.config
 }
 
-func (c *client) Client() *kubernetes.Clientset {
+func (c *client) Client() kubernetes.Interface {
 	return c.client
 }
 
-// GetInClusterClient retrieves a new kubernetes clientset
-func GetInClusterClient() (Client, error) {
-	config, err := rest.InClusterConfig()
+func (c *client) Dynamic() dynamic.Interface {
+	return c.dynamic
+}
+
+// newClient creates the clientset and dynamic client for the config
+func newClient(config *rest.Config) (*client, error) {
+	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
-	// creates the clientset
-	clientset, err := kubernetes.NewForConfig(config)
+	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &client{
-		config: config,
-		client: clientset,
+		config:  config,
+		client:  clientset,
+		dynamic: dynamicClient,
 	}, nil
 }
 
-// GetDefaultClient retrieves the default config client
-func GetDefaultClient() (Client, error) {
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+// GetInClusterClient retrieves a new kubernetes clientset
+func GetInClusterClient() (Client, error) {
+	config, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	// creates the clientset
-	clientset, err := kubernetes.NewForConfig(config)
+	return newClient(config)
+}
+
+// GetDefaultClient retrieves the default config client. If the KUBECONFIG environment variable
+// is set, the kube config at that path is used. If KUBE_CONTEXT is set, that context is used instead
+// of the current context
+func GetDefaultClient() (Client, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if os.Getenv("KUBECONFIG") != "" {
+		loadingRules.ExplicitPath = os.Getenv("KUBECONFIG")
+	}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: os.Getenv("KUBE_CONTEXT")}).ClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return &client{
-		config: config,
-		client: clientset,
-	}, nil
+	return newClient(config)
+}
+
+// GetKubeConfigClient retrieves a client for the current context of the kube config at the given path and
+// returns the name of that context
+func GetKubeConfigClient(path string) (Client, string, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: path}, &clientcmd.ConfigOverrides{})
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	c, err := newClient(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return c, rawConfig.CurrentContext, nil
 }
@@ -0,0 +1,136 @@
+package kube
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://kube.example.com:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test-token
+`
+
+func TestGetDefaultClientKubeConfig(t *testing.T) {
+	dir := t.TempDir()
+	kubeConfig := filepath.Join(dir, "config")
+	err := ioutil.WriteFile(kubeConfig, []byte(testKubeConfig), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the default kube config in the home directory is not used
+	t.Setenv("HOME", dir)
+	t.Setenv("KUBECONFIG", kubeConfig)
+
+	client, err := GetDefaultClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := client.Config()
+	if config.Host != "https://kube.example.com:6443" {
+		t.Fatalf("Expected the server of the kube config at KUBECONFIG, got %s", config.Host)
+	} else if config.BearerToken != "test-token" {
+		t.Fatalf("Expected the token of the kube config at KUBECONFIG, got %s", config.BearerToken)
+	} else if client.Client() == nil {
+		t.Fatal("Expected a clientset")
+	}
+
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "missing"))
+	_, err = GetDefaultClient()
+	if err == nil {
+		t.Fatal("Expected an error for a missing kube config")
+	}
+}
+
+const multiContextKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: production
+  cluster:
+    server: https://production.example.com:6443
+- name: staging
+  cluster:
+    server: https://staging.example.com:6443
+contexts:
+- name: production
+  context:
+    cluster: production
+    user: admin
+- name: staging
+  context:
+    cluster: staging
+    user: admin
+current-context: production
+users:
+- name: admin
+  user:
+    token: admin-token
+`
+
+func TestGetDefaultClientContext(t *testing.T) {
+	dir := t.TempDir()
+	kubeConfig := filepath.Join(dir, "config")
+	err := ioutil.WriteFile(kubeConfig, []byte(multiContextKubeConfig), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("HOME", dir)
+	t.Setenv("KUBECONFIG", kubeConfig)
+
+	tests := []struct {
+		name         string
+		context      string
+		expectedHost string
+		expectedErr  bool
+	}{
+		{
+			name:         "current context",
+			expectedHost: "https://production.example.com:6443",
+		},
+		{
+			name:         "named context",
+			context:      "staging",
+			expectedHost: "https://staging.example.com:6443",
+		},
+		{
+			name:        "missing context",
+			context:     "development",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Setenv("KUBE_CONTEXT", test.context)
+
+		client, err := GetDefaultClient()
+		if test.expectedErr {
+			if err == nil {
+				t.Fatalf("%s: expected an error", test.name)
+			}
+
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if client.Config().Host != test.expectedHost {
+			t.Fatalf("%s: expected host %s, got %s", test.name, test.expectedHost, client.Config().Host)
+		}
+	}
+}
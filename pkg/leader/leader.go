@@ -0,0 +1,106 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/kube"
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaseName is the name of the lease that is used for the election
+const LeaseName = "kube-problem-leader"
+
+const (
+	leaseDuration = time.Second * 15
+	renewDeadline = time.Second * 10
+	retryPeriod   = time.Second * 2
+)
+
+// Elector elects a single leader between multiple replicas with a coordination.k8s.io lease
+type Elector struct {
+	client    kube.Client
+	namespace string
+	identity  string
+
+	// running is set to 1 while the replica takes part in the election
+	running int32
+}
+
+// NewElector creates a new elector that uses a lease in the given namespace. The identity has to be unique
+// per replica, e.g. the pod name
+func NewElector(client kube.Client, namespace, identity string) *Elector {
+	return &Elector{
+		client:    client,
+		namespace: namespace,
+		identity:  identity,
+	}
+}
+
+// Running returns if the replica takes part in the election, either as leader or as standby
+func (e *Elector) Running() bool {
+	return atomic.LoadInt32(&e.running) == 1
+}
+
+// Run blocks until the lease was acquired and then calls onStartedLeading. If the lease cannot be renewed
+// anymore, the context passed to onStartedLeading is cancelled and onStoppedLeading is called. Run returns
+// when ctx is cancelled and onStartedLeading has returned, the lease is released then
+func (e *Elector) Run(ctx context.Context, onStartedLeading func(ctx context.Context), onStoppedLeading func()) {
+	leading := make(chan struct{})
+	done := make(chan struct{})
+
+	atomic.StoreInt32(&e.running, 1)
+	defer atomic.StoreInt32(&e.running, 0)
+
+	log.Info("Waiting for leadership", "lease", LeaseName, "namespace", e.namespace, "identity", e.identity)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      LeaseName,
+				Namespace: e.namespace,
+			},
+			Client:     e.client.Client().CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: e.identity},
+		},
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            LeaseName,
+
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				close(leading)
+				defer close(done)
+
+				log.Info("Acquired leadership", "lease", LeaseName, "identity", e.identity)
+				onStartedLeading(leaderCtx)
+			},
+			// Called whenever RunOrDie returns, also if the lease was never acquired, so the lost leadership is
+			// handled after it returned
+			OnStoppedLeading: func() {},
+			OnNewLeader: func(identity string) {
+				log.Info("New leader elected", "lease", LeaseName, "leader", identity, "self", identity == e.identity)
+			},
+		},
+	})
+
+	if ctx.Err() != nil {
+		// Wait until the runners have stopped if this replica was leading
+		select {
+		case <-leading:
+			<-done
+			log.Info("Released leadership", "lease", LeaseName, "identity", e.identity)
+		default:
+		}
+
+		return
+	}
+
+	log.Info("Lost leadership", "lease", LeaseName, "identity", e.identity)
+	onStoppedLeading()
+}
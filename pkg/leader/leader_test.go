@@ -0,0 +1,130 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// fakeClient is a kube client backed by a fake clientset
+type fakeClient struct {
+	clientset *fake.Clientset
+}
+
+func (c *fakeClient) Config() *rest.Config {
+	return &rest.Config{}
+}
+
+func (c *fakeClient) Client() kubernetes.Interface {
+	return c.clientset
+}
+
+func (c *fakeClient) Dynamic() dynamic.Interface {
+	return nil
+}
+
+// syncBuffer is a buffer the log messages of the election goroutines can be written to concurrently
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.String()
+}
+
+func TestRunAcquiresAndReleasesLease(t *testing.T) {
+	logs := &syncBuffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	client := &fakeClient{clientset: fake.NewSimpleClientset()}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	returned := make(chan struct{})
+	elector := NewElector(client, "default", "replica-1")
+	go func() {
+		defer close(returned)
+
+		elector.Run(ctx, func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+			close(stopped)
+		}, func() {
+			t.Error("Expected the leadership not to be lost")
+		})
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second * 10):
+		t.Fatal("Expected the lease to be acquired")
+	}
+	if !elector.Running() {
+		t.Fatal("Expected the elector to be running")
+	}
+
+	lease, err := client.clientset.CoordinationV1().Leases("default").Get(LeaseName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	} else if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != "replica-1" {
+		t.Fatalf("Expected the lease to be held by replica-1, got %v", lease.Spec.HolderIdentity)
+	}
+
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(time.Second * 10):
+		t.Fatal("Expected Run to return after the context was cancelled")
+	}
+
+	// Run waits for the leader to stop before it returns
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("Expected the leader to be stopped before Run returned")
+	}
+	if elector.Running() {
+		t.Fatal("Expected the elector not to be running after Run returned")
+	}
+
+	lease, err = client.clientset.CoordinationV1().Leases("default").Get(LeaseName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	} else if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == "replica-1" {
+		t.Fatal("Expected the lease to be released")
+	}
+
+	// The leadership changes are logged at info level
+	for _, msg := range []string{"Acquired leadership", "New leader elected", "Released leadership"} {
+		if !strings.Contains(logs.String(), "level=INFO msg=\""+msg+"\"") {
+			t.Fatalf("Expected '%s' to be logged at info level, got %s", msg, logs.String())
+		}
+	}
+	if !strings.Contains(logs.String(), "leader=replica-1") {
+		t.Fatalf("Expected the identity of the new leader to be logged, got %s", logs.String())
+	}
+}
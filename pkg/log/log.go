@@ -0,0 +1,115 @@
+// Package log is a small structured logger on top of log/slog. Messages are written with key value pairs
+// either as logfmt style text or as json lines
+package log
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Format is the output format of the logger
+type Format string
+
+// The available log formats
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+var (
+	mutex  sync.Mutex
+	out    io.Writer = os.Stderr
+	format           = FormatText
+	level            = &slog.LevelVar{}
+	logger           = newLogger()
+)
+
+// newLogger returns a logger that writes to out in the configured format. Needs to be called with the mutex locked
+func newLogger() *slog.Logger {
+	options := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(out, options))
+	}
+
+	return slog.New(slog.NewTextHandler(out, options))
+}
+
+// Configure sets the minimum level (debug, info, warn or error) and the output format (text or json).
+// Empty values keep the current setting
+func Configure(levelName, formatName string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	switch strings.ToLower(levelName) {
+	case "":
+	case "debug":
+		level.Set(slog.LevelDebug)
+	case "info":
+		level.Set(slog.LevelInfo)
+	case "warn", "warning":
+		level.Set(slog.LevelWarn)
+	case "error":
+		level.Set(slog.LevelError)
+	default:
+		return fmt.Errorf("unknown log level '%s' (expected debug, info, warn or error)", levelName)
+	}
+
+	switch Format(strings.ToLower(formatName)) {
+	case "":
+	case FormatText:
+		format = FormatText
+	case FormatJSON:
+		format = FormatJSON
+	default:
+		return fmt.Errorf("unknown log format '%s' (expected text or json)", formatName)
+	}
+
+	logger = newLogger()
+	return nil
+}
+
+// SetOutput sets the writer log messages are written to
+func SetOutput(w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	out = w
+	logger = newLogger()
+}
+
+func getLogger() *slog.Logger {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return logger
+}
+
+// Debug logs a message with the given key value pairs at debug level
+func Debug(msg string, keyvals ...interface{}) {
+	getLogger().Debug(msg, keyvals...)
+}
+
+// Info logs a message with the given key value pairs at info level
+func Info(msg string, keyvals ...interface{}) {
+	getLogger().Info(msg, keyvals...)
+}
+
+// Warn logs a message with the given key value pairs at warn level
+func Warn(msg string, keyvals ...interface{}) {
+	getLogger().Warn(msg, keyvals...)
+}
+
+// Error logs a message with the given key value pairs at error level
+func Error(msg string, keyvals ...interface{}) {
+	getLogger().Error(msg, keyvals...)
+}
+
+// Fatal logs a message with the given key value pairs at error level and exits
+func Fatal(msg string, keyvals ...interface{}) {
+	getLogger().Error(msg, keyvals...)
+	os.Exit(1)
+}
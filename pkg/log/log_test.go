@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	defer func() {
+		SetOutput(os.Stderr)
+		Configure("info", "text")
+	}()
+
+	b := &bytes.Buffer{}
+	SetOutput(b)
+
+	err := Configure("info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Debug("hidden")
+	Info("Problem reported", "pod", "default/pod", "error", errors.New("failed"))
+
+	line := map[string]interface{}{}
+	err = json.Unmarshal(b.Bytes(), &line)
+	if err != nil {
+		t.Fatalf("Expected a single json line, got %s: %v", b.String(), err)
+	} else if line["level"] != "INFO" || line["msg"] != "Problem reported" || line["pod"] != "default/pod" || line["error"] != "failed" {
+		t.Fatalf("Unexpected json line %s", b.String())
+	}
+
+	b.Reset()
+	err = Configure("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Warn("Problem reported", "pod", "default/pod")
+	if out := b.String(); !strings.Contains(out, `level=WARN msg="Problem reported" pod=default/pod`) {
+		t.Fatalf("Unexpected text line %s", out)
+	}
+}
+
+func TestConfigureInvalid(t *testing.T) {
+	if Configure("verbose", "") == nil {
+		t.Fatal("Expected an error for an unknown level")
+	} else if Configure("", "xml") == nil {
+		t.Fatal("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,122 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a weekly recurring maintenance window
+type Window struct {
+	Day time.Weekday
+
+	// Start and End are the offsets from midnight. If End is before Start, the window ends on the next day
+	Start time.Duration
+	End   time.Duration
+}
+
+// Windows is a list of maintenance windows
+type Windows []Window
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Parse parses a comma separated list of windows such as "Sat 02:00-04:00,Sun 02:00-04:00"
+func Parse(value string) (Windows, error) {
+	windows := Windows{}
+	for _, window := range strings.Split(value, ",") {
+		window = strings.TrimSpace(window)
+		if window == "" {
+			continue
+		}
+
+		fields := strings.Fields(window)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid maintenance window '%s' (expected e.g. 'Sat 02:00-04:00')", window)
+		}
+
+		day, ok := weekdays[strings.ToLower(fields[0])]
+		if !ok && len(fields[0]) >= 3 {
+			day, ok = weekdays[strings.ToLower(fields[0][:3])]
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday '%s' in maintenance window '%s'", fields[0], window)
+		}
+
+		times := strings.Split(fields[1], "-")
+		if len(times) != 2 {
+			return nil, fmt.Errorf("invalid time range '%s' in maintenance window '%s'", fields[1], window)
+		}
+
+		start, err := parseTimeOfDay(times[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid start time in maintenance window '%s': %v", window, err)
+		}
+
+		end, err := parseTimeOfDay(times[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid end time in maintenance window '%s': %v", window, err)
+		}
+
+		windows = append(windows, Window{
+			Day:   day,
+			Start: start,
+			End:   end,
+		})
+	}
+
+	return windows, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("expected a time like 02:00, got '%s'", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// IsActive returns true if t is within the window
+func (w Window) IsActive(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.End > w.Start {
+		return t.Weekday() == w.Day && offset >= w.Start && offset < w.End
+	}
+
+	// The window spans midnight
+	nextDay := (w.Day + 1) % 7
+	return (t.Weekday() == w.Day && offset >= w.Start) || (t.Weekday() == nextDay && offset < w.End)
+}
+
+// IsActive returns true if t is within any of the windows
+func (w Windows) IsActive(t time.Time) bool {
+	for _, window := range w {
+		if window.IsActive(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns the windows in the same format they are parsed from
+func (w Windows) String() string {
+	windows := make([]string, 0, len(w))
+	for _, window := range w {
+		windows = append(windows, fmt.Sprintf("%s %s-%s", window.Day.String()[:3], formatTimeOfDay(window.Start), formatTimeOfDay(window.End)))
+	}
+
+	return strings.Join(windows, ",")
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", d/time.Hour, (d%time.Hour)/time.Minute)
+}
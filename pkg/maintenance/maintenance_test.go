@@ -0,0 +1,138 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: "",
+		},
+		{
+			name:     "multiple windows",
+			value:    "Sat 02:00-04:00, Sun 02:00-04:00",
+			expected: "Sat 02:00-04:00,Sun 02:00-04:00",
+		},
+		{
+			name:     "full weekday",
+			value:    "monday 23:30-01:15",
+			expected: "Mon 23:30-01:15",
+		},
+		{
+			name:        "missing time range",
+			value:       "Sat",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid weekday",
+			value:       "Xyz 02:00-04:00",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid time range",
+			value:       "Sat 02:00",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid time",
+			value:       "Sat 25:00-04:00",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		windows, err := Parse(test.value)
+		if test.expectedErr {
+			if err == nil {
+				t.Fatalf("%s: expected an error parsing '%s'", test.name, test.value)
+			}
+
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if windows.String() != test.expected {
+			t.Fatalf("%s: expected %s, got %s", test.name, test.expected, windows.String())
+		}
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	windows, err := Parse("Sat 02:00-04:00,Sun 23:00-01:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2020-06-06 is a Saturday
+	tests := []struct {
+		name     string
+		time     time.Time
+		expected bool
+	}{
+		{
+			name:     "before window",
+			time:     time.Date(2020, 6, 6, 1, 59, 59, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "window start",
+			time:     time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "inside window",
+			time:     time.Date(2020, 6, 6, 3, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "window end",
+			time:     time.Date(2020, 6, 6, 4, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "other weekday",
+			time:     time.Date(2020, 6, 5, 3, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "before midnight",
+			time:     time.Date(2020, 6, 7, 23, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "after midnight",
+			time:     time.Date(2020, 6, 8, 0, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "after window spanning midnight",
+			time:     time.Date(2020, 6, 8, 1, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "same time on the day after",
+			time:     time.Date(2020, 6, 9, 0, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		if windows.IsActive(test.time) != test.expected {
+			t.Fatalf("%s: expected IsActive(%s) to be %v", test.name, test.time, test.expected)
+		}
+	}
+
+	if (Windows{}).IsActive(time.Date(2020, 6, 6, 3, 0, 0, 0, time.UTC)) {
+		t.Fatal("Expected no window to be active without windows")
+	}
+}
@@ -161,7 +161,7 @@ func getMetricsFromMetricsAPI(metricsClient metricsclientset.Interface, namespac
 
 // IsMetricsAPIAvailable checks if the metrics api is available
 func IsMetricsAPIAvailable(kubeClient kube.Client) (bool, error) {
-	apiGroups, err := kubeClient.Client().DiscoveryClient.ServerGroups()
+	apiGroups, err := kubeClient.Client().Discovery().ServerGroups()
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,253 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Problem is a problem that is sent to the notifiers
+type Problem struct {
+	ID        string
+	Cluster   string
+	Type      string
+	Severity  string
+	Kind      string
+	Name      string
+	Namespace string
+	// NodeGroup is the node pool of node problems if node alerts are grouped
+	NodeGroup string
+	// Channel is the channel the problem is routed to, notifiers without channels ignore it. If it is empty the
+	// default channel of the notifier is used
+	Channel string
+
+	Message string
+	Occured time.Time
+
+	Reported        bool
+	OccuredCounter  int
+	ResolvedCounter int
+}
+
+// Resource returns a human readable description of the resource the problem occured on
+func (p Problem) Resource() string {
+	resource := fmt.Sprintf("%s '%s'", p.Kind, p.Name)
+	if p.Namespace != "" {
+		resource = fmt.Sprintf("%s '%s' in namespace '%s'", p.Kind, p.Name, p.Namespace)
+	}
+	if p.Cluster != "" {
+		resource += fmt.Sprintf(" in cluster '%s'", p.Cluster)
+	}
+
+	return resource
+}
+
+// Notifier is the interface a notification backend has to implement
+type Notifier interface {
+	Alert(p Problem) error
+	Resolve(p Problem) error
+}
+
+// MessageSender is implemented by notifiers that can send free text messages
+type MessageSender interface {
+	// SendMessage sends the message to the channel or the default channel of the notifier if channel is empty
+	SendMessage(channel, message string) error
+}
+
+// BatchAlerter is implemented by notifiers that can alert multiple problems of the same type in a single message
+type BatchAlerter interface {
+	AlertBatch(problems []Problem) error
+}
+
+// ThreadNotifier is implemented by notifiers that can send further messages about a problem as replies to its alert
+type ThreadNotifier interface {
+	// AlertThread sends the alert and returns the thread replies are sent to
+	AlertThread(p Problem) (string, error)
+	// SendThreadMessage replies with the message in the given thread
+	SendThreadMessage(thread, message string) error
+	// ResolveThread replies with the resolve message in the given thread
+	ResolveThread(thread string, p Problem) error
+}
+
+// MultiNotifier broadcasts all problems to each of its notifiers
+type MultiNotifier []Notifier
+
+// Alert sends the problem to all notifiers
+func (m MultiNotifier) Alert(p Problem) error {
+	errs := []error{}
+	for _, notifier := range m {
+		err := notifier.Alert(p)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// AlertBatch sends the problems as a single message to all notifiers that support it and one by one to the others
+func (m MultiNotifier) AlertBatch(problems []Problem) error {
+	errs := []error{}
+	for _, notifier := range m {
+		if batchAlerter, ok := notifier.(BatchAlerter); ok {
+			err := batchAlerter.AlertBatch(problems)
+			if err != nil {
+				errs = append(errs, err)
+			}
+
+			continue
+		}
+
+		for _, p := range problems {
+			err := notifier.Alert(p)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// AlertThread sends the problem to all notifiers and returns the thread of the first notifier that supports threads
+func (m MultiNotifier) AlertThread(p Problem) (string, error) {
+	thread := ""
+	errs := []error{}
+	for _, notifier := range m {
+		threadNotifier, ok := notifier.(ThreadNotifier)
+		if !ok || thread != "" {
+			err := notifier.Alert(p)
+			if err != nil {
+				errs = append(errs, err)
+			}
+
+			continue
+		}
+
+		var err error
+		thread, err = threadNotifier.AlertThread(p)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return thread, utilerrors.NewAggregate(errs)
+}
+
+// SendThreadMessage replies in the thread of the first notifier that supports threads, which is the notifier
+// the thread was returned from by AlertThread
+func (m MultiNotifier) SendThreadMessage(thread, message string) error {
+	for _, notifier := range m {
+		if threadNotifier, ok := notifier.(ThreadNotifier); ok {
+			return threadNotifier.SendThreadMessage(thread, message)
+		}
+	}
+
+	return nil
+}
+
+// ResolveThread sends the resolved problem as reply in the thread to the first notifier that supports threads and
+// as usual to all other notifiers
+func (m MultiNotifier) ResolveThread(thread string, p Problem) error {
+	replied := false
+	errs := []error{}
+	for _, notifier := range m {
+		var err error
+		if threadNotifier, ok := notifier.(ThreadNotifier); ok && !replied {
+			replied = true
+			err = threadNotifier.ResolveThread(thread, p)
+		} else {
+			err = notifier.Resolve(p)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Resolve sends the resolved problem to all notifiers
+func (m MultiNotifier) Resolve(p Problem) error {
+	errs := []error{}
+	for _, notifier := range m {
+		err := notifier.Resolve(p)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// SendMessage sends the message to all notifiers that support free text messages
+func (m MultiNotifier) SendMessage(channel, message string) error {
+	errs := []error{}
+	for _, notifier := range m {
+		sender, ok := notifier.(MessageSender)
+		if !ok {
+			continue
+		}
+
+		err := sender.SendMessage(channel, message)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// ClusterNotifier sets the cluster of every problem before it is sent to its notifiers, which is used if multiple
+// clusters are watched. The cluster is prepended to the problem id, so problems of different clusters don't collide
+type ClusterNotifier struct {
+	Cluster  string
+	Notifier MultiNotifier
+}
+
+func (c *ClusterNotifier) withCluster(p Problem) Problem {
+	p.Cluster = c.Cluster
+	p.ID = c.Cluster + "/" + p.ID
+	return p
+}
+
+// Alert sends the problem with the cluster to all notifiers
+func (c *ClusterNotifier) Alert(p Problem) error {
+	return c.Notifier.Alert(c.withCluster(p))
+}
+
+// AlertBatch sends the problems with the cluster to all notifiers
+func (c *ClusterNotifier) AlertBatch(problems []Problem) error {
+	clusterProblems := make([]Problem, 0, len(problems))
+	for _, p := range problems {
+		clusterProblems = append(clusterProblems, c.withCluster(p))
+	}
+
+	return c.Notifier.AlertBatch(clusterProblems)
+}
+
+// AlertThread sends the problem with the cluster to all notifiers and returns the thread
+func (c *ClusterNotifier) AlertThread(p Problem) (string, error) {
+	return c.Notifier.AlertThread(c.withCluster(p))
+}
+
+// SendThreadMessage replies with the message in the given thread
+func (c *ClusterNotifier) SendThreadMessage(thread, message string) error {
+	return c.Notifier.SendThreadMessage(thread, message)
+}
+
+// ResolveThread sends the resolved problem with the cluster as reply in the thread
+func (c *ClusterNotifier) ResolveThread(thread string, p Problem) error {
+	return c.Notifier.ResolveThread(thread, c.withCluster(p))
+}
+
+// Resolve sends the resolved problem with the cluster to all notifiers
+func (c *ClusterNotifier) Resolve(p Problem) error {
+	return c.Notifier.Resolve(c.withCluster(p))
+}
+
+// SendMessage sends the message prefixed with the cluster to all notifiers that support free text messages
+func (c *ClusterNotifier) SendMessage(channel, message string) error {
+	return c.Notifier.SendMessage(channel, fmt.Sprintf("[%s] %s", c.Cluster, message))
+}
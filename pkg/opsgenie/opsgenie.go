@@ -0,0 +1,147 @@
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+)
+
+const alertsAPIURL = "https://api.opsgenie.com/v2/alerts"
+
+// maxMessageLength is the maximum length of an alert message accepted by opsgenie
+const maxMessageLength = 130
+
+// Client is the opsgenie client struct
+type Client struct {
+	APIKey string
+	Team   string
+
+	apiURL     string
+	httpClient *http.Client
+}
+
+type createAlertRequest struct {
+	Message     string      `json:"message"`
+	Alias       string      `json:"alias"`
+	Description string      `json:"description"`
+	Responders  []responder `json:"responders,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Entity      string      `json:"entity"`
+	Source      string      `json:"source"`
+	Priority    string      `json:"priority"`
+}
+
+type responder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type closeAlertRequest struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+// NewClient creates a new opsgenie client to use, team is optional and routes the alerts to that team
+func NewClient(apiKey, team string) (*Client, error) {
+	if apiKey == "" {
+		return nil, errors.New("No opsgenie api key provided. Is env variable OPSGENIE_API_KEY set?")
+	}
+
+	return &Client{
+		APIKey:     apiKey,
+		Team:       team,
+		apiURL:     alertsAPIURL,
+		httpClient: &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+// Alert creates a new opsgenie alert with the problem id as alias
+func (c *Client) Alert(p notify.Problem) error {
+	message := fmt.Sprintf("Problem with %s", p.Resource())
+	if len(message) > maxMessageLength {
+		message = message[:maxMessageLength]
+	}
+
+	request := &createAlertRequest{
+		Message:     message,
+		Alias:       p.ID,
+		Description: p.Message,
+		Tags:        []string{"kube-problem", p.Type, p.Severity},
+		Entity:      p.Resource(),
+		Source:      "kube-problem",
+		Priority:    getPriority(p.Severity),
+	}
+	if c.Team != "" {
+		request.Responders = []responder{{Name: c.Team, Type: "team"}}
+	}
+
+	return c.send(c.apiURL, request)
+}
+
+// Resolve closes the opsgenie alert that was created for the problem
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.send(fmt.Sprintf("%s/%s/close?identifierType=alias", c.apiURL, url.PathEscape(p.ID)), &closeAlertRequest{
+		Source: "kube-problem",
+		Note:   "The problem is resolved: " + p.Message,
+	})
+}
+
+// getPriority maps the problem severity to an opsgenie priority
+func getPriority(severity string) string {
+	switch severity {
+	case "critical":
+		return "P1"
+	case "info":
+		return "P5"
+	}
+
+	return "P3"
+}
+
+// send posts the request to the opsgenie api. Rate limited requests are retried with an increasing backoff
+func (c *Client) send(url string, request interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(nil, retry.DefaultPolicy, "sending to opsgenie", func() (bool, error) {
+		return c.post(url, body)
+	})
+}
+
+func (c *Client) post(url string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+c.APIKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("opsgenie returned status code %d: %s", resp.StatusCode, string(out))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, err
+	}
+
+	return false, err
+}
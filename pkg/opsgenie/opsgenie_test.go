@@ -0,0 +1,169 @@
+package opsgenie
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "pod/default/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Severity:  "critical",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+}
+
+type request struct {
+	path   string
+	query  string
+	header http.Header
+	body   []byte
+}
+
+// newTestClient returns a client that sends to a mock opsgenie api, which records the requests. The requests are
+// answered with the given status codes in order and with 202 afterwards
+func newTestClient(t *testing.T, team string, statusCodes ...int) (*Client, func() []request) {
+	var (
+		requests      []request
+		requestsMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		requestsMutex.Lock()
+		defer requestsMutex.Unlock()
+
+		requests = append(requests, request{path: req.URL.EscapedPath(), query: req.URL.RawQuery, header: req.Header, body: body})
+		if len(requests) <= len(statusCodes) {
+			w.WriteHeader(statusCodes[len(requests)-1])
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient("api-key", team)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.apiURL = server.URL + "/v2/alerts"
+
+	return client, func() []request {
+		requestsMutex.Lock()
+		defer requestsMutex.Unlock()
+
+		return requests
+	}
+}
+
+func TestCreateAlert(t *testing.T) {
+	client, requests := newTestClient(t, "platform")
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := requests()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(sent))
+	} else if sent[0].path != "/v2/alerts" {
+		t.Fatalf("Unexpected path %s", sent[0].path)
+	} else if sent[0].header.Get("Authorization") != "GenieKey api-key" || sent[0].header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected headers %v", sent[0].header)
+	}
+
+	alert := &createAlertRequest{}
+	err = json.Unmarshal(sent[0].body, alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if alert.Alias != testProblem.ID {
+		t.Fatalf("Expected the problem id as alias, got %s", alert.Alias)
+	} else if alert.Message != "Problem with Pod 'pod' in namespace 'default'" || alert.Description != testProblem.Message {
+		t.Fatalf("Unexpected message %s or description %s", alert.Message, alert.Description)
+	} else if alert.Priority != "P1" {
+		t.Fatalf("Expected priority P1 for a critical problem, got %s", alert.Priority)
+	} else if len(alert.Responders) != 1 || alert.Responders[0].Name != "platform" || alert.Responders[0].Type != "team" {
+		t.Fatalf("Expected the team as responder, got %#v", alert.Responders)
+	} else if strings.Join(alert.Tags, ",") != "kube-problem,PodStatus,critical" {
+		t.Fatalf("Unexpected tags %v", alert.Tags)
+	}
+}
+
+func TestCreateAlertWithoutTeam(t *testing.T) {
+	client, requests := newTestClient(t, "")
+	err := client.Alert(notify.Problem{ID: "node/condition", Kind: "Node", Name: strings.Repeat("n", 200), Message: "Node is not ready"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alert := &createAlertRequest{}
+	err = json.Unmarshal(requests()[0].body, alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alert.Responders) != 0 {
+		t.Fatalf("Expected no responders without a team, got %#v", alert.Responders)
+	} else if len(alert.Message) != maxMessageLength {
+		t.Fatalf("Expected the message to be truncated to %d characters, got %d", maxMessageLength, len(alert.Message))
+	} else if alert.Priority != "P3" {
+		t.Fatalf("Expected the default priority P3, got %s", alert.Priority)
+	}
+}
+
+func TestCloseAlert(t *testing.T) {
+	client, requests := newTestClient(t, "")
+	err := client.Resolve(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := requests()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(sent))
+	} else if sent[0].path != "/v2/alerts/pod%2Fdefault%2Fstatus/close" || sent[0].query != "identifierType=alias" {
+		t.Fatalf("Expected the alert to be closed by alias, got %s?%s", sent[0].path, sent[0].query)
+	}
+
+	closeRequest := &closeAlertRequest{}
+	err = json.Unmarshal(sent[0].body, closeRequest)
+	if err != nil {
+		t.Fatal(err)
+	} else if closeRequest.Source != "kube-problem" || closeRequest.Note != "The problem is resolved: "+testProblem.Message {
+		t.Fatalf("Unexpected close request %#v", closeRequest)
+	}
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	client, requests := newTestClient(t, "", http.StatusTooManyRequests)
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(requests()) != 2 {
+		t.Fatalf("Expected the alert to be sent again after a rate limited request, got %d requests", len(requests()))
+	}
+
+	client, requests = newTestClient(t, "", http.StatusUnauthorized)
+	err = client.Alert(testProblem)
+	if err == nil {
+		t.Fatal("Expected an error for an unauthorized request")
+	} else if len(requests()) != 1 {
+		t.Fatalf("Expected an unauthorized request not to be retried, got %d requests", len(requests()))
+	}
+}
@@ -0,0 +1,120 @@
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+)
+
+const eventsAPIURL = "https://events.pagerduty.com/v2/enqueue"
+
+const (
+	eventActionTrigger = "trigger"
+	eventActionResolve = "resolve"
+)
+
+// Client is the pagerduty client struct
+type Client struct {
+	RoutingKey string
+
+	httpClient *http.Client
+	apiURL     string
+}
+
+type event struct {
+	RoutingKey  string        `json:"routing_key"`
+	EventAction string        `json:"event_action"`
+	DedupKey    string        `json:"dedup_key"`
+	Payload     *eventPayload `json:"payload,omitempty"`
+}
+
+type eventPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+// NewClient creates a new pagerduty client to use
+func NewClient(routingKey string) (*Client, error) {
+	if routingKey == "" {
+		return nil, errors.New("No pagerduty routing key provided. Is env variable PAGERDUTY_ROUTING_KEY set?")
+	}
+
+	return &Client{
+		RoutingKey: routingKey,
+		httpClient: &http.Client{Timeout: time.Second * 30},
+		apiURL:     eventsAPIURL,
+	}, nil
+}
+
+// Alert triggers a new pagerduty incident with the problem id as dedup key
+func (c *Client) Alert(p notify.Problem) error {
+	return c.sendEvent(&event{
+		RoutingKey:  c.RoutingKey,
+		EventAction: eventActionTrigger,
+		DedupKey:    p.ID,
+		Payload: &eventPayload{
+			Summary:  fmt.Sprintf("Problem with %s: %s", p.Resource(), p.Message),
+			Source:   "kube-problem",
+			Severity: getSeverity(p.Severity),
+		},
+	})
+}
+
+// Resolve resolves the pagerduty incident that was triggered for the problem
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.sendEvent(&event{
+		RoutingKey:  c.RoutingKey,
+		EventAction: eventActionResolve,
+		DedupKey:    p.ID,
+	})
+}
+
+// getSeverity maps the problem severity to a pagerduty severity
+func getSeverity(severity string) string {
+	switch severity {
+	case "critical", "warning", "info":
+		return severity
+	}
+
+	return "error"
+}
+
+// sendEvent sends a new event to the pagerduty events v2 api
+func (c *Client) sendEvent(e *event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(nil, retry.DefaultPolicy, "sending to pagerduty", func() (bool, error) {
+		return c.post(body)
+	})
+}
+
+func (c *Client) post(body []byte) (bool, error) {
+	resp, err := c.httpClient.Post(c.apiURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusAccepted {
+		return false, nil
+	}
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("pagerduty returned status code %d: %s", resp.StatusCode, string(out))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, err
+	}
+
+	return false, err
+}
@@ -0,0 +1,151 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "pod/default/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Severity:  "critical",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+}
+
+// newTestClient returns a client that sends to a mock events api, which records the sent events. The requests are
+// answered with the given status codes in order and with 202 afterwards
+func newTestClient(t *testing.T, statusCodes ...int) (*Client, func() []event) {
+	var (
+		events      []event
+		eventsMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		} else if req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type %s", req.Header.Get("Content-Type"))
+		}
+
+		e := event{}
+		err = json.Unmarshal(body, &e)
+		if err != nil {
+			t.Error(err)
+		}
+
+		eventsMutex.Lock()
+		defer eventsMutex.Unlock()
+
+		events = append(events, e)
+		if len(events) <= len(statusCodes) {
+			w.WriteHeader(statusCodes[len(events)-1])
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient("routing-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.apiURL = server.URL + "/v2/enqueue"
+
+	return client, func() []event {
+		eventsMutex.Lock()
+		defer eventsMutex.Unlock()
+
+		return events
+	}
+}
+
+func TestAlert(t *testing.T) {
+	client, events := newTestClient(t)
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := events()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(sent))
+	} else if sent[0].RoutingKey != "routing-key" || sent[0].EventAction != eventActionTrigger {
+		t.Fatalf("Expected a trigger event with the routing key, got %#v", sent[0])
+	} else if sent[0].DedupKey != testProblem.ID {
+		t.Fatalf("Expected the problem id as dedup key, got %s", sent[0].DedupKey)
+	} else if sent[0].Payload == nil {
+		t.Fatal("Expected a payload")
+	} else if sent[0].Payload.Severity != "critical" || sent[0].Payload.Source != "kube-problem" {
+		t.Fatalf("Unexpected payload %#v", sent[0].Payload)
+	} else if sent[0].Payload.Summary != "Problem with Pod 'pod' in namespace 'default': "+testProblem.Message {
+		t.Fatalf("Unexpected summary %s", sent[0].Payload.Summary)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	client, events := newTestClient(t)
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Resolve(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The resolve event needs the dedup key of the trigger event to resolve the incident
+	sent := events()
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(sent))
+	} else if sent[1].RoutingKey != "routing-key" || sent[1].EventAction != eventActionResolve {
+		t.Fatalf("Expected a resolve event with the routing key, got %#v", sent[1])
+	} else if sent[1].DedupKey != sent[0].DedupKey {
+		t.Fatalf("Expected the dedup key %s of the alert, got %s", sent[0].DedupKey, sent[1].DedupKey)
+	} else if sent[1].Payload != nil {
+		t.Fatalf("Expected no payload for a resolve event, got %#v", sent[1].Payload)
+	}
+}
+
+func TestGetSeverity(t *testing.T) {
+	tests := map[string]string{
+		"critical": "critical",
+		"warning":  "warning",
+		"info":     "info",
+		"":         "error",
+	}
+
+	for severity, expected := range tests {
+		if actual := getSeverity(severity); actual != expected {
+			t.Fatalf("Expected pagerduty severity %s for %s, got %s", expected, severity, actual)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	client, events := newTestClient(t, http.StatusTooManyRequests, http.StatusInternalServerError)
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(events()) != 3 {
+		t.Fatalf("Expected the event to be sent again after a rate limited and a failed request, got %d requests", len(events()))
+	}
+
+	client, events = newTestClient(t, http.StatusBadRequest)
+	err = client.Alert(testProblem)
+	if err == nil {
+		t.Fatal("Expected an error for an invalid event")
+	} else if len(events()) != 1 {
+		t.Fatalf("Expected an invalid event not to be retried, got %d requests", len(events()))
+	}
+}
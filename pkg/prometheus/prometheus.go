@@ -0,0 +1,174 @@
+package prometheus
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ActiveProblems is the number of currently active problems per resource
+var ActiveProblems = NewGaugeVec("kube_problem_active_total", "Number of currently active problems", "problem_type", "kind", "namespace", "name")
+
+// CheckDuration is the time the node and namespace checks and the slack requests take
+var CheckDuration = NewHistogramVec("kube_problem_check_duration_seconds", "Duration of the checks and slack requests in seconds", DefaultBuckets, "check_type", "namespace")
+
+// ChecksTotal is the number of node and namespace checks by result (success or error)
+var ChecksTotal = NewCounterVec("kube_problem_checks_total", "Number of node and namespace checks", "result")
+
+// NotificationErrors is the number of messages that could not be sent by type (report, update, resolve or batch)
+var NotificationErrors = NewCounterVec("kube_problem_notification_errors_total", "Number of alerts, updates and resolves that could not be sent", "type")
+
+// PodsByPhase is the number of pods per watched namespace and phase
+var PodsByPhase = NewGaugeVec("kube_problem_pods_by_phase", "Number of pods by phase", "namespace", "phase")
+
+// PodsByStatus is the number of pods per watched namespace and detailed status (e.g. CrashLoopBackOff)
+var PodsByStatus = NewGaugeVec("kube_problem_pods_by_status", "Number of pods by status", "namespace", "status")
+
+// Handler returns a http handler that serves all registered metrics, including the go runtime and process metrics,
+// in the prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// GaugeVec is a gauge with a value per label combination. Values that drop to zero are removed to keep the number
+// of series small
+type GaugeVec struct {
+	vec        *prometheus.GaugeVec
+	labelNames []string
+
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+// NewGaugeVec creates and registers a new gauge
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labelNames)
+	prometheus.MustRegister(vec)
+
+	return &GaugeVec{
+		vec:        vec,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+	}
+}
+
+// Inc increments the gauge for the given label values
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.add(1, labelValues)
+}
+
+// Dec decrements the gauge for the given label values
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.add(-1, labelValues)
+}
+
+func (g *GaugeVec) add(delta float64, labelValues []string) {
+	gauge := g.vec.WithLabelValues(labelValues...)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	key := strings.Join(labelValues, "\xff")
+	g.values[key] += delta
+	if g.values[key] == 0 {
+		delete(g.values, key)
+		g.vec.DeleteLabelValues(labelValues...)
+		return
+	}
+
+	gauge.Set(g.values[key])
+}
+
+// GaugeValue is the value of a gauge for the label values
+type GaugeValue struct {
+	LabelValues []string
+	Value       float64
+}
+
+// Replace removes all values of the gauge whose label labelName has the value labelValue and sets the given values
+// instead, e.g. to replace the values of a namespace with the result of the latest check
+func (g *GaugeVec) Replace(labelName, labelValue string, values []GaugeValue) {
+	index := -1
+	for i, name := range g.labelNames {
+		if name == labelName {
+			index = i
+		}
+	}
+	if index == -1 {
+		panic("metric has no label " + labelName)
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for key := range g.values {
+		if strings.Split(key, "\xff")[index] == labelValue {
+			delete(g.values, key)
+		}
+	}
+	g.vec.DeletePartialMatch(prometheus.Labels{labelName: labelValue})
+	g.set(values)
+}
+
+// ReplaceAll removes all values of the gauge and sets the given values instead
+func (g *GaugeVec) ReplaceAll(values []GaugeValue) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.values = make(map[string]float64)
+	g.vec.Reset()
+	g.set(values)
+}
+
+func (g *GaugeVec) set(values []GaugeValue) {
+	for _, value := range values {
+		if value.Value == 0 {
+			continue
+		}
+
+		g.vec.WithLabelValues(value.LabelValues...).Set(value.Value)
+		g.values[strings.Join(value.LabelValues, "\xff")] = value.Value
+	}
+}
+
+// CounterVec is a counter with a value per label combination
+type CounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+// NewCounterVec creates and registers a new counter
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labelNames)
+	prometheus.MustRegister(vec)
+
+	return &CounterVec{vec: vec}
+}
+
+// Inc increments the counter for the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+// DefaultBuckets are the upper bounds in seconds of the histogram buckets
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// HistogramVec is a histogram with a distribution per label combination
+type HistogramVec struct {
+	vec *prometheus.HistogramVec
+}
+
+// NewHistogramVec creates and registers a new histogram with the given (sorted) bucket upper bounds
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labelNames)
+	prometheus.MustRegister(vec)
+
+	return &HistogramVec{vec: vec}
+}
+
+// Observe adds a value to the histogram for the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.vec.WithLabelValues(labelValues...).Observe(value)
+}
@@ -0,0 +1,80 @@
+package prometheus
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func scrape(t *testing.T) string {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("Unexpected content type %s", contentType)
+	}
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(out)
+}
+
+func TestGaugeVecExposition(t *testing.T) {
+	gauge := NewGaugeVec("test_gauge", "A test gauge", "kind", "name")
+	defer prometheus.Unregister(gauge.vec)
+
+	gauge.Inc("Pod", "b")
+	gauge.Inc("Pod", "a\"quoted\"")
+	gauge.Inc("Pod", "a\"quoted\"")
+	gauge.Inc("Node", "c")
+	gauge.Dec("Node", "c")
+
+	expected := `# HELP test_gauge A test gauge
+# TYPE test_gauge gauge
+test_gauge{kind="Pod",name="a\"quoted\""} 2
+test_gauge{kind="Pod",name="b"} 1
+`
+	if out := scrape(t); !strings.Contains(out, expected) {
+		t.Fatalf("Unexpected exposition:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestGaugeVecReplace(t *testing.T) {
+	gauge := NewGaugeVec("test_replaced_gauge", "A test gauge", "namespace", "phase")
+	defer prometheus.Unregister(gauge.vec)
+
+	gauge.ReplaceAll([]GaugeValue{{LabelValues: []string{"default", "Running"}, Value: 2}, {LabelValues: []string{"test", "Running"}, Value: 1}})
+	gauge.Replace("namespace", "default", []GaugeValue{{LabelValues: []string{"default", "Pending"}, Value: 1}, {LabelValues: []string{"default", "Failed"}, Value: 0}})
+
+	expected := `# HELP test_replaced_gauge A test gauge
+# TYPE test_replaced_gauge gauge
+test_replaced_gauge{namespace="default",phase="Pending"} 1
+test_replaced_gauge{namespace="test",phase="Running"} 1
+`
+	if out := scrape(t); !strings.Contains(out, expected) {
+		t.Fatalf("Unexpected exposition:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestGaugeVecLabelCount(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected a panic for a wrong number of label values")
+		}
+	}()
+
+	ActiveProblems.Inc("PodStatus")
+}
@@ -0,0 +1,127 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	pubsubapi "cloud.google.com/go/pubsub"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+const (
+	eventTypeProblem = "problem"
+	eventTypeResolve = "resolve"
+)
+
+// publishTimeout is the maximum time to publish a message including the retries of the pub/sub client
+const publishTimeout = time.Second * 30
+
+// Client publishes problems as json messages to a google cloud pub/sub topic
+type Client struct {
+	ProjectID string
+	TopicID   string
+
+	topic *pubsubapi.Topic
+}
+
+type payload struct {
+	EventType string `json:"event_type"`
+
+	ID        string `json:"id"`
+	Type      string `json:"problem_type"`
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+
+	Message string    `json:"message"`
+	Occured time.Time `json:"occured"`
+}
+
+// NewClient creates a new pub/sub client to use. The client authenticates with the google application default
+// credentials, if PUBSUB_EMULATOR_HOST is set the messages are published to the emulator without authentication
+func NewClient(projectID, topicID string) (*Client, error) {
+	if projectID == "" {
+		return nil, errors.New("No pub/sub project id provided. Is env variable PUBSUB_PROJECT_ID set?")
+	}
+	if topicID == "" {
+		return nil, errors.New("No pub/sub topic id provided. Is env variable PUBSUB_TOPIC_ID set?")
+	}
+
+	client, err := pubsubapi.NewClient(context.Background(), projectID)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating pub/sub client: %v", err)
+	}
+
+	return &Client{
+		ProjectID: projectID,
+		TopicID:   topicID,
+
+		topic: client.Topic(topicID),
+	}, nil
+}
+
+// Alert publishes a problem event to the topic
+func (c *Client) Alert(p notify.Problem) error {
+	return c.publish(eventTypeProblem, p)
+}
+
+// Resolve publishes a resolve event to the topic
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.publish(eventTypeResolve, p)
+}
+
+// newMessage returns the message of the event, the attributes allow subscriptions to filter the events
+func newMessage(eventType string, p notify.Problem) (*pubsubapi.Message, error) {
+	data, err := json.Marshal(&payload{
+		EventType: eventType,
+
+		ID:        p.ID,
+		Type:      p.Type,
+		Severity:  p.Severity,
+		Kind:      p.Kind,
+		Name:      p.Name,
+		Namespace: p.Namespace,
+		Cluster:   p.Cluster,
+
+		Message: p.Message,
+		Occured: p.Occured,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]string{
+		"event_type":   eventType,
+		"problem_type": p.Type,
+		"kind":         p.Kind,
+	}
+	if p.Namespace != "" {
+		attributes["namespace"] = p.Namespace
+	}
+
+	return &pubsubapi.Message{Data: data, Attributes: attributes}, nil
+}
+
+// publish publishes the event and waits until the server acknowledged it. Failed requests are retried by the pub/sub
+// client until the publish timeout is reached
+func (c *Client) publish(eventType string, p notify.Problem) error {
+	msg, err := newMessage(eventType, p)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	_, err = c.topic.Publish(ctx, msg).Get(ctx)
+	if err != nil {
+		return fmt.Errorf("Error publishing to pub/sub topic %s: %v", c.TopicID, err)
+	}
+
+	return nil
+}
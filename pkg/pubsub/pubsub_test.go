@@ -0,0 +1,94 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	pubsubapi "cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "default/pod/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+}
+
+// newTestClient returns a client that publishes to an in-memory pub/sub emulator with an existing topic
+func newTestClient(t *testing.T) (*Client, *pstest.Server) {
+	server := pstest.NewServer()
+	t.Cleanup(func() { server.Close() })
+	t.Setenv("PUBSUB_EMULATOR_HOST", server.Addr)
+
+	admin, err := pubsubapi.NewClient(context.Background(), "project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	_, err = admin.CreateTopic(context.Background(), "topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient("project", "topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, server
+}
+
+func TestPublish(t *testing.T) {
+	tests := []struct {
+		name              string
+		send              func(c *Client) error
+		expectedEventType string
+	}{
+		{
+			name: "alert",
+			send: func(c *Client) error {
+				return c.Alert(testProblem)
+			},
+			expectedEventType: eventTypeProblem,
+		},
+		{
+			name: "resolve",
+			send: func(c *Client) error {
+				return c.Resolve(testProblem)
+			},
+			expectedEventType: eventTypeResolve,
+		},
+	}
+
+	for _, test := range tests {
+		client, server := newTestClient(t)
+		err := test.send(client)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		messages := server.Messages()
+		if len(messages) != 1 {
+			t.Fatalf("%s: expected 1 message, got %d", test.name, len(messages))
+		}
+
+		attributes := messages[0].Attributes
+		if attributes["event_type"] != test.expectedEventType || attributes["problem_type"] != "PodStatus" || attributes["kind"] != "Pod" || attributes["namespace"] != "default" {
+			t.Fatalf("%s: unexpected attributes %v", test.name, attributes)
+		}
+
+		data := payload{}
+		err = json.Unmarshal(messages[0].Data, &data)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		} else if data.EventType != test.expectedEventType || data.ID != testProblem.ID || data.Message != testProblem.Message {
+			t.Fatalf("%s: unexpected payload %#v", test.name, data)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package retry
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+)
+
+const (
+	// DefaultMax is the default number of times a failed request is retried
+	DefaultMax = 5
+	// DefaultBase is the default backoff before the first retry
+	DefaultBase = time.Second
+	// DefaultMaxBackoff is the default maximum backoff between two retries
+	DefaultMaxBackoff = time.Second * 30
+)
+
+// DefaultPolicy is the policy the notifiers use to retry failed requests
+var DefaultPolicy = Policy{
+	Max:        DefaultMax,
+	Base:       DefaultBase,
+	MaxBackoff: DefaultMaxBackoff,
+}
+
+var (
+	jitterRand      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	jitterRandMutex sync.Mutex
+)
+
+// Policy configures how often and how long to wait before a failed request is retried
+type Policy struct {
+	// Max is the maximum number of retries after the first attempt
+	Max int
+	// Base is the backoff before the first retry
+	Base time.Duration
+	// MaxBackoff caps the backoff between two retries
+	MaxBackoff time.Duration
+}
+
+// Backoff returns a random duration between 0 and base * 2^attempt (capped at the max backoff)
+func (p Policy) Backoff(attempt int) time.Duration {
+	backoff := p.MaxBackoff
+	if attempt < 16 && p.Base<<uint(attempt) < p.MaxBackoff {
+		backoff = p.Base << uint(attempt)
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	jitterRandMutex.Lock()
+	defer jitterRandMutex.Unlock()
+
+	return time.Duration(jitterRand.Int63n(int64(backoff) + 1))
+}
+
+// Do calls fn until it succeeds, returns an error that should not be retried, the retries of
+// the policy are used up or stop is closed. fn returns if a failed call should be retried.
+// The error of the last call is returned
+func Do(stop <-chan struct{}, policy Policy, description string, fn func() (bool, error)) error {
+	for attempt := 0; ; attempt++ {
+		shouldRetry, err := fn()
+		if err == nil || !shouldRetry {
+			return err
+		}
+		if attempt >= policy.Max {
+			log.Warn("Giving up "+description, "attempts", attempt+1, "error", err)
+			return err
+		}
+
+		backoff := policy.Backoff(attempt)
+		log.Warn("Retry "+description, "retry_in", backoff, "error", err)
+		if !Wait(stop, backoff) {
+			return err
+		}
+	}
+}
+
+// Wait waits for the given duration and returns false if stop was closed before
+func Wait(stop <-chan struct{}, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{Max: 5, Base: time.Second, MaxBackoff: time.Second * 30}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: time.Second},
+		{attempt: 1, max: time.Second * 2},
+		{attempt: 4, max: time.Second * 16},
+		{attempt: 5, max: time.Second * 30},
+		{attempt: 100, max: time.Second * 30},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 50; i++ {
+			backoff := policy.Backoff(test.attempt)
+			if backoff < 0 || backoff > test.max {
+				t.Fatalf("attempt %d: backoff %v not within [0, %v]", test.attempt, backoff, test.max)
+			}
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	errFailed := errors.New("failed")
+	policy := Policy{Max: 3, Base: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name          string
+		results       []bool
+		retryable     bool
+		expectedCalls int
+		expectErr     bool
+	}{
+		{
+			name:          "success",
+			results:       []bool{true},
+			expectedCalls: 1,
+		},
+		{
+			name:          "success after retries",
+			results:       []bool{false, false, true},
+			retryable:     true,
+			expectedCalls: 3,
+		},
+		{
+			name:          "not retryable",
+			results:       []bool{false, true},
+			expectedCalls: 1,
+			expectErr:     true,
+		},
+		{
+			name:          "retries exhausted",
+			results:       []bool{false, false, false, false, false, false},
+			retryable:     true,
+			expectedCalls: 4,
+			expectErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		err := Do(nil, policy, "test", func() (bool, error) {
+			success := test.results[calls]
+			calls++
+			if success {
+				return false, nil
+			}
+
+			return test.retryable, errFailed
+		})
+
+		if calls != test.expectedCalls {
+			t.Fatalf("%s: expected %d calls, got %d", test.name, test.expectedCalls, calls)
+		}
+		if (err != nil) != test.expectErr {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
+
+func TestDoStop(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+
+	calls := 0
+	err := Do(stop, Policy{Max: 10, Base: time.Hour, MaxBackoff: time.Hour}, "test", func() (bool, error) {
+		calls++
+		return true, errors.New("failed")
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call after stop was closed, got %d", calls)
+	}
+}
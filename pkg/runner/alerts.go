@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/alertmanager"
+)
+
+// ReportAlert reports a firing alertmanager alert like a problem that was found in the cluster
+func (r *Runner) ReportAlert(alert alertmanager.Alert) error {
+	problem := &problemDesc{
+		problemType: problemTypeAlertmanagerAlert,
+
+		message: alert.Message(),
+		id:      getAlertID(alert),
+
+		kind:      resourceKindAlert,
+		name:      alert.Labels["alertname"],
+		namespace: alert.Labels["namespace"],
+		occured:   time.Now(),
+	}
+
+	// The severity label overwrites the severity of the problem type
+	if alertSeverity := severity(alert.Labels["severity"]); alertSeverity == severityCritical || alertSeverity == severityWarning || alertSeverity == severityInfo {
+		r.problemsMutex.Lock()
+		if r.problems[problem.id] == nil {
+			r.addProblem(problem)
+			problem.severity = alertSeverity
+		}
+		r.problemsMutex.Unlock()
+	}
+
+	err := r.reportProblem(problem)
+	if err != nil {
+		return err
+	}
+
+	// Alerts are received outside of the check cycle, so they are sent right away
+	r.flushReports()
+	return nil
+}
+
+// ResolveAlert resolves a resolved alertmanager alert
+func (r *Runner) ResolveAlert(alert alertmanager.Alert) error {
+	err := r.resolveProblemWithID(getAlertID(alert))
+	if err != nil {
+		return err
+	}
+
+	r.flushReports()
+	return nil
+}
+
+// getAlertID returns the problem id of the alert, which is based on the fingerprint or the labels of the alert
+func getAlertID(alert alertmanager.Alert) string {
+	if alert.Fingerprint != "" {
+		return "alertmanager/" + alert.Fingerprint
+	}
+
+	labels := make([]string, 0, len(alert.Labels))
+	for name, value := range alert.Labels {
+		labels = append(labels, name+"="+value)
+	}
+
+	sort.Strings(labels)
+	return "alertmanager/" + strings.Join(labels, ",")
+}
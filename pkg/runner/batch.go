@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+)
+
+// queueReport adds the problem to the reports that are sent at the end of the check cycle.
+// Needs to be called with the problems mutex locked
+func (r *Runner) queueReport(problem *problemDesc) {
+	r.batchedReports = append(r.batchedReports, problem)
+}
+
+// flushReports sends the queued notifications and the batched reports of the check cycle. The alerts are built with
+// the problems mutex locked, so they mention the problems that were correlated in this cycle, and sent after it was
+// released. Errors are logged and counted, so every message is sent even if sending a previous one failed
+func (r *Runner) flushReports() {
+	r.problemsMutex.Lock()
+	notifications := r.notifications
+	for i := range notifications {
+		if notifications[i].kind == notificationReport {
+			notifications[i].alert = r.getAlertProblem(notifications[i].problem)
+		}
+	}
+	reports := make([]notification, 0, len(r.batchedReports))
+	for _, problem := range r.batchedReports {
+		reports = append(reports, notification{kind: notificationReport, problem: problem, alert: r.getAlertProblem(problem)})
+	}
+	r.notifications = nil
+	r.batchedReports = nil
+	r.problemsMutex.Unlock()
+
+	r.sendNotifications(notifications)
+	if len(reports) > 0 {
+		r.batchReportProblems(reports)
+	}
+}
+
+// batchKey identifies the problems that are sent in a single message
+type batchKey struct {
+	problemType problemType
+	nodeGroup   string
+}
+
+// batchReportProblems groups the problems by type and node group and sends one message per group. Problems are
+// still tracked and resolved one by one
+func (r *Runner) batchReportProblems(reports []notification) {
+	keys := []batchKey{}
+	groups := make(map[batchKey][]notify.Problem)
+	for _, report := range reports {
+		key := batchKey{problemType: report.problem.problemType, nodeGroup: report.problem.nodeGroup}
+		if groups[key] == nil {
+			keys = append(keys, key)
+		}
+
+		r.addPodLogs(&report.alert, report.problem)
+		report.alert.Channel = r.getSlackChannel(report.problem)
+		groups[key] = append(groups[key], report.alert)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		if r.dryRun {
+			log.Info("Dry run: not sending batched report message", "problem_type", string(key.problemType), "node_group", key.nodeGroup, "count", len(group))
+			continue
+		}
+
+		log.Info("Sending batched report message", "problem_type", string(key.problemType), "node_group", key.nodeGroup, "count", len(group))
+
+		var err error
+		batchAlerter, ok := r.notifier.(notify.BatchAlerter)
+		if ok && len(group) > 1 {
+			err = batchAlerter.AlertBatch(group)
+		} else {
+			for _, p := range group {
+				err = r.notifier.Alert(p)
+				if err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			log.Error("Error sending batched report message", "problem_type", string(key.problemType), "node_group", key.nodeGroup, "error", err)
+			prometheus.NotificationErrors.Inc("batch")
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// defaultCertWarnDays is the number of days before the expiry of a tls certificate a problem is reported
+const defaultCertWarnDays = 30
+
+func (r *Runner) doWatchTLSSecrets(namespace string) error {
+	if !r.checkTLSSecrets {
+		return nil
+	}
+
+	var secretList *v1.SecretList
+	err := r.withRetry(func() (err error) {
+		secretList, err = r.client.Client().CoreV1().Secrets(namespace).List(r.listOptions(fields.OneTermEqualSelector("type", string(v1.SecretTypeTLS))))
+		return err
+	})
+	if apierrors.IsForbidden(err) {
+		// The other checks continue, the secrets rule of the clusterrole is commented out by default
+		log.Error("Not allowed to list the tls secrets, add secrets to the kube-problem clusterrole", "namespace", namespace, "error", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, secret := range secretList.Items {
+		if isIgnored(&secret) {
+			continue
+		}
+
+		cert, err := parseCertificate(secret.Data[v1.TLSCertKey])
+		if err != nil {
+			log.Debug("Couldn't parse tls certificate", "namespace", secret.Namespace, "secret", secret.Name, "error", err)
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		remaining := time.Until(cert.NotAfter)
+		if remaining < time.Duration(r.certWarnDays)*24*time.Hour {
+			days := int(remaining.Hours() / 24)
+			msg := fmt.Sprintf("TLS certificate '%s' in secret '%s/%s' expires in %d days (%s)", cert.Subject.CommonName, secret.Namespace, secret.Name, days, cert.NotAfter.UTC().Format(time.RFC3339))
+			if remaining <= 0 {
+				msg = fmt.Sprintf("TLS certificate '%s' in secret '%s/%s' has expired %d days ago (%s)", cert.Subject.CommonName, secret.Namespace, secret.Name, -days, cert.NotAfter.UTC().Format(time.RFC3339))
+			}
+
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeCertExpiry,
+
+				message: msg,
+				id:      secret.Name + "/" + secret.Namespace + string(problemTypeCertExpiry),
+
+				kind:      resourceKindSecret,
+				name:      secret.Name,
+				namespace: secret.Namespace,
+				occured:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindSecret, secret.Name, secret.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseCertificate parses the first certificate of the given pem data, which is the leaf certificate of a chain
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+
+	return nil, errors.New("no certificate found")
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDoWatchTLSSecretsForbidden(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		listed := false
+		client := newFakeClient()
+		client.clientset.(*fake.Clientset).PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			listed = true
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", fmt.Errorf("not allowed"))
+		})
+
+		r := newTestRunner(slack.NewMockClient())
+		r.namespaceSelector = fields.Everything()
+		r.client = client
+		r.checkTLSSecrets = enabled
+
+		err := r.doWatchTLSSecrets("default")
+		if err != nil {
+			t.Fatalf("Expected forbidden secrets to be skipped, got %v", err)
+		} else if listed != enabled {
+			t.Fatalf("Expected secrets listed %v with CHECK_TLS_SECRETS=%v, got %v", enabled, enabled, listed)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	v1 "k8s.io/api/core/v1"
+)
+
+// maxCorrelatedPodProblems is the maximum number of pod problems that are mentioned in the alert of a node problem
+const maxCorrelatedPodProblems = 5
+
+// correlateProblems links the problems of pods with the problems of the node they are scheduled on and vice versa.
+// The links are only used to mention the related problems in alerts and do not affect resolving
+func (r *Runner) correlateProblems() {
+	r.problemsMutex.Lock()
+	defer r.problemsMutex.Unlock()
+
+	nodeProblems := make(map[string][]*problemDesc)
+	for _, problem := range r.problems {
+		problem.correlatedWith = nil
+		if problem.kind == resourceKindNode {
+			nodeProblems[problem.name] = append(nodeProblems[problem.name], problem)
+		}
+	}
+	if len(nodeProblems) == 0 {
+		return
+	}
+
+	podNodes := make(map[string]string)
+	for _, podWatch := range r.podWatches {
+		for _, obj := range podWatch.Objects() {
+			pod := obj.(*v1.Pod)
+			if pod.Spec.NodeName != "" {
+				podNodes[pod.Namespace+"/"+pod.Name] = pod.Spec.NodeName
+			}
+		}
+	}
+
+	for _, problem := range r.problems {
+		if problem.kind != resourceKindPod {
+			continue
+		}
+
+		for _, nodeProblem := range nodeProblems[podNodes[problem.namespace+"/"+problem.name]] {
+			problem.correlatedWith = append(problem.correlatedWith, nodeProblem)
+			nodeProblem.correlatedWith = append(nodeProblem.correlatedWith, problem)
+		}
+	}
+}
+
+// toAlertProblem returns the problem that is sent as alert, which mentions the correlated problems in its message
+func (p *problemDesc) toAlertProblem() notify.Problem {
+	problem := p.toNotifyProblem()
+	if len(p.correlatedWith) > 0 {
+		messages := []string{}
+		for i, correlated := range p.correlatedWith {
+			if i == maxCorrelatedPodProblems {
+				messages = append(messages, fmt.Sprintf("and %d more", len(p.correlatedWith)-maxCorrelatedPodProblems))
+				break
+			}
+
+			messages = append(messages, correlated.message)
+		}
+
+		if p.kind == resourceKindNode {
+			problem.Message += fmt.Sprintf(". This may be related to pod problems on the node: %s", strings.Join(messages, "; "))
+		} else {
+			problem.Message += fmt.Sprintf(". This may be related to node problem: %s", strings.Join(messages, "; "))
+		}
+	}
+	return problem
+}
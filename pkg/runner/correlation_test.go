@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCorrelateBeforeSending(t *testing.T) {
+	tests := []struct {
+		name            string
+		podNode         string
+		expectedMessage string
+	}{
+		{
+			name:            "pod on the node with a problem",
+			podNode:         "node-1",
+			expectedMessage: "This may be related to node problem: Node node-1 is not ready",
+		},
+		{
+			name:    "pod on another node",
+			podNode: "node-2",
+		},
+	}
+
+	for _, test := range tests {
+		notifier := &recordingNotifier{}
+		r := newTestRunner(notifier)
+		r.client = newFakeClient(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			Spec:       v1.PodSpec{NodeName: test.podNode},
+		})
+		r.podWatches = map[string]*resourceListWatch{"": newPodListWatch(r.client, "", labels.Everything(), fields.Everything())}
+		startWatches(t, r)
+
+		// The problems are found in the same check cycle and alerted at its end
+		err := r.reportProblem(&problemDesc{
+			problemType: problemTypePodStatus,
+			kind:        resourceKindPod,
+			name:        "pod",
+			namespace:   "default",
+			id:          "default/pod/status",
+			message:     "Pod default/pod has critical status CrashLoopBackOff",
+			occured:     time.Now(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.reportProblem(&problemDesc{
+			problemType: problemTypeNodeCondition,
+			kind:        resourceKindNode,
+			name:        "node-1",
+			id:          "node-1/condition",
+			message:     "Node node-1 is not ready",
+			occured:     time.Now(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(notifier.alerts) != 0 {
+			t.Fatalf("%s: expected no alerts before the end of the check cycle, got %d", test.name, len(notifier.alerts))
+		}
+
+		r.correlateProblems()
+		r.flushReports()
+
+		if len(notifier.alerts) != 2 {
+			t.Fatalf("%s: expected 2 alerts, got %d", test.name, len(notifier.alerts))
+		}
+
+		podMessage := notifier.alertMessages[0]
+		if test.expectedMessage == "" && strings.Contains(podMessage, "related") {
+			t.Fatalf("%s: unexpected correlation in %q", test.name, podMessage)
+		} else if !strings.Contains(podMessage, test.expectedMessage) {
+			t.Fatalf("%s: expected %q in %q", test.name, test.expectedMessage, podMessage)
+		}
+	}
+}
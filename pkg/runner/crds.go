@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// parseWatchCRDs parses a comma separated list of group/version/resource tuples (e.g. cert-manager.io/v1/certificates).
+// Resources of the core group can be specified as /version/resource
+func parseWatchCRDs(value string) ([]schema.GroupVersionResource, error) {
+	gvrs := []schema.GroupVersionResource{}
+	for _, tuple := range strings.Split(value, ",") {
+		tuple = strings.TrimSpace(tuple)
+		if tuple == "" {
+			continue
+		}
+
+		splitted := strings.Split(tuple, "/")
+		if len(splitted) != 3 || splitted[1] == "" || splitted[2] == "" {
+			return nil, fmt.Errorf("Invalid crd %s, expected group/version/resource", tuple)
+		}
+
+		gvrs = append(gvrs, schema.GroupVersionResource{Group: splitted[0], Version: splitted[1], Resource: splitted[2]})
+	}
+
+	return gvrs, nil
+}
+
+func (r *Runner) doWatchCRDs(namespace string) error {
+	for _, gvr := range r.watchCRDs {
+		err := r.doWatchCRD(gvr, namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) doWatchCRD(gvr schema.GroupVersionResource, namespace string) error {
+	var list *unstructured.UnstructuredList
+	err := r.withRetry(func() (err error) {
+		list, err = r.client.Dynamic().Resource(gvr).Namespace(namespace).List(r.listOptions())
+		return err
+	})
+	if apierrors.IsForbidden(err) {
+		// The other checks continue, the clusterrole has to be extended for every crd in WATCH_CRDS
+		log.Error("Not allowed to list the custom resources, add them to the kube-problem clusterrole", "resource", gvr.String(), "namespace", namespace, "error", err)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Error listing %s: %v", gvr.String(), err)
+	}
+
+	for _, obj := range list.Items {
+		if isIgnored(&obj) {
+			continue
+		}
+
+		kind := resourceKind(obj.GetKind())
+		if kind == "" {
+			kind = resourceKind(gvr.Resource)
+		}
+
+		// Handle problem reporting or resolving
+		message, notReady := getNotReadyCondition(&obj)
+		if notReady {
+			msg := fmt.Sprintf("%s '%s/%s' (%s) is not ready: %s", kind, obj.GetNamespace(), obj.GetName(), gvr.String(), message)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeCRDStatus,
+
+				message: msg,
+				id:      obj.GetName() + "/" + obj.GetNamespace() + string(problemTypeCRDStatus) + "/" + gvr.String(),
+
+				kind:      kind,
+				name:      obj.GetName(),
+				namespace: obj.GetNamespace(),
+				occured:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(kind, obj.GetName(), obj.GetNamespace())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getNotReadyCondition returns the message of the Ready condition if its status is False
+func getNotReadyCondition(obj *unstructured.Unstructured) (string, bool) {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return "", false
+	}
+
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" || condition["status"] != "False" {
+			continue
+		}
+
+		message, _, _ := unstructured.NestedString(condition, "message")
+		if message == "" {
+			message, _, _ = unstructured.NestedString(condition, "reason")
+		}
+
+		return message, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestCertificate(name, ready string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":    "Ready",
+					"status":  ready,
+					"message": "Issuing certificate as Secret does not exist",
+				},
+			},
+		},
+	}}
+}
+
+func TestParseWatchCRDs(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  []schema.GroupVersionResource
+		expectErr bool
+	}{
+		{
+			value:    "",
+			expected: []schema.GroupVersionResource{},
+		},
+		{
+			value:    "cert-manager.io/v1/certificates, /v1/configmaps",
+			expected: []schema.GroupVersionResource{{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}, {Version: "v1", Resource: "configmaps"}},
+		},
+		{
+			value:     "cert-manager.io/certificates",
+			expectErr: true,
+		},
+		{
+			value:     "cert-manager.io//certificates",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		gvrs, err := parseWatchCRDs(test.value)
+		if (err != nil) != test.expectErr {
+			t.Fatalf("%s: unexpected error %v", test.value, err)
+		}
+		if test.expectErr {
+			continue
+		}
+		if len(gvrs) != len(test.expected) {
+			t.Fatalf("%s: expected %v, got %v", test.value, test.expected, gvrs)
+		}
+		for i := range gvrs {
+			if gvrs[i] != test.expected[i] {
+				t.Fatalf("%s: expected %v, got %v", test.value, test.expected, gvrs)
+			}
+		}
+	}
+}
+
+func TestDoWatchCRD(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+	tests := []struct {
+		name          string
+		ready         string
+		expectProblem bool
+	}{
+		{
+			name:          "not ready",
+			ready:         "False",
+			expectProblem: true,
+		},
+		{
+			name:  "ready",
+			ready: "True",
+		},
+	}
+
+	for _, test := range tests {
+		r := newTestRunner(slack.NewMockClient())
+		r.namespaceSelector = fields.Everything()
+		r.client = &fakeClient{dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newTestCertificate("cert", test.ready))}
+
+		err := r.doWatchCRD(gvr, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := r.problems["cert/default"+string(problemTypeCRDStatus)+"/"+gvr.String()]; ok != test.expectProblem {
+			t.Fatalf("%s: expected problem %v, got %v", test.name, test.expectProblem, ok)
+		}
+	}
+}
+
+func TestDoWatchCRDForbidden(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	dynamic := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	dynamic.PrependReactor("list", "certificates", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(gvr.GroupResource(), "", fmt.Errorf("not allowed"))
+	})
+
+	r := newTestRunner(slack.NewMockClient())
+	r.namespaceSelector = fields.Everything()
+	r.client = &fakeClient{dynamic: dynamic}
+
+	err := r.doWatchCRD(gvr, "default")
+	if err != nil {
+		t.Fatalf("Expected forbidden custom resources to be skipped, got %v", err)
+	}
+}
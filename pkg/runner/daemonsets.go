@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func (r *Runner) doWatchDaemonSets(namespace string) error {
+	var daemonSetList *appsv1.DaemonSetList
+	err := r.withRetry(func() (err error) {
+		daemonSetList, err = r.client.Client().AppsV1().DaemonSets(namespace).List(r.listOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, daemonSet := range daemonSetList.Items {
+		if isIgnored(&daemonSet) {
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		if daemonSet.Status.NumberUnavailable > 0 {
+			msg := fmt.Sprintf("DaemonSet '%s/%s' has %d of %d desired pod(s) unavailable for more than %v", daemonSet.Namespace, daemonSet.Name, daemonSet.Status.NumberUnavailable, daemonSet.Status.DesiredNumberScheduled, r.daemonSetUnavailableTimeout)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeDaemonSetUnavailable,
+
+				message: msg,
+				id:      daemonSet.Name + "/" + daemonSet.Namespace + string(problemTypeDaemonSetUnavailable),
+
+				kind:        resourceKindDaemonSet,
+				name:        daemonSet.Name,
+				namespace:   daemonSet.Namespace,
+				occured:     time.Now(),
+				reportAfter: r.daemonSetUnavailableTimeout,
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindDaemonSet, daemonSet.Name, daemonSet.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// deploymentRevisionAnnotation is the annotation the deployment controller sets to the current revision
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// maxDeploymentTemplates is the maximum number of pod templates per deployment that are remembered to detect rollbacks
+const maxDeploymentTemplates = 10
+
+func (r *Runner) doWatchDeployments(namespace string) error {
+	var deploymentList *appsv1.DeploymentList
+	err := r.withRetry(func() (err error) {
+		deploymentList, err = r.client.Client().AppsV1().Deployments(namespace).List(r.listOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, deployment := range deploymentList.Items {
+		if isIgnored(&deployment) {
+			continue
+		}
+
+		// Check if the deployment was rolled back to an older revision
+		if oldRevision, rolledBack := r.checkDeploymentRevision(&deployment); rolledBack {
+			revision := deployment.Annotations[deploymentRevisionAnnotation]
+			msg := fmt.Sprintf("Deployment '%s/%s' was rolled back from revision %s to revision %s, this often indicates a problem with the new version", deployment.Namespace, deployment.Name, oldRevision, revision)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeDeploymentRollback,
+
+				message: msg,
+				id:      deployment.Name + "/" + deployment.Namespace + string(problemTypeDeploymentRollback) + revision,
+
+				kind:      resourceKindDeployment,
+				name:      deployment.Name,
+				namespace: deployment.Namespace,
+				occured:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Handle problem reporting or resolving
+		if deployment.Status.UnavailableReplicas > 0 {
+			msg := fmt.Sprintf("Deployment '%s/%s' has %d unavailable replica(s) for more than %v", deployment.Namespace, deployment.Name, deployment.Status.UnavailableReplicas, r.deploymentStallTimeout)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeDeploymentStall,
+
+				message: msg,
+				id:      deployment.Name + "/" + deployment.Namespace + string(problemTypeDeploymentStall),
+
+				kind:        resourceKindDeployment,
+				name:        deployment.Name,
+				namespace:   deployment.Namespace,
+				occured:     time.Now(),
+				reportAfter: r.deploymentStallTimeout,
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindDeployment, deployment.Name, deployment.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkDeploymentRevision remembers the revision of the deployment and returns the previous revision and true if the
+// deployment was rolled back since the last check. A rollback either decreases the revision or, as with
+// kubectl rollout undo, creates a new revision with the pod template of an older revision
+func (r *Runner) checkDeploymentRevision(deployment *appsv1.Deployment) (string, bool) {
+	revision, ok := deployment.Annotations[deploymentRevisionAnnotation]
+	if !ok {
+		return "", false
+	}
+
+	template, err := hashPodTemplate(deployment)
+	if err != nil {
+		return "", false
+	}
+
+	r.deploymentRevisionsMutex.Lock()
+	defer r.deploymentRevisionsMutex.Unlock()
+
+	key := deployment.Namespace + "/" + deployment.Name
+	lastRevision, seen := r.deploymentRevisions[key]
+	templateRevisions := r.deploymentTemplateRevisions[key]
+	if templateRevisions == nil || len(templateRevisions) >= maxDeploymentTemplates {
+		templateRevisions = make(map[string]string)
+		r.deploymentTemplateRevisions[key] = templateRevisions
+	}
+
+	templateRevision, templateSeen := templateRevisions[template]
+	r.deploymentRevisions[key] = revision
+	templateRevisions[template] = revision
+	if !seen || lastRevision == revision {
+		return "", false
+	}
+
+	newRevision, err := strconv.Atoi(revision)
+	if err != nil {
+		return "", false
+	}
+	oldRevision, err := strconv.Atoi(lastRevision)
+	if err != nil {
+		return "", false
+	}
+
+	return lastRevision, newRevision < oldRevision || (templateSeen && templateRevision != lastRevision)
+}
+
+// hashPodTemplate returns a hash of the pod template of the deployment
+func hashPodTemplate(deployment *appsv1.Deployment) (string, error) {
+	out, err := json.Marshal(deployment.Spec.Template)
+	if err != nil {
+		return "", err
+	}
+
+	hash := fnv.New32a()
+	hash.Write(out)
+	return strconv.FormatUint(uint64(hash.Sum32()), 16), nil
+}
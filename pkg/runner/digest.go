@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+const digestWindow = time.Hour * 24 * 7
+const digestMaxProblemsPerType = 5
+
+// digest collects all problems of the last 7 days including resolved ones
+type digest struct {
+	day  time.Weekday
+	hour int
+
+	mutex    sync.Mutex
+	entries  map[string]*digestEntry
+	lastSent time.Time
+}
+
+type digestEntry struct {
+	problemType problemType
+	resource    string
+	message     string
+
+	count    int
+	lastSeen time.Time
+}
+
+func newDigestFromEnv() *digest {
+	if os.Getenv("ENABLE_DIGEST") != "true" {
+		return nil
+	}
+
+	day := time.Monday
+	if os.Getenv("DIGEST_DAY") != "" {
+		found := false
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(weekday.String(), os.Getenv("DIGEST_DAY")) {
+				day = weekday
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Warn("Invalid value, expected a weekday like Monday, using default", "env", "DIGEST_DAY", "value", os.Getenv("DIGEST_DAY"), "default", day.String())
+		}
+	}
+
+	hour := 9
+	if os.Getenv("DIGEST_HOUR") != "" {
+		parsed, err := strconv.Atoi(os.Getenv("DIGEST_HOUR"))
+		if err != nil || parsed < 0 || parsed > 23 {
+			log.Warn("Invalid value, expected a number between 0 and 23, using default", "env", "DIGEST_HOUR", "value", os.Getenv("DIGEST_HOUR"), "default", hour)
+		} else {
+			hour = parsed
+		}
+	}
+
+	log.Info("Sending a weekly digest", "day", day.String(), "hour", hour)
+	return &digest{
+		day:     day,
+		hour:    hour,
+		entries: make(map[string]*digestEntry),
+	}
+}
+
+// record records an occurrence of the given problem
+func (d *digest) record(problem *problemDesc) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.entries[problem.id] == nil {
+		d.entries[problem.id] = &digestEntry{
+			problemType: problem.problemType,
+			resource:    problem.toNotifyProblem().Resource(),
+			message:     problem.message,
+		}
+	}
+
+	d.entries[problem.id].count++
+	d.entries[problem.id].lastSeen = time.Now()
+}
+
+// run sends the digest at the configured time until the context is cancelled
+func (d *digest) run(ctx context.Context, sender notify.MessageSender) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if now.Weekday() != d.day || now.Hour() != d.hour || now.Sub(d.lastSent) < time.Hour*24 {
+				continue
+			}
+
+			d.lastSent = now
+			log.Info("Sending weekly digest")
+			err := sender.SendMessage("", d.message())
+			if err != nil {
+				log.Error("Error sending weekly digest", "error", err)
+			}
+		}
+	}
+}
+
+func (d *digest) message() string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// Group the problems of the last 7 days by type
+	groups := map[problemType][]*digestEntry{}
+	totals := map[problemType]int{}
+	for id, entry := range d.entries {
+		if time.Since(entry.lastSeen) > digestWindow {
+			delete(d.entries, id)
+			continue
+		}
+
+		groups[entry.problemType] = append(groups[entry.problemType], entry)
+		totals[entry.problemType] += entry.count
+	}
+	if len(groups) == 0 {
+		return "Weekly digest: there were no problems in the cluster in the last 7 days :tada:"
+	}
+
+	problemTypes := make([]problemType, 0, len(groups))
+	for problemType := range groups {
+		problemTypes = append(problemTypes, problemType)
+	}
+	sort.Slice(problemTypes, func(i, j int) bool {
+		return totals[problemTypes[i]] > totals[problemTypes[j]]
+	})
+
+	lines := []string{"Weekly digest: these were the most recurring problems of the last 7 days"}
+	for _, problemType := range problemTypes {
+		entries := groups[problemType]
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].count > entries[j].count
+		})
+
+		lines = append(lines, fmt.Sprintf("*%s* (%d problem(s), occured %d time(s))", problemType, len(entries), totals[problemType]))
+		for i, entry := range entries {
+			if i == digestMaxProblemsPerType {
+				lines = append(lines, fmt.Sprintf("    • ... and %d more", len(entries)-digestMaxProblemsPerType))
+				break
+			}
+
+			lines = append(lines, fmt.Sprintf("    • %s (%dx): %s", entry.resource, entry.count, entry.message))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func (r *Runner) doWatchEndpoints(namespace string) error {
+	var endpointsList *v1.EndpointsList
+	err := r.withRetry(func() (err error) {
+		endpointsList, err = r.client.Client().CoreV1().Endpoints(namespace).List(r.listOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, endpoints := range endpointsList.Items {
+		if isIgnored(&endpoints) {
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		ready, notReady := countEndpointAddresses(&endpoints)
+		if ready == 0 && notReady > 0 {
+			msg := fmt.Sprintf("Service '%s/%s' has no ready endpoints (%d not ready), traffic to the service will fail", endpoints.Namespace, endpoints.Name, notReady)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeNoReadyEndpoints,
+
+				message: msg,
+				id:      endpoints.Name + "/" + endpoints.Namespace + string(problemTypeNoReadyEndpoints),
+
+				kind:      resourceKindService,
+				name:      endpoints.Name,
+				namespace: endpoints.Namespace,
+				occured:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindService, endpoints.Name, endpoints.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// countEndpointAddresses returns the number of ready and not ready addresses of the endpoints
+func countEndpointAddresses(endpoints *v1.Endpoints) (int, int) {
+	ready, notReady := 0, 0
+	for _, subset := range endpoints.Subsets {
+		ready += len(subset.Addresses)
+		notReady += len(subset.NotReadyAddresses)
+	}
+
+	return ready, notReady
+}
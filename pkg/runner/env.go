@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+)
+
+// getRatioFromEnv parses a ratio between 0.0 and 1.0 from the given environment variable
+func getRatioFromEnv(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		log.Warn("Invalid value, expected a value between 0.0 and 1.0, using default", "env", name, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+
+	return ratio
+}
+
+// getFactorFromEnv parses a positive factor (e.g. 1.5) from the given environment variable
+func getFactorFromEnv(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	factor, err := strconv.ParseFloat(value, 64)
+	if err != nil || factor <= 0 {
+		log.Warn("Invalid value, expected a number greater than 0, using default", "env", name, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+
+	return factor
+}
+
+// getCountFromEnv parses a positive count from the given environment variable
+func getCountFromEnv(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		log.Warn("Invalid value, expected a number greater than 0, using default", "env", name, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+
+	return count
+}
+
+// getDurationFromEnv parses a positive duration (e.g. 5m) from the given environment variable
+func getDurationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Warn("Invalid value, expected a duration like 5m, using default", "env", name, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+
+	return duration
+}
+
+// getNamespaceIntervalsFromEnv parses comma separated namespace=seconds pairs (e.g. prod=5,staging=30) from the
+// given environment variable. Invalid pairs are skipped
+func getNamespaceIntervalsFromEnv(name string) map[string]time.Duration {
+	intervals := make(map[string]time.Duration)
+	for _, pair := range strings.Split(os.Getenv(name), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		splitted := strings.Split(pair, "=")
+		if len(splitted) != 2 || strings.TrimSpace(splitted[0]) == "" {
+			log.Warn("Invalid value, expected namespace=seconds, skipping", "env", name, "value", pair)
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimSpace(splitted[1]))
+		if err != nil || seconds < 1 || seconds > 3600 {
+			log.Warn("Invalid interval, expected seconds between 1 and 3600, skipping", "env", name, "namespace", splitted[0], "value", splitted[1])
+			continue
+		}
+
+		intervals[strings.TrimSpace(splitted[0])] = time.Duration(seconds) * time.Second
+	}
+
+	return intervals
+}
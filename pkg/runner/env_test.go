@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNamespaceIntervalsFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string]time.Duration
+	}{
+		{
+			name:     "unset",
+			value:    "",
+			expected: map[string]time.Duration{},
+		},
+		{
+			name:  "valid",
+			value: "prod=5, staging = 30",
+			expected: map[string]time.Duration{
+				"prod":    5 * time.Second,
+				"staging": 30 * time.Second,
+			},
+		},
+		{
+			name:  "out of range",
+			value: "prod=0,staging=3601,dev=3600",
+			expected: map[string]time.Duration{
+				"dev": time.Hour,
+			},
+		},
+		{
+			name:  "invalid pairs",
+			value: "prod,=5,staging=fast,dev=10=20,test=10",
+			expected: map[string]time.Duration{
+				"test": 10 * time.Second,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Setenv("NAMESPACE_INTERVALS", test.value)
+
+		intervals := getNamespaceIntervalsFromEnv("NAMESPACE_INTERVALS")
+		if len(intervals) != len(test.expected) {
+			t.Fatalf("%s: expected intervals %v, got %v", test.name, test.expected, intervals)
+		}
+		for namespace, interval := range test.expected {
+			if intervals[namespace] != interval {
+				t.Fatalf("%s: expected interval %v for namespace %s, got %v", test.name, interval, namespace, intervals[namespace])
+			}
+		}
+	}
+}
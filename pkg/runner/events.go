@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// defaultWatchEventReasons are the reasons of warning events that are reported by default
+const defaultWatchEventReasons = "FailedScheduling,Evicted,BackOff,NodeNotReady,FailedMount,FailedCreate"
+
+// eventProblemTypes maps the reasons of warning events to the problem types the pod and node checks report the
+// same problem with. Events whose problem was already found by these checks are not alerted a second time
+var eventProblemTypes = map[resourceKind]map[string]problemType{
+	resourceKindPod: {
+		"FailedScheduling": problemTypePodPending,
+		"Evicted":          problemTypePodStatus,
+		"BackOff":          problemTypePodStatus,
+		"NodeNotReady":     problemTypePodOnNotReadyNode,
+	},
+	resourceKindNode: {
+		"NodeNotReady": problemTypeNodeCondition,
+	},
+}
+
+func (r *Runner) doWatchEvents(namespace string) error {
+	var eventList *v1.EventList
+	err := r.withRetry(func() (err error) {
+		eventList, err = r.client.Client().CoreV1().Events(namespace).List(r.listOptions(fields.OneTermEqualSelector("type", v1.EventTypeWarning)))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, event := range eventList.Items {
+		// Only events that happened since the last check are relevant, older events were already processed
+		if !r.watchEventReasons[event.Reason] || time.Since(getEventTime(&event)) > defaultInterval*2 {
+			continue
+		}
+
+		kind := resourceKind(event.InvolvedObject.Kind)
+		problemType, mapped := eventProblemTypes[kind][event.Reason]
+		if mapped && r.hasProblem(kind, event.InvolvedObject.Name, event.InvolvedObject.Namespace, problemType) {
+			continue
+		} else if !mapped {
+			problemType = problemTypeWarningEvent
+		}
+
+		if !r.isWatchedEventObject(&event.InvolvedObject) {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s '%s' has warning event '%s' (seen %d time(s)): %s", kind, event.InvolvedObject.Name, event.Reason, event.Count, event.Message)
+		if event.InvolvedObject.Namespace != "" {
+			msg = fmt.Sprintf("%s '%s/%s' has warning event '%s' (seen %d time(s)): %s", kind, event.InvolvedObject.Namespace, event.InvolvedObject.Name, event.Reason, event.Count, event.Message)
+		}
+
+		// Mapped events use the id of the pod and node checks, so the problem is not reported twice if the check
+		// finds it later
+		id := event.InvolvedObject.Name + "/" + event.InvolvedObject.Namespace + string(problemType)
+		if !mapped {
+			id += event.Reason
+		}
+		seen[id] = true
+
+		err = r.reportProblem(&problemDesc{
+			problemType: problemType,
+
+			message: msg,
+			id:      id,
+
+			kind:           kind,
+			name:           event.InvolvedObject.Name,
+			namespace:      event.InvolvedObject.Namespace,
+			eventNamespace: event.Namespace,
+			occured:        time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	r.resolveWarningEvents(namespace, seen)
+	return nil
+}
+
+// hasProblem returns if the checks (not the events) found a problem of the type for the resource
+func (r *Runner) hasProblem(kind resourceKind, name, namespace string, problemType problemType) bool {
+	r.problemsMutex.Lock()
+	defer r.problemsMutex.Unlock()
+
+	for _, problem := range r.problems {
+		if problem.kind == kind && problem.name == name && problem.namespace == namespace && problem.problemType == problemType && problem.eventNamespace == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWatchedEventObject returns if the resource of the event is checked. Events of pods and nodes that do not match
+// WATCH_LABEL_SELECTOR or WATCH_NODE_SELECTOR are skipped as well as events of opted out pods and ignored resources
+func (r *Runner) isWatchedEventObject(object *v1.ObjectReference) bool {
+	switch resourceKind(object.Kind) {
+	case resourceKindPod:
+		pod, ok := r.getWatchedPod(object.Namespace, object.Name)
+		return ok && !r.isOptedOut(pod)
+	case resourceKindNode:
+		if r.nodeWatch == nil {
+			return false
+		}
+
+		node, ok := r.nodeWatch.Get(object.Name).(*v1.Node)
+		return ok && !isIgnored(node)
+	}
+
+	gvr, _ := meta.UnsafeGuessKindToResource(schema.FromAPIVersionAndKind(object.APIVersion, object.Kind))
+	obj, err := r.client.Dynamic().Resource(gvr).Namespace(object.Namespace).Get(object.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false
+	} else if err != nil {
+		// Events of resources kube-problem is not allowed to read are reported
+		log.Debug("Couldn't get the resource of the event", "resource", gvr.String(), "namespace", object.Namespace, "name", object.Name, "error", err)
+		return true
+	}
+
+	return !isIgnored(obj)
+}
+
+// getWatchedPod returns the pod from the pod informers, pods not matching the selectors are not found
+func (r *Runner) getWatchedPod(namespace, name string) (*v1.Pod, bool) {
+	for _, podWatch := range []*resourceListWatch{r.podWatches[namespace], r.podWatches[metav1.NamespaceAll]} {
+		if podWatch == nil {
+			continue
+		}
+
+		if pod, ok := podWatch.Get(namespace + "/" + name).(*v1.Pod); ok {
+			return pod, true
+		}
+	}
+
+	return nil, false
+}
+
+// resolveWarningEvents resolves the problems found by the events of the namespace whose events did not occur again
+func (r *Runner) resolveWarningEvents(namespace string, seen map[string]bool) {
+	r.problemsMutex.Lock()
+	defer r.problemsMutex.Unlock()
+
+	for id, problem := range r.problems {
+		if problem.eventNamespace == "" || seen[id] || (namespace != metav1.NamespaceAll && problem.eventNamespace != namespace) {
+			continue
+		}
+
+		r.deleteProblem(id)
+		r.queueResolve(problem)
+	}
+}
+
+// getWatchEventReasonsFromEnv returns the event reasons of WATCH_EVENT_REASONS without the reasons of IGNORE_EVENT_REASONS
+func getWatchEventReasonsFromEnv() map[string]bool {
+	value := os.Getenv("WATCH_EVENT_REASONS")
+	if strings.TrimSpace(value) == "" {
+		value = defaultWatchEventReasons
+	}
+
+	reasons := make(map[string]bool)
+	for _, reason := range strings.Split(value, ",") {
+		if reason = strings.TrimSpace(reason); reason != "" {
+			reasons[reason] = true
+		}
+	}
+	for _, reason := range strings.Split(os.Getenv("IGNORE_EVENT_REASONS"), ",") {
+		delete(reasons, strings.TrimSpace(reason))
+	}
+
+	return reasons
+}
+
+func getEventTime(event *v1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	} else if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+
+	return event.FirstTimestamp.Time
+}
@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestResolveWarningEvents(t *testing.T) {
+	tests := []struct {
+		name           string
+		namespace      string
+		eventNamespace string
+		seen           bool
+		expectResolved bool
+	}{
+		{
+			name:           "event occured again",
+			namespace:      "default",
+			eventNamespace: "default",
+			seen:           true,
+		},
+		{
+			name:           "event did not occur again",
+			namespace:      "default",
+			eventNamespace: "default",
+			expectResolved: true,
+		},
+		{
+			name:           "event of another namespace",
+			namespace:      "other",
+			eventNamespace: "default",
+		},
+		{
+			name:           "all namespaces",
+			namespace:      metav1.NamespaceAll,
+			eventNamespace: "default",
+			expectResolved: true,
+		},
+	}
+
+	for _, test := range tests {
+		notifier := slack.NewMockClient()
+		r := newTestRunner(notifier)
+
+		id := "pod/default" + string(problemTypeWarningEvent) + "FailedMount"
+		r.problems[id] = &problemDesc{
+			problemType:    problemTypeWarningEvent,
+			kind:           resourceKindPod,
+			name:           "pod",
+			namespace:      "default",
+			eventNamespace: test.eventNamespace,
+			id:             id,
+			message:        "Pod 'default/pod' has warning event 'FailedMount'",
+			occured:        time.Now(),
+			reported:       true,
+		}
+
+		r.resolveWarningEvents(test.namespace, map[string]bool{id: test.seen})
+		r.flushReports()
+
+		if _, ok := r.problems[id]; ok == test.expectResolved {
+			t.Fatalf("%s: expected resolved %v, problem still exists %v", test.name, test.expectResolved, ok)
+		}
+		if resolves := notifier.Resolves(); (len(resolves) == 1) != test.expectResolved {
+			t.Fatalf("%s: unexpected resolves %v", test.name, resolves)
+		}
+	}
+}
+
+func TestGetWatchEventReasonsFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		watch    string
+		ignore   string
+		expected []string
+	}{
+		{
+			name:     "default",
+			expected: []string{"FailedScheduling", "Evicted", "BackOff", "NodeNotReady", "FailedMount", "FailedCreate"},
+		},
+		{
+			name:     "configured",
+			watch:    "BackOff, FailedScheduling",
+			expected: []string{"BackOff", "FailedScheduling"},
+		},
+		{
+			name:     "ignored",
+			ignore:   "BackOff,Evicted",
+			expected: []string{"FailedScheduling", "NodeNotReady", "FailedMount", "FailedCreate"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Setenv("WATCH_EVENT_REASONS", test.watch)
+		t.Setenv("IGNORE_EVENT_REASONS", test.ignore)
+
+		reasons := getWatchEventReasonsFromEnv()
+		if len(reasons) != len(test.expected) {
+			t.Fatalf("%s: expected reasons %v, got %v", test.name, test.expected, reasons)
+		}
+		for _, reason := range test.expected {
+			if !reasons[reason] {
+				t.Fatalf("%s: expected reasons %v, got %v", test.name, test.expected, reasons)
+			}
+		}
+	}
+}
+
+// newWarningEvent returns a warning event of the default namespace that just occurred
+func newWarningEvent(kind, apiVersion, name, reason string) *v1.Event {
+	return &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name + "." + reason, Namespace: "default"},
+		InvolvedObject: v1.ObjectReference{Kind: kind, APIVersion: apiVersion, Name: name, Namespace: "default"},
+		Type:           v1.EventTypeWarning,
+		Reason:         reason,
+		Count:          1,
+		LastTimestamp:  metav1.Now(),
+	}
+}
+
+func TestDoWatchEvents(t *testing.T) {
+	healthy := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	replicaSet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "ReplicaSet",
+		"metadata": map[string]interface{}{
+			"name":        "ignored-rs",
+			"namespace":   "default",
+			"annotations": map[string]interface{}{IgnoreAnnotation: "true"},
+		},
+	}}
+
+	client := newFakeClient(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		healthy,
+		newCrashLoopPod("broken", nil),
+		newCrashLoopPod("ignored", map[string]string{IgnoreAnnotation: "true"}),
+		newWarningEvent("Pod", "v1", "web", "FailedMount"),
+		newWarningEvent("Pod", "v1", "web", "BackOff"),
+		newWarningEvent("Pod", "v1", "broken", "BackOff"),
+		newWarningEvent("Pod", "v1", "ignored", "FailedMount"),
+		newWarningEvent("Pod", "v1", "deselected", "FailedMount"),
+		newWarningEvent("ReplicaSet", "apps/v1", "ignored-rs", "FailedCreate"),
+	)
+	client.dynamic = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), replicaSet)
+
+	r := newFakeRunner(t, client, &recordingNotifier{}, false, []string{"default"})
+	startWatches(t, r)
+
+	err := r.doWatchNamespace("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.doWatchEvents("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		// Found by the pod check, the event of the same problem is not reported again
+		"broken/default" + string(problemTypePodStatus): "",
+		// Events are mapped to the problem types of the pod check or reported as warning events
+		"web/default" + string(problemTypePodStatus):                    "default",
+		"web/default" + string(problemTypeWarningEvent) + "FailedMount": "default",
+	}
+	for id, problem := range r.problems {
+		eventNamespace, ok := expected[id]
+		if !ok {
+			t.Fatalf("Unexpected problem %s", id)
+		} else if problem.eventNamespace != eventNamespace {
+			t.Fatalf("Expected event namespace '%s' of problem %s, got '%s'", eventNamespace, id, problem.eventNamespace)
+		}
+	}
+	if len(r.problems) != len(expected) {
+		t.Fatalf("Expected problems %v, got %v", expected, r.problems)
+	}
+
+	// The pod check does not resolve the problems found by events, they are resolved once the events stop
+	err = r.resolveProblemsOf(resourceKindPod, "web", "default")
+	if err != nil {
+		t.Fatal(err)
+	} else if _, ok := r.problems["web/default"+string(problemTypePodStatus)]; !ok {
+		t.Fatalf("Expected the problem of the event not to be resolved by the pod check")
+	}
+
+	r.resolveWarningEvents("default", map[string]bool{})
+	if _, ok := r.problems["web/default"+string(problemTypePodStatus)]; ok {
+		t.Fatalf("Expected the problem of the event to be resolved once the event stopped")
+	} else if _, ok := r.problems["broken/default"+string(problemTypePodStatus)]; !ok {
+		t.Fatalf("Expected the problem of the pod check not to be resolved by the events")
+	}
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultNodeFlapCount = 3
+const defaultNodeFlapWindow = time.Minute * 10
+
+// recordNodeTransitions records the Ready to NotReady transition of the node if it is not ready and returns the
+// number of transitions within the flap window. It is called on every node update that changes the ready status, so
+// nodes that are only not ready between two checks are counted as well
+func (r *Runner) recordNodeTransitions(node *v1.Node) int {
+	r.nodeConditionHistoryMutex.Lock()
+	defer r.nodeConditionHistoryMutex.Unlock()
+
+	transitions := r.nodeConditionHistory[node.Name]
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != v1.NodeReady || condition.Status == v1.ConditionTrue || condition.LastTransitionTime.IsZero() {
+			continue
+		}
+
+		if len(transitions) == 0 || condition.LastTransitionTime.Time.After(transitions[len(transitions)-1]) {
+			transitions = append(transitions, condition.LastTransitionTime.Time)
+		}
+	}
+
+	// Forget the transitions outside of the window
+	for len(transitions) > 0 && time.Since(transitions[0]) > r.nodeFlapWindow {
+		transitions = transitions[1:]
+	}
+	if len(transitions) == 0 {
+		delete(r.nodeConditionHistory, node.Name)
+		return 0
+	}
+
+	r.nodeConditionHistory[node.Name] = transitions
+	return len(transitions)
+}
+
+func (r *Runner) deleteNodeTransitions(nodeName string) {
+	r.nodeConditionHistoryMutex.Lock()
+	defer r.nodeConditionHistoryMutex.Unlock()
+
+	delete(r.nodeConditionHistory, nodeName)
+}
+
+// checkNodeFlapping reports nodes with more than nodeFlapCount transitions to NotReady within the flap window. The
+// problem is only resolved once the node stopped flapping, so it is not resolved whenever the node is ready again
+func (r *Runner) checkNodeFlapping(node *v1.Node, role string) error {
+	id := node.Name + string(problemTypeNodeFlapping)
+	transitions := r.recordNodeTransitions(node)
+	if transitions <= r.nodeFlapCount {
+		return r.resolveProblemWithID(id)
+	}
+
+	msg := fmt.Sprintf("Node '%s' (%s) is flapping, it became not ready %d times within the last %v", node.Name, role, transitions, r.nodeFlapWindow)
+	return r.reportProblem(&problemDesc{
+		problemType: problemTypeNodeFlapping,
+		kind:        resourceKindNode,
+		name:        node.Name,
+		nodeGroup:   r.getNodeGroup(node),
+
+		message: msg,
+		id:      id,
+		occured: time.Now(),
+	})
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/api"
+)
+
+const defaultProblemHistorySize = 100
+
+// problemHistory is a ring buffer that holds the last resolved problems
+type problemHistory struct {
+	mutex   sync.Mutex
+	entries []*api.ResolvedProblem
+	next    int
+	full    bool
+}
+
+func newProblemHistory(size int) *problemHistory {
+	return &problemHistory{
+		entries: make([]*api.ResolvedProblem, size),
+	}
+}
+
+// add records the resolved problem and overwrites the oldest one if the history is full
+func (h *problemHistory) add(problem *problemDesc, resolved time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.entries[h.next] = &api.ResolvedProblem{
+		Problem:  *problem.toAPIProblem(),
+		Resolved: resolved,
+	}
+
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns the resolved problems, the most recently resolved problem first
+func (h *problemHistory) list() []*api.ResolvedProblem {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	count := h.next
+	if h.full {
+		count = len(h.entries)
+	}
+
+	problems := make([]*api.ResolvedProblem, 0, count)
+	for i := 1; i <= count; i++ {
+		problems = append(problems, h.entries[(h.next-i+len(h.entries))%len(h.entries)])
+	}
+
+	return problems
+}
+
+// History returns the last resolved problems, the most recently resolved problem first
+func (r *Runner) History() []*api.ResolvedProblem {
+	return r.history.list()
+}
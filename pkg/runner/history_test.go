@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestProblemHistory(t *testing.T) {
+	history := newProblemHistory(3)
+	if problems := history.list(); len(problems) != 0 {
+		t.Fatalf("Expected an empty history, got %d problems", len(problems))
+	}
+
+	resolved := time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC)
+	for i := 1; i <= 4; i++ {
+		history.add(&problemDesc{
+			problemType: problemTypePodStatus,
+			kind:        resourceKindPod,
+			name:        fmt.Sprintf("pod-%d", i),
+			namespace:   "default",
+			id:          fmt.Sprintf("default/pod-%d/status", i),
+			occured:     resolved,
+		}, resolved.Add(time.Duration(i)*time.Minute))
+	}
+
+	// The oldest problem was overwritten and the most recently resolved problem comes first
+	problems := history.list()
+	expected := []string{"default/pod-4/status", "default/pod-3/status", "default/pod-2/status"}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %d", len(expected), len(problems))
+	}
+	for i, id := range expected {
+		if problems[i].ID != id {
+			t.Fatalf("Expected problem %s at position %d, got %s", id, i, problems[i].ID)
+		} else if problems[i].Resolved.IsZero() {
+			t.Fatalf("Expected the resolution time of %s to be set", id)
+		}
+	}
+}
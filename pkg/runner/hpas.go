@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// hpasResource are the horizontal pod autoscalers of autoscaling/v2. The vendored clientset only knows
+// autoscaling/v2beta2, which is not served anymore since kubernetes 1.26, so they are listed with the dynamic client
+// into the autoscaling/v2beta2 types
+var hpasResource = schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+
+func (r *Runner) doWatchHPAs(namespace string) error {
+	hpaList := &autoscalingv2beta2.HorizontalPodAutoscalerList{}
+	err := r.listResources(hpasResource, namespace, hpaList)
+	if err != nil {
+		return fmt.Errorf("Error listing %s: %v", hpasResource.String(), err)
+	}
+
+	for _, hpa := range hpaList.Items {
+		if isIgnored(&hpa) {
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		if hpa.Status.CurrentReplicas >= hpa.Spec.MaxReplicas {
+			msg := fmt.Sprintf("HorizontalPodAutoscaler '%s/%s' is at its maximum of %d replica(s) (current: %d) for more than %v, the workload cannot scale further", hpa.Namespace, hpa.Name, hpa.Spec.MaxReplicas, hpa.Status.CurrentReplicas, r.hpaAtMaxTimeout)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeHPAAtMax,
+
+				message: msg,
+				id:      hpa.Name + "/" + hpa.Namespace + string(problemTypeHPAAtMax),
+
+				kind:        resourceKindHPA,
+				name:        hpa.Name,
+				namespace:   hpa.Namespace,
+				occured:     time.Now(),
+				reportAfter: r.hpaAtMaxTimeout,
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindHPA, hpa.Name, hpa.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// newTestHPA returns an autoscaling/v2 horizontal pod autoscaler of the default namespace
+func newTestHPA(name string, maxReplicas, currentReplicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"maxReplicas": maxReplicas,
+			"scaleTargetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"name":       name,
+			},
+		},
+		"status": map[string]interface{}{
+			"currentReplicas": currentReplicas,
+			"desiredReplicas": currentReplicas,
+		},
+	}}
+}
+
+func TestDoWatchHPAs(t *testing.T) {
+	tests := []struct {
+		name            string
+		currentReplicas int64
+		expectProblem   bool
+	}{
+		{
+			name:            "at max",
+			currentReplicas: 5,
+			expectProblem:   true,
+		},
+		{
+			name:            "below max",
+			currentReplicas: 3,
+		},
+	}
+
+	for _, test := range tests {
+		r := newTestRunner(slack.NewMockClient())
+		r.namespaceSelector = fields.Everything()
+		r.client = &fakeClient{dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newTestHPA("web", 5, test.currentReplicas))}
+
+		err := r.doWatchHPAs("default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := r.problems["web/default"+string(problemTypeHPAAtMax)]; ok != test.expectProblem {
+			t.Fatalf("%s: expected problem %v, got %v", test.name, test.expectProblem, ok)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// checkContainerImages reports every container and init container of the pod whose image uses the latest tag and
+// resolves the problem once the image is pinned
+func (r *Runner) checkContainerImages(pod *v1.Pod) error {
+	for _, container := range append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		msg := ""
+		if usesLatestTag(container.Image) {
+			msg = fmt.Sprintf("Container '%s' of pod '%s/%s' uses the latest tag of image '%s', the version might change unexpectedly when the pod is restarted", container.Name, pod.Namespace, pod.Name, container.Image)
+		}
+		err := r.reportContainerProblem(pod, container.Name, problemTypePodLatestTag, msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// usesLatestTag returns true if the image has the tag latest or no tag at all. Images pinned by digest are never latest
+func usesLatestTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+
+	// The registry can contain a port, so the tag is searched in the last path segment only
+	name := image[strings.LastIndex(image, "/")+1:]
+	index := strings.LastIndex(name, ":")
+	return index == -1 || name[index+1:] == "latest"
+}
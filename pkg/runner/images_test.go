@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUsesLatestTag(t *testing.T) {
+	tests := map[string]bool{
+		"nginx":                      true,
+		"nginx:latest":               true,
+		"registry:5000/nginx":        true,
+		"registry:5000/nginx:1.25":   false,
+		"nginx:1.25":                 false,
+		"nginx@sha256:0123456789abc": false,
+	}
+
+	for image, expected := range tests {
+		if usesLatestTag(image) != expected {
+			t.Fatalf("Expected usesLatestTag(%s) to be %v", image, expected)
+		}
+	}
+}
+
+func TestCheckContainerImagesResolve(t *testing.T) {
+	notifier := &recordingNotifier{}
+	r := newTestRunner(notifier)
+	r.thresholds = &ThresholdConfig{PodLatestTag: 1}
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "container", Image: "nginx:latest"}}},
+	}
+	err := r.checkContainerImages(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.flushReports()
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected 1 alert for the container with the latest tag, got %d", len(notifier.alerts))
+	}
+
+	pod.Spec.Containers[0].Image = "nginx:1.25"
+	err = r.checkContainerImages(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.flushReports()
+	if len(r.problems) != 0 {
+		t.Fatal("Expected the problem to be resolved once the image is pinned")
+	} else if len(notifier.resolves) != 1 || notifier.resolves[0] != notifier.alerts[0] {
+		t.Fatalf("Expected the resolve message of %s, got %v", notifier.alerts[0], notifier.resolves)
+	}
+}
@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/robfig/cron"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// cronJobsResource are the cron jobs of batch/v1. The vendored clientset only knows batch/v1beta1, which is not served
+// anymore since kubernetes 1.25, so they are listed with the dynamic client into the batch/v1beta1 types
+var cronJobsResource = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}
+
+// cronJobScheduleGrace is the time the cron job controller has to schedule an activation before it is considered missed
+const cronJobScheduleGrace = time.Minute * 2
+
+func (r *Runner) doWatchJobs(namespace string) error {
+	cronJobList, err := r.listCronJobs(namespace)
+	if err != nil {
+		return err
+	}
+
+	// The cron jobs by namespace/name to find the owner of a job
+	cronJobs := make(map[string]*batchv1beta1.CronJob, len(cronJobList.Items))
+	for i := range cronJobList.Items {
+		cronJobs[cronJobList.Items[i].Namespace+"/"+cronJobList.Items[i].Name] = &cronJobList.Items[i]
+	}
+
+	var jobList *batchv1.JobList
+	err = r.withRetry(func() (err error) {
+		jobList, err = r.client.Client().BatchV1().Jobs(namespace).List(r.listOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobList.Items {
+		if isIgnored(&job) {
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		failed := job.Status.Failed > 0 && job.Status.CompletionTime == nil
+		if failed {
+			msg := fmt.Sprintf("Job '%s/%s' has failed %d time(s), last failure at %s", job.Namespace, job.Name, job.Status.Failed, getJobLastFailure(&job))
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeJobFailed,
+
+				message: msg,
+				id:      job.Name + "/" + job.Namespace + string(problemTypeJobFailed),
+
+				kind:      resourceKindJob,
+				name:      job.Name,
+				namespace: job.Namespace,
+				occured:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		stuck := false
+		if cronJob := getOwningCronJob(&job, cronJobs); cronJob != nil && job.Status.StartTime != nil && job.Status.CompletionTime == nil && job.Status.Active > 0 {
+			maxDuration := r.cronJobMaxDuration
+			if cronJob.Spec.JobTemplate.Spec.ActiveDeadlineSeconds != nil {
+				maxDuration = time.Duration(*cronJob.Spec.JobTemplate.Spec.ActiveDeadlineSeconds) * time.Second
+			}
+
+			running := time.Since(job.Status.StartTime.Time)
+			if running > maxDuration {
+				stuck = true
+				msg := fmt.Sprintf("Job '%s/%s' of CronJob '%s' is running for %v (expected at most %v)", job.Namespace, job.Name, cronJob.Name, running.Round(time.Second), maxDuration)
+				err = r.reportProblem(&problemDesc{
+					problemType: problemTypeCronJobStuck,
+
+					message: msg,
+					id:      job.Name + "/" + job.Namespace + string(problemTypeCronJobStuck),
+
+					kind:      resourceKindJob,
+					name:      job.Name,
+					namespace: job.Namespace,
+					occured:   time.Now(),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if !failed && !stuck {
+			err = r.resolveProblemsOf(resourceKindJob, job.Name, job.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, cronJob := range cronJobList.Items {
+		if isIgnored(&cronJob) || (cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend) {
+			continue
+		}
+
+		schedule, err := cron.ParseStandard(cronJob.Spec.Schedule)
+		if err != nil {
+			// We cannot tell if a schedule was missed
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		if missed := getMissedSchedule(&cronJob, schedule, time.Now()); !missed.IsZero() {
+			msg := fmt.Sprintf("CronJob '%s/%s' with schedule '%s' was not scheduled at %s", cronJob.Namespace, cronJob.Name, cronJob.Spec.Schedule, missed.Format(time.RFC3339))
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeCronJobMissed,
+
+				message: msg,
+				id:      cronJob.Name + "/" + cronJob.Namespace + string(problemTypeCronJobMissed),
+
+				kind:      resourceKindCronJob,
+				name:      cronJob.Name,
+				namespace: cronJob.Namespace,
+				occured:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindCronJob, cronJob.Name, cronJob.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// listCronJobs returns the cron jobs of the namespace. If the cluster does not serve batch/v1 cron jobs (before
+// kubernetes 1.21), the cron job checks are turned off and an empty list is returned, so the jobs are still checked
+func (r *Runner) listCronJobs(namespace string) (*batchv1beta1.CronJobList, error) {
+	cronJobList := &batchv1beta1.CronJobList{}
+	if atomic.LoadInt32(&r.cronJobsUnsupported) == 1 {
+		return cronJobList, nil
+	}
+
+	err := r.listResources(cronJobsResource, namespace, cronJobList)
+	if apierrors.IsNotFound(err) {
+		if atomic.CompareAndSwapInt32(&r.cronJobsUnsupported, 0, 1) {
+			log.Warn("CronJobs of batch/v1 are not served by the cluster, the cron job checks are turned off", "resource", cronJobsResource.String(), "error", err)
+		}
+
+		return &batchv1beta1.CronJobList{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Error listing %s: %v", cronJobsResource.String(), err)
+	}
+
+	return cronJobList, nil
+}
+
+// getOwningCronJob returns the cron job that created the job or nil if the job was not created by a cron job
+func getOwningCronJob(job *batchv1.Job, cronJobs map[string]*batchv1beta1.CronJob) *batchv1beta1.CronJob {
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind == string(resourceKindCronJob) {
+			return cronJobs[job.Namespace+"/"+owner.Name]
+		}
+	}
+
+	return nil
+}
+
+func getJobLastFailure(job *batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == v1.ConditionTrue {
+			return condition.LastTransitionTime.Format(time.RFC3339)
+		}
+	}
+
+	return "unknown"
+}
+
+// getMissedSchedule returns the activation of the cron job after its last schedule time if it was not scheduled
+// within cronJobScheduleGrace after it, otherwise the zero time. Cron jobs that forbid concurrent runs skip their
+// activations while a job is still active, which is not reported as missed
+func getMissedSchedule(cronJob *batchv1beta1.CronJob, schedule cron.Schedule, now time.Time) time.Time {
+	if cronJob.Spec.ConcurrencyPolicy == batchv1beta1.ForbidConcurrent && len(cronJob.Status.Active) > 0 {
+		return time.Time{}
+	}
+
+	lastSchedule := cronJob.CreationTimestamp.Time
+	if cronJob.Status.LastScheduleTime != nil {
+		lastSchedule = cronJob.Status.LastScheduleTime.Time
+	}
+
+	next := schedule.Next(lastSchedule)
+	if next.IsZero() || now.Before(next.Add(cronJobScheduleGrace)) {
+		return time.Time{}
+	}
+
+	return next
+}
@@ -0,0 +1,172 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	"github.com/robfig/cron"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFailedJob returns a job of the default namespace that has failed
+func newFailedJob(name string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     batchv1.JobStatus{Failed: 1},
+	}
+}
+
+func TestDoWatchJobsCronJobMissed(t *testing.T) {
+	lastSchedule := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata": map[string]interface{}{
+			"name":      "backup",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"schedule": "*/5 * * * *",
+		},
+		"status": map[string]interface{}{
+			"lastScheduleTime": lastSchedule,
+		},
+	}}
+
+	r := newTestRunner(slack.NewMockClient())
+	r.namespaceSelector = fields.Everything()
+	r.client = &fakeClient{
+		clientset: fake.NewSimpleClientset(),
+		dynamic:   dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), cronJob),
+	}
+
+	err := r.doWatchJobs("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := r.problems["backup/default"+string(problemTypeCronJobMissed)]; !ok {
+		t.Fatalf("Expected the missed schedule of the batch/v1 cron job to be reported")
+	}
+}
+
+func TestDoWatchJobsCronJobsNotServed(t *testing.T) {
+	client := newFakeClient(newFailedJob("migrate"))
+	client.dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("list", "cronjobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(cronJobsResource.GroupResource(), "")
+	})
+
+	r := newTestRunner(slack.NewMockClient())
+	r.namespaceSelector = fields.Everything()
+	r.client = client
+
+	err := r.doWatchJobs("default")
+	if err != nil {
+		t.Fatalf("Expected the cron job check to be turned off, got %v", err)
+	} else if r.cronJobsUnsupported != 1 {
+		t.Fatalf("Expected the cron jobs to be marked as unsupported")
+	} else if _, ok := r.problems["migrate/default"+string(problemTypeJobFailed)]; !ok {
+		t.Fatalf("Expected the failed job to be reported without cron jobs")
+	}
+}
+
+func TestGetMissedSchedule(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(value string) *metav1.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t := metav1.NewTime(parsed)
+		return &t
+	}
+
+	tests := []struct {
+		name              string
+		schedule          string
+		lastSchedule      *metav1.Time
+		concurrencyPolicy batchv1beta1.ConcurrencyPolicy
+		active            int
+		expectedMissed    string
+	}{
+		{
+			name:         "every 5 minutes on time",
+			schedule:     "*/5 * * * *",
+			lastSchedule: at("2020-03-10T11:55:00Z"),
+		},
+		{
+			name:           "every 5 minutes missed",
+			schedule:       "*/5 * * * *",
+			lastSchedule:   at("2020-03-10T11:50:00Z"),
+			expectedMissed: "2020-03-10T11:55:00Z",
+		},
+		{
+			name:         "weekdays at 9 on time",
+			schedule:     "0 9 * * 1-5",
+			lastSchedule: at("2020-03-10T09:00:00Z"),
+		},
+		{
+			name:           "weekdays at 9 missed on monday",
+			schedule:       "0 9 * * 1-5",
+			lastSchedule:   at("2020-03-06T09:00:00Z"),
+			expectedMissed: "2020-03-09T09:00:00Z",
+		},
+		{
+			name:         "first day of the month",
+			schedule:     "30 2 1 * *",
+			lastSchedule: at("2020-03-01T02:30:00Z"),
+		},
+		{
+			name:         "hourly within the grace period",
+			schedule:     "@hourly",
+			lastSchedule: at("2020-03-10T11:00:00Z"),
+		},
+		{
+			name:           "hourly missed",
+			schedule:       "@hourly",
+			lastSchedule:   at("2020-03-10T10:00:00Z"),
+			expectedMissed: "2020-03-10T11:00:00Z",
+		},
+		{
+			name:              "forbidden concurrent run still active",
+			schedule:          "*/5 * * * *",
+			lastSchedule:      at("2020-03-10T11:00:00Z"),
+			concurrencyPolicy: batchv1beta1.ForbidConcurrent,
+			active:            1,
+		},
+	}
+
+	for _, test := range tests {
+		schedule, err := cron.ParseStandard(test.schedule)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		cronJob := &batchv1beta1.CronJob{
+			Spec: batchv1beta1.CronJobSpec{Schedule: test.schedule, ConcurrencyPolicy: test.concurrencyPolicy},
+			Status: batchv1beta1.CronJobStatus{
+				LastScheduleTime: test.lastSchedule,
+				Active:           make([]v1.ObjectReference, test.active),
+			},
+		}
+
+		missed := getMissedSchedule(cronJob, schedule, now)
+		if test.expectedMissed == "" && !missed.IsZero() {
+			t.Fatalf("%s: expected no missed schedule, got %v", test.name, missed)
+		} else if test.expectedMissed != "" && missed.Format(time.RFC3339) != test.expectedMissed {
+			t.Fatalf("%s: expected missed schedule %s, got %v", test.name, test.expectedMissed, missed)
+		}
+	}
+}
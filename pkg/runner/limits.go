@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// checkContainerLimits reports every container of the pod that has no cpu or memory limit and resolves the
+// problem once the limits are set
+func (r *Runner) checkContainerLimits(pod *v1.Pod) error {
+	for _, container := range pod.Spec.Containers {
+		missing := []string{}
+		for _, resourceName := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			if limit, ok := container.Resources.Limits[resourceName]; !ok || limit.IsZero() {
+				missing = append(missing, string(resourceName))
+			}
+		}
+
+		msg := ""
+		if len(missing) > 0 {
+			msg = fmt.Sprintf("Container '%s' of pod '%s/%s' has no %s limit", container.Name, pod.Namespace, pod.Name, strings.Join(missing, " and "))
+		}
+		err := r.reportContainerProblem(pod, container.Name, problemTypePodNoLimits, msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultPodLogLines = 20
+
+// podLogSnippetLines is the number of log lines that are included in the alert
+const podLogSnippetLines = 3
+
+// maxPodLogSnippetLength is the maximum length of the log snippet to stay within the message limits of slack
+const maxPodLogSnippetLength = 300
+
+// getCrashLoopContainer returns the name of the first container of the pod that is in CrashLoopBackOff
+func getCrashLoopContainer(pod *v1.Pod) string {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == "CrashLoopBackOff" {
+			return containerStatus.Name
+		}
+	}
+
+	return ""
+}
+
+// getPodLogSnippet returns the last lines of the log of the previous (crashed) instance of the container, which is
+// truncated to maxPodLogSnippetLength. Errors are only logged, because the logs are optional in the alert
+func (r *Runner) getPodLogSnippet(namespace, name, container string) string {
+	tailLines := int64(r.podLogLines)
+	out, err := r.client.Client().CoreV1().Pods(namespace).GetLogs(name, &v1.PodLogOptions{
+		Container: container,
+		Previous:  true,
+		TailLines: &tailLines,
+	}).Do().Raw()
+	if err != nil {
+		log.Warn("Error retrieving the logs of the crashed container", "namespace", namespace, "pod", name, "container", container, "error", err)
+		return ""
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, "\r"))
+		}
+	}
+	if len(lines) > podLogSnippetLines {
+		lines = lines[len(lines)-podLogSnippetLines:]
+	}
+
+	// Keep the end of the log, which most likely contains the reason of the crash
+	snippet := strings.Join(lines, "\n")
+	if len(snippet) > maxPodLogSnippetLength {
+		snippet = "..." + snippet[len(snippet)-maxPodLogSnippetLength:]
+	}
+
+	return snippet
+}
+
+// addPodLogs adds the log snippet of the crashed container of the problem to the alert if pod logs are included.
+// Needs to be called without the problems mutex locked, because the logs are retrieved from the api
+func (r *Runner) addPodLogs(alert *notify.Problem, problem *problemDesc) {
+	if !r.includePodLogs || problem.container == "" {
+		return
+	}
+
+	logs := r.getPodLogSnippet(problem.namespace, problem.name, problem.container)
+	if logs != "" {
+		alert.Message += fmt.Sprintf("\nLast log lines of container '%s':\n```\n%s\n```", problem.container, logs)
+	}
+}
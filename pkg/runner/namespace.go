@@ -2,8 +2,11 @@ package runner
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/util/node"
@@ -26,20 +29,81 @@ var CriticalStatus = map[string]bool{
 	"CreateContainerConfigError": true,
 	"InvalidImageName":           true,
 	"Evicted":                    true,
+
+	"Init:Error":            true,
+	"Init:OOMKilled":        true,
+	"Init:CrashLoopBackOff": true,
 }
 
-func (r *Runner) doWatchNamespace(namespace string) error {
-	podList, err := r.client.Client().CoreV1().Pods(namespace).List(metav1.ListOptions{})
-	if err != nil {
-		return err
+// isOptedOut returns if the pod should be skipped. If an opt in annotation is configured, only pods with that
+// annotation are checked and the ignore annotation has no effect
+func (r *Runner) isOptedOut(pod *v1.Pod) bool {
+	if r.optInAnnotation != "" {
+		return pod.Annotations[r.optInAnnotation] != r.optInValue
 	}
 
-	for _, pod := range podList.Items {
+	return isIgnored(pod)
+}
+
+func (r *Runner) doWatchNamespace(namespace string) error {
+	var err error
+	seen := make(map[string]bool)
+	podsByPhase := make(map[[2]string]int)
+	podsByStatus := make(map[[2]string]int)
+	podMetrics := r.getPodMetrics(namespace)
+	for _, obj := range r.podWatches[namespace].Objects() {
 		var problem *problemDesc
 
-		status := GetPodStatus(&pod)
-		if CriticalStatus[status] {
+		pod := obj.(*v1.Pod)
+		seen[pod.Namespace+"/"+pod.Name] = true
+		if r.isOptedOut(pod) {
+			continue
+		}
+
+		status := GetPodStatus(pod)
+		podsByPhase[[2]string{pod.Namespace, getPodPhase(pod)}]++
+		podsByStatus[[2]string{pod.Namespace, status}]++
+		oomKills := r.recordOOMKills(pod)
+		if pod.DeletionTimestamp != nil {
+			// The deletion timestamp already includes the grace period
+			gracePeriod := getTerminationGracePeriod(pod)
+			stuck := time.Since(pod.DeletionTimestamp.Time.Add(-gracePeriod))
+			if stuck > gracePeriod+r.podTerminationBuffer {
+				msg := fmt.Sprintf("Pod '%s/%s' is stuck in Terminating for %v (termination grace period: %v)", pod.Namespace, pod.Name, stuck.Round(time.Second), gracePeriod)
+				problem = &problemDesc{
+					problemType: problemTypePodStuckTerminating,
+
+					message: msg,
+					id:      pod.Name + "/" + pod.Namespace + string(problemTypePodStuckTerminating),
+
+					kind:      resourceKindPod,
+					name:      pod.Name,
+					namespace: pod.Namespace,
+					occured:   time.Now(),
+				}
+			}
+		} else if status == "Running" && r.isNodeNotReady(pod.Spec.NodeName) {
+			msg := fmt.Sprintf("Pod '%s/%s' is running on node '%s', which is not ready, so the pod is probably not working", pod.Namespace, pod.Name, pod.Spec.NodeName)
+			problem = &problemDesc{
+				problemType: problemTypePodOnNotReadyNode,
+
+				message: msg,
+				id:      pod.Name + "/" + pod.Namespace + string(problemTypePodOnNotReadyNode),
+
+				kind:      resourceKindPod,
+				name:      pod.Name,
+				namespace: pod.Namespace,
+				occured:   time.Now(),
+			}
+		} else if oomKillProblem := r.getOOMKillProblem(pod, status, oomKills); oomKillProblem != nil {
+			problem = oomKillProblem
+		} else if CriticalStatus[status] {
 			msg := fmt.Sprintf("Pod '%s/%s' has critical status '%s'", pod.Namespace, pod.Name, status)
+			if status == "ErrImagePull" || status == "ImagePullBackOff" {
+				msg += getImagePullDetails(pod)
+			} else if strings.HasPrefix(status, "Init:") {
+				msg += getInitContainerDetails(pod)
+			}
 			problem = &problemDesc{
 				problemType: problemTypePodStatus,
 
@@ -51,6 +115,9 @@ func (r *Runner) doWatchNamespace(namespace string) error {
 				namespace: pod.Namespace,
 				occured:   time.Now(),
 			}
+			if status == "CrashLoopBackOff" {
+				problem.container = getCrashLoopContainer(pod)
+			}
 		} else if OkayStatus[status] {
 			for _, containerStatus := range pod.Status.ContainerStatuses {
 				if containerStatus.LastTerminationState.Terminated != nil && time.Since(containerStatus.LastTerminationState.Terminated.FinishedAt.Time) <= time.Hour && containerStatus.LastTerminationState.Terminated.ExitCode != 0 {
@@ -70,6 +137,11 @@ func (r *Runner) doWatchNamespace(namespace string) error {
 					break
 				}
 			}
+
+			// Running pods whose containers are not passing their readiness checks
+			if problem == nil {
+				problem = r.getPodUnreadyProblem(pod)
+			}
 		} else {
 			msg := fmt.Sprintf("Pod '%s/%s' is not starting with status '%s'", pod.Namespace, pod.Name, status)
 			problem = &problemDesc{
@@ -87,25 +159,232 @@ func (r *Runner) doWatchNamespace(namespace string) error {
 
 		// Handle problem reporting or resolving
 		if problem != nil {
+			r.setPodPriority(problem, pod)
 			err = r.reportProblem(problem)
 			if err != nil {
 				return err
 			}
 		} else {
-			for _, problem := range r.problems {
-				if problem.kind == resourceKindPod && problem.name == pod.Name && problem.namespace == pod.Namespace {
-					err = r.resolveProblem(problem)
-					if err != nil {
-						return err
-					}
-				}
+			err = r.resolveProblemsOf(resourceKindPod, pod.Name, pod.Namespace)
+			if err != nil {
+				return err
 			}
 		}
+
+		// Check if the containers have resource limits
+		if r.checkResourceLimits && status == "Running" {
+			err = r.checkContainerLimits(pod)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Check if the limits of restarted containers are too close to their requests
+		if r.checkResourceRatios && status == "Running" {
+			err = r.checkContainerRatios(pod)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Check if the containers are cpu throttled
+		if podMetrics[pod.Namespace+"/"+pod.Name] != nil && status == "Running" {
+			err = r.checkCPUThrottling(pod, podMetrics[pod.Namespace+"/"+pod.Name])
+			if err != nil {
+				return err
+			}
+		}
+
+		// Check if the containers use the latest image tag
+		if r.checkLatestTag && status == "Running" {
+			err = r.checkContainerImages(pod)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Check if the containers have health probes
+		if r.checkMissingProbes && status == "Running" {
+			err = r.checkContainerProbes(pod)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	setPodGauges(namespace, podsByPhase, podsByStatus)
+	return r.resolveRemovedPods(namespace, seen)
+}
+
+// getPodPhase returns the phase of the pod or Unknown if the pod has no phase yet
+func getPodPhase(pod *v1.Pod) string {
+	if pod.Status.Phase == "" {
+		return string(v1.PodUnknown)
+	}
+
+	return string(pod.Status.Phase)
+}
+
+// setPodGauges replaces the pod phase and status gauges of the checked namespace with the counts of the latest check,
+// which are keyed by the namespace of the pods and the phase or status. If all namespaces are watched with a single
+// watcher, the gauges of all namespaces are replaced
+func setPodGauges(namespace string, podsByPhase, podsByStatus map[[2]string]int) {
+	setPodGauge(prometheus.PodsByPhase, namespace, podsByPhase)
+	setPodGauge(prometheus.PodsByStatus, namespace, podsByStatus)
+}
+
+func setPodGauge(gauge *prometheus.GaugeVec, namespace string, counts map[[2]string]int) {
+	values := make([]prometheus.GaugeValue, 0, len(counts))
+	for labelValues, count := range counts {
+		values = append(values, prometheus.GaugeValue{LabelValues: []string{labelValues[0], labelValues[1]}, Value: float64(count)})
+	}
+
+	if namespace == metav1.NamespaceAll {
+		gauge.ReplaceAll(values)
+	} else {
+		gauge.Replace("namespace", namespace, values)
+	}
+}
+
+// resolveRemovedPods resolves the stuck terminating problems of pods that were finally removed
+func (r *Runner) resolveRemovedPods(namespace string, seen map[string]bool) error {
+	r.problemsMutex.Lock()
+	for _, problem := range r.problems {
+		if problem.problemType != problemTypePodStuckTerminating || (namespace != "" && problem.namespace != namespace) || seen[problem.namespace+"/"+problem.name] {
+			continue
+		}
+
+		r.resolveProblem(problem)
 	}
+	r.problemsMutex.Unlock()
 
 	return nil
 }
 
+// getTerminationGracePeriod returns the grace period of the pod deletion
+func getTerminationGracePeriod(pod *v1.Pod) time.Duration {
+	if pod.DeletionGracePeriodSeconds != nil {
+		return time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second
+	} else if pod.Spec.TerminationGracePeriodSeconds != nil {
+		return time.Duration(*pod.Spec.TerminationGracePeriodSeconds) * time.Second
+	}
+
+	return v1.DefaultTerminationGracePeriodSeconds * time.Second
+}
+
+// imagePullErrors maps substrings of image pull error messages to a short error description
+var imagePullErrors = []struct {
+	substrings []string
+	detail     string
+}{
+	{[]string{"unauthorized", "authentication required", "pull access denied", "denied"}, "access denied, check the image pull secrets or if the repository exists"},
+	{[]string{"not found", "manifest unknown", "does not exist"}, "image not found"},
+	{[]string{"rate limit", "toomanyrequests"}, "pull rate limit reached"},
+	{[]string{"no such host", "i/o timeout", "connection refused"}, "registry not reachable"},
+}
+
+// imageReferenceRegEx matches the first quoted image reference in an image pull error message
+var imageReferenceRegEx = regexp.MustCompile(`"([^"]+)"`)
+
+// getImagePullDetails returns the registry and error of the first container that fails to pull its image
+func getImagePullDetails(pod *v1.Pod) string {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting == nil || (containerStatus.State.Waiting.Reason != "ErrImagePull" && containerStatus.State.Waiting.Reason != "ImagePullBackOff") {
+			continue
+		}
+
+		registry, errorDetail := parseImagePullError(containerStatus.State.Waiting.Message)
+		if registry == "" {
+			registry = getImageRegistry(containerStatus.Image)
+		}
+		if errorDetail == "" {
+			return fmt.Sprintf(" (registry: %s)", registry) + getImageTagMutationHint(containerStatus)
+		}
+
+		return fmt.Sprintf(": %s (registry: %s)", errorDetail, registry) + getImageTagMutationHint(containerStatus)
+	}
+
+	return ""
+}
+
+// getInitContainerDetails returns the name and failure reason of the first failed init container of the pod
+func getInitContainerDetails(pod *v1.Pod) string {
+	for _, containerStatus := range pod.Status.InitContainerStatuses {
+		if containerStatus.State.Terminated != nil && containerStatus.State.Terminated.ExitCode != 0 {
+			return fmt.Sprintf(": init container '%s' terminated with reason '%s' and exit code '%d'", containerStatus.Name, containerStatus.State.Terminated.Reason, containerStatus.State.Terminated.ExitCode)
+		} else if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason != "" && containerStatus.State.Waiting.Reason != "PodInitializing" {
+			msg := fmt.Sprintf(": init container '%s' is waiting with reason '%s'", containerStatus.Name, containerStatus.State.Waiting.Reason)
+			if containerStatus.LastTerminationState.Terminated != nil {
+				msg += fmt.Sprintf(" (last termination reason '%s' with exit code '%d')", containerStatus.LastTerminationState.Terminated.Reason, containerStatus.LastTerminationState.Terminated.ExitCode)
+			}
+
+			return msg
+		}
+	}
+
+	return ""
+}
+
+// getImageTagMutationHint returns a hint if the container was already pulled by tag (its image id contains a digest)
+// and now fails to pull, which happens if the tag was overwritten in the registry (e.g. a floating main tag)
+func getImageTagMutationHint(containerStatus v1.ContainerStatus) string {
+	if strings.Contains(containerStatus.Image, "@sha256:") {
+		return ""
+	}
+
+	index := strings.Index(containerStatus.ImageID, "sha256:")
+	if index == -1 {
+		return ""
+	}
+
+	return fmt.Sprintf(", possible image tag mutation: image '%s' was previously pulled with digest '%s'", containerStatus.Image, containerStatus.ImageID[index:])
+}
+
+// parseImagePullError extracts the registry hostname and a short error description from an image pull error message.
+// Both are empty if they cannot be determined
+func parseImagePullError(message string) (registry, errorDetail string) {
+	matches := imageReferenceRegEx.FindStringSubmatch(message)
+	if matches != nil {
+		registry = getImageRegistry(matches[1])
+	}
+
+	lower := strings.ToLower(message)
+	for _, imagePullError := range imagePullErrors {
+		for _, substring := range imagePullError.substrings {
+			if strings.Contains(lower, substring) {
+				return registry, imagePullError.detail
+			}
+		}
+	}
+
+	return registry, ""
+}
+
+// getImageRegistry returns the registry hostname of the image reference, which is docker.io if the
+// reference does not start with a hostname
+func getImageRegistry(image string) string {
+	splitted := strings.SplitN(image, "/", 2)
+	if len(splitted) == 2 && (strings.ContainsAny(splitted[0], ".:") || splitted[0] == "localhost") {
+		return splitted[0]
+	}
+
+	return "docker.io"
+}
+
+func getContainerMemoryLimit(pod *v1.Pod, containerName string) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name != containerName {
+			continue
+		}
+
+		if limit, ok := container.Resources.Limits[v1.ResourceMemory]; ok {
+			return limit.String()
+		}
+	}
+
+	return "none"
+}
+
 // GetPodStatus returns the pod status as a string
 // Taken from https://github.com/kubernetes/kubernetes/pkg/printers/internalversion/printers.go
 func GetPodStatus(pod *v1.Pod) string {
@@ -0,0 +1,196 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newCrashLoopPod(name string, annotations map[string]string) *v1.Pod {
+	return newCrashLoopPodIn("default", name, annotations)
+}
+
+func newCrashLoopPodIn(namespace, name string, annotations map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Annotations: annotations},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  "app",
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				},
+			},
+		},
+	}
+}
+
+func TestIgnoredPod(t *testing.T) {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	ignored := newCrashLoopPod("ignored", map[string]string{IgnoreAnnotation: "true"})
+
+	notifier := &recordingNotifier{}
+	r := newFakeRunner(t, newFakeClient(namespace, ignored), notifier, false, []string{"default"})
+	startWatches(t, r)
+
+	for i := 0; i < 3; i++ {
+		err := r.doWatchNamespaces(r.watchNamespaces)
+		r.flushReports()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("Expected no alerts for the ignored pod, got %v", notifier.alerts)
+	}
+
+	// The same pod without the annotation is reported
+	notifier = &recordingNotifier{}
+	r = newFakeRunner(t, newFakeClient(namespace, newCrashLoopPod("broken", nil)), notifier, false, []string{"default"})
+	startWatches(t, r)
+
+	err := r.doWatchNamespaces(r.watchNamespaces)
+	r.flushReports()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected 1 alert for the pod without the annotation, got %v", notifier.alerts)
+	}
+}
+
+// TestWatchNamespacesConcurrently checks many namespaces with a small worker pool, run it with -race to detect
+// unsynchronized access to the problems
+func TestWatchNamespacesConcurrently(t *testing.T) {
+	t.Setenv("NAMESPACE_WORKERS", "4")
+
+	objects := []runtime.Object{}
+	namespaces := []string{}
+	for i := 0; i < 20; i++ {
+		namespace := fmt.Sprintf("namespace-%d", i)
+		namespaces = append(namespaces, namespace)
+		objects = append(objects, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}, newCrashLoopPodIn(namespace, "broken", nil))
+	}
+
+	notifier := &recordingNotifier{}
+	r := newFakeRunner(t, newFakeClient(objects...), notifier, false, namespaces)
+	if r.namespaceWorkers != 4 {
+		t.Fatalf("Expected 4 namespace workers, got %d", r.namespaceWorkers)
+	}
+	startWatches(t, r)
+
+	for i := 0; i < 3; i++ {
+		err := r.doWatchNamespaces(r.watchNamespaces)
+		r.flushReports()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(r.problems) != len(namespaces) {
+		t.Fatalf("Expected %d problems, got %d", len(namespaces), len(r.problems))
+	} else if len(notifier.alerts) != len(namespaces) {
+		t.Fatalf("Expected %d alerts, got %d", len(namespaces), len(notifier.alerts))
+	}
+}
+
+func TestNamespacesDue(t *testing.T) {
+	t.Setenv("NAMESPACE_INTERVALS", "prod=5,unwatched=10")
+
+	objects := []runtime.Object{}
+	for _, namespace := range []string{"prod", "dev"} {
+		objects = append(objects, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	}
+
+	r := newFakeRunner(t, newFakeClient(objects...), &recordingNotifier{}, false, []string{"prod", "dev"})
+	if _, ok := r.namespaceIntervals["unwatched"]; ok {
+		t.Fatal("Expected the interval of the unwatched namespace to be ignored")
+	} else if r.loopInterval() != 5*time.Second {
+		t.Fatalf("Expected a loop interval of 5 seconds, got %v", r.loopInterval())
+	}
+
+	start := time.Now()
+	tests := []struct {
+		offset   time.Duration
+		expected []string
+	}{
+		{
+			offset:   0,
+			expected: []string{"prod", "dev"},
+		},
+		{
+			offset:   5 * time.Second,
+			expected: []string{"prod"},
+		},
+		{
+			offset:   8 * time.Second,
+			expected: []string{},
+		},
+		{
+			// dev falls back to the default interval
+			offset:   defaultInterval,
+			expected: []string{"prod", "dev"},
+		},
+	}
+
+	for _, test := range tests {
+		due := r.namespacesDue(start.Add(test.offset))
+		if strings.Join(due, ",") != strings.Join(test.expected, ",") {
+			t.Fatalf("Expected namespaces %v to be due after %v, got %v", test.expected, test.offset, due)
+		}
+	}
+}
+
+func TestExcludedNamespaces(t *testing.T) {
+	client := newFakeClient(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+
+	r, err := NewRunner(client, &recordingNotifier{}, false, []string{metav1.NamespaceAll}, []string{"kube-system", "monitoring"}, labels.Everything(), labels.Everything(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "metadata.namespace!=kube-system,metadata.namespace!=monitoring"
+	if selector := r.listOptions().FieldSelector; selector != expected {
+		t.Fatalf("Expected field selector %s, got %s", expected, selector)
+	}
+	if selector := r.listOptions(fields.OneTermEqualSelector("type", "Warning")).FieldSelector; selector != expected+",type=Warning" {
+		t.Fatalf("Expected the additional selector to be appended, got %s", selector)
+	}
+
+	// Exclusions are only supported when all namespaces are watched
+	_, err = NewRunner(client, &recordingNotifier{}, false, []string{"default"}, []string{"kube-system"}, labels.Everything(), labels.Everything(), nil, nil)
+	if err == nil {
+		t.Fatal("Expected an error for an excluded namespace without watching all namespaces")
+	}
+}
+
+func TestFailedCheckIsSkipped(t *testing.T) {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	client := newFakeClient(namespace, newCrashLoopPod("broken", nil))
+	client.clientset.(*fake.Clientset).PrependReactor("list", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "", fmt.Errorf("not allowed"))
+	})
+
+	notifier := &recordingNotifier{}
+	r := newFakeRunner(t, client, notifier, false, []string{"default"})
+	startWatches(t, r)
+
+	err := r.doWatchNamespaces(r.watchNamespaces)
+	r.flushReports()
+	if err != nil {
+		t.Fatalf("Expected the forbidden deployments to be skipped, got %v", err)
+	} else if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected 1 alert for the broken pod, got %v", notifier.alerts)
+	}
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// doWatchNamespaceStatus reports watched namespaces that are terminating for longer than the namespace terminating timeout
+func (r *Runner) doWatchNamespaceStatus() error {
+	namespaces := []v1.Namespace{}
+	if len(r.watchNamespaces) == 1 && r.watchNamespaces[0] == metav1.NamespaceAll {
+		var namespaceList *v1.NamespaceList
+		err := r.withRetry(func() (err error) {
+			namespaceList, err = r.client.Client().CoreV1().Namespaces().List(metav1.ListOptions{})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, namespace := range namespaceList.Items {
+			if !r.excludedNamespaces[namespace.Name] {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	} else {
+		for _, name := range r.watchNamespaces {
+			var namespace *v1.Namespace
+			err := r.withRetry(func() (err error) {
+				namespace, err = r.client.Client().CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+				return err
+			})
+			if apierrors.IsNotFound(err) {
+				// The namespace is gone, so it is not stuck anymore
+				err = r.resolveProblemsOf(resourceKindNamespace, name, "")
+				if err != nil {
+					return err
+				}
+
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			namespaces = append(namespaces, *namespace)
+		}
+	}
+
+	for _, namespace := range namespaces {
+		if isIgnored(&namespace) {
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		if namespace.Status.Phase == v1.NamespaceTerminating && namespace.DeletionTimestamp != nil && time.Since(namespace.DeletionTimestamp.Time) > r.namespaceTerminatingTimeout {
+			msg := fmt.Sprintf("Namespace '%s' is stuck in Terminating for %v (age: %v), check the finalizers of the namespace and its resources", namespace.Name, time.Since(namespace.DeletionTimestamp.Time).Round(time.Second), time.Since(namespace.CreationTimestamp.Time).Round(time.Second))
+			err := r.reportProblem(&problemDesc{
+				problemType: problemTypeNamespaceStuck,
+
+				message: msg,
+				id:      namespace.Name + string(problemTypeNamespaceStuck),
+
+				kind:    resourceKindNamespace,
+				name:    namespace.Name,
+				occured: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err := r.resolveProblemsOf(resourceKindNamespace, namespace.Name, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package runner
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// getNodeGroup returns the value of the node group label of the node, which is empty if node grouping is disabled
+func (r *Runner) getNodeGroup(node *v1.Node) string {
+	if r.nodeGroupLabel == "" {
+		return ""
+	}
+
+	return node.Labels[r.nodeGroupLabel]
+}
+
+// isGroupedNodeProblem returns true if the problem is reported together with the problems of the same type of the
+// other nodes in its node group at the end of the check cycle
+func (p *problemDesc) isGroupedNodeProblem() bool {
+	return p.kind == resourceKindNode && p.nodeGroup != ""
+}
@@ -2,14 +2,31 @@ package runner
 
 import (
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metricsapi "k8s.io/metrics/pkg/apis/metrics"
 )
 
+// nodeLeaseNamespace is the namespace the kubelets renew their node leases in
+const nodeLeaseNamespace = "kube-node-lease"
+
+// minConditionHeartbeatTimeout is the minimum heartbeat timeout of nodes without a lease. Kubelets that renew a lease
+// only update the heartbeat of their ready condition every 5 minutes
+const minConditionHeartbeatTimeout = time.Minute * 10
+
+const (
+	nodeRoleControlPlane = "control-plane"
+	nodeRoleWorker       = "worker"
+)
+
+// nodeRoleControlPlaneLabels are the labels that mark a node as control plane node
+var nodeRoleControlPlaneLabels = []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"}
+
 func (r *Runner) doWatchNodes() error {
 	var nodeMetricsAvailable bool = false
 	var nodeMetricsMap = map[string]*metricsapi.NodeMetrics{}
@@ -22,20 +39,33 @@ func (r *Runner) doWatchNodes() error {
 			nodeMetricsMap[nodeMetric.Name] = &metric
 		}
 	} else if err != nil {
-		log.Printf("Couldn't get metrics for nodes: %v", err)
+		log.Warn("Couldn't get metrics for nodes", "error", err)
 	}
 
-	nodeList, err := r.client.Client().CoreV1().Nodes().List(metav1.ListOptions{})
-	if err != nil {
-		return err
-	}
+	nodeReadyStatus := make(map[string]bool)
+	defer r.setNodeReadyStatus(nodeReadyStatus)
+
+	renewTimes := r.getNodeLeaseRenewTimes()
+
+	for _, obj := range r.nodeWatch.Objects() {
+		node := obj.(*v1.Node)
+		nodeReadyStatus[node.Name] = isNodeReady(node)
+		if isIgnored(node) {
+			continue
+		}
 
-	for _, node := range nodeList.Items {
-		problem, err := isNodeProblem(&node)
+		role := getNodeRole(node)
+		err = r.checkNodeFlapping(node, role)
 		if err != nil {
 			return err
-		} else if nodeMetricsAvailable && nodeMetricsMap[node.Name] == nil {
-			msg := fmt.Sprintf("Metrics for node '%s' cannot be retrieved. This could mean the node crashed or is under heavy load", node.Name)
+		}
+
+		heartbeat, heartbeatTimeout := getNodeHeartbeat(node, renewTimes, r.nodeHeartbeatTimeout)
+		problem, err := isNodeProblem(node, role, heartbeat, heartbeatTimeout)
+		if err != nil {
+			return err
+		} else if problem == nil && nodeMetricsAvailable && nodeMetricsMap[node.Name] == nil {
+			msg := fmt.Sprintf("Metrics for node '%s' (%s) cannot be retrieved. This could mean the node crashed or is under heavy load", node.Name, role)
 			problem = &problemDesc{
 				problemType: problemTypeNodeResourcePressure,
 				kind:        resourceKindNode,
@@ -45,17 +75,26 @@ func (r *Runner) doWatchNodes() error {
 				message: msg,
 				occured: time.Now(),
 			}
-		} else if nodeMetricsAvailable && nodeMetricsMap[node.Name] != nil {
+		} else if problem == nil && nodeMetricsAvailable && nodeMetricsMap[node.Name] != nil {
 			cpuUsed := nodeMetricsMap[node.Name].Usage.Cpu().MilliValue()
 			cpuAvail := node.Status.Capacity.Cpu().MilliValue()
 			cpuUsage := float64(cpuUsed) / float64(cpuAvail)
 
+			// Allocatable memory excludes the memory reserved for the kernel and system daemons
 			memUsed := nodeMetricsMap[node.Name].Usage.Memory().MilliValue()
-			memAvail := node.Status.Capacity.Memory().MilliValue()
+			memAvail := getAllocatableMemory(node).MilliValue()
 			memUsage := float64(memUsed) / float64(memAvail)
 
-			if cpuUsage >= 0.95 {
-				msg := fmt.Sprintf("Node '%s' has constantly around 100%% cpu usage, this could slow down workloads running on the node", node.Name)
+			// Ephemeral storage usage is not reported by all metrics providers
+			diskUsage := float64(0)
+			diskUsed, diskUsedOk := nodeMetricsMap[node.Name].Usage[v1.ResourceEphemeralStorage]
+			diskAvail, diskAvailOk := node.Status.Capacity[v1.ResourceEphemeralStorage]
+			if diskUsedOk && diskAvailOk && diskAvail.Value() > 0 {
+				diskUsage = float64(diskUsed.Value()) / float64(diskAvail.Value())
+			}
+
+			if cpuUsage >= r.nodeCPUThreshold {
+				msg := fmt.Sprintf("Node '%s' (%s) has constantly over %d%% cpu usage, this could slow down workloads running on the node", node.Name, role, int(r.nodeCPUThreshold*100))
 				problem = &problemDesc{
 					problemType: problemTypeNodeResourcePressure,
 					kind:        resourceKindNode,
@@ -65,8 +104,8 @@ func (r *Runner) doWatchNodes() error {
 					message: msg,
 					occured: time.Now(),
 				}
-			} else if memUsage >= 0.95 {
-				msg := fmt.Sprintf("Node '%s' has constantly around 100%% memory usage, this could slow down workloads running on the node", node.Name)
+			} else if memUsage >= r.nodeMemThreshold {
+				msg := fmt.Sprintf("Node '%s' (%s) has constantly over %d%% memory usage, this could slow down workloads running on the node", node.Name, role, int(r.nodeMemThreshold*100))
 				problem = &problemDesc{
 					problemType: problemTypeNodeResourcePressure,
 					kind:        resourceKindNode,
@@ -76,35 +115,202 @@ func (r *Runner) doWatchNodes() error {
 					message: msg,
 					occured: time.Now(),
 				}
-			}
+			} else if diskUsage >= r.nodeDiskThreshold {
+				msg := fmt.Sprintf("Node '%s' (%s) has over %d%% ephemeral storage usage (%d of %d bytes used), pods could be evicted soon", node.Name, role, int(r.nodeDiskThreshold*100), diskUsed.Value(), diskAvail.Value())
+				problem = &problemDesc{
+					problemType: problemTypeNodeDiskPressure,
+					kind:        resourceKindNode,
+					name:        node.Name,
 
-			// Handle problem reporting or resolving
-			if problem != nil {
-				err = r.reportProblem(problem)
-				if err != nil {
-					return err
-				}
-			} else {
-				for _, problem := range r.problems {
-					if problem.kind == resourceKindNode && problem.name == node.Name {
-						err = r.resolveProblem(problem)
-						if err != nil {
-							return err
-						}
-					}
+					id:      node.Name + string(problemTypeNodeDiskPressure),
+					message: msg,
+					occured: time.Now(),
 				}
 			}
 		}
+
+		// Handle problem reporting or resolving, the allocatable skew is a static property of the node and
+		// therefore checked and resolved independently
+		if problem != nil {
+			err = r.reportNodeProblem(node, role, problem)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindNode, node.Name, "", problemTypeNodeAllocatableSkew)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = r.checkNodeAllocatableSkew(node, role)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func isNodeProblem(node *v1.Node) (*problemDesc, error) {
+// reportNodeProblem reports the problem of the node with the severity of its role and its node group
+func (r *Runner) reportNodeProblem(node *v1.Node, role string, problem *problemDesc) error {
+	// Problems of control plane nodes can have their own severity
+	if role == nodeRoleControlPlane && r.controlPlaneSeverity != "" {
+		problem.severity = r.controlPlaneSeverity
+	}
+
+	problem.nodeGroup = r.getNodeGroup(node)
+	return r.reportProblem(problem)
+}
+
+// checkNodeAllocatableSkew reports the node if a large part of its memory is not allocatable and resolves the
+// problem once the reserved memory is below the threshold again
+func (r *Runner) checkNodeAllocatableSkew(node *v1.Node, role string) error {
+	id := node.Name + string(problemTypeNodeAllocatableSkew)
+	reserved, capacity := getReservedMemory(node), node.Status.Capacity.Memory()
+	if capacity.Value() == 0 || float64(reserved.Value())/float64(capacity.Value()) <= r.nodeAllocatableSkewThreshold {
+		return r.resolveProblemWithID(id)
+	}
+
+	msg := fmt.Sprintf("Node '%s' (%s) has only %s of its %s memory allocatable (%s reserved), pods might be evicted or not scheduled although the node seems to have enough memory", node.Name, role, getAllocatableMemory(node).String(), capacity.String(), reserved.String())
+	return r.reportNodeProblem(node, role, &problemDesc{
+		problemType: problemTypeNodeAllocatableSkew,
+		kind:        resourceKindNode,
+		name:        node.Name,
+
+		id:      id,
+		message: msg,
+		occured: time.Now(),
+	})
+}
+
+func (r *Runner) setNodeReadyStatus(nodeReadyStatus map[string]bool) {
+	r.nodeReadyStatusMutex.Lock()
+	defer r.nodeReadyStatusMutex.Unlock()
+
+	r.nodeReadyStatus = nodeReadyStatus
+}
+
+// updateNodeReadyStatus updates the ready status of a single node, e.g. if the informer was notified about a change
+func (r *Runner) updateNodeReadyStatus(nodeName string, ready bool) {
+	r.nodeReadyStatusMutex.Lock()
+	defer r.nodeReadyStatusMutex.Unlock()
+
+	if r.nodeReadyStatus == nil {
+		r.nodeReadyStatus = make(map[string]bool)
+	}
+	r.nodeReadyStatus[nodeName] = ready
+}
+
+// deleteNodeReadyStatus removes the ready status of a deleted node
+func (r *Runner) deleteNodeReadyStatus(nodeName string) {
+	r.nodeReadyStatusMutex.Lock()
+	defer r.nodeReadyStatusMutex.Unlock()
+
+	delete(r.nodeReadyStatus, nodeName)
+}
+
+// isNodeNotReady returns true if the node was not ready during the last node check or its last update. Unknown nodes are
+// considered ready, e.g. if nodes are not watched
+func (r *Runner) isNodeNotReady(nodeName string) bool {
+	r.nodeReadyStatusMutex.RLock()
+	defer r.nodeReadyStatusMutex.RUnlock()
+
+	ready, ok := r.nodeReadyStatus[nodeName]
+	return ok && !ready
+}
+
+// getAllocatableMemory returns the allocatable memory of the node or its capacity if allocatable is not reported
+func getAllocatableMemory(node *v1.Node) *resource.Quantity {
+	if allocatable := node.Status.Allocatable.Memory(); !allocatable.IsZero() {
+		return allocatable
+	}
+
+	return node.Status.Capacity.Memory()
+}
+
+// getReservedMemory returns the memory of the node that is not allocatable
+func getReservedMemory(node *v1.Node) *resource.Quantity {
+	reserved := node.Status.Capacity.Memory().DeepCopy()
+	reserved.Sub(*getAllocatableMemory(node))
+	return &reserved
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// getNodeRole returns control-plane if the node has the control plane or legacy master role label, otherwise worker
+func getNodeRole(node *v1.Node) string {
+	for _, label := range nodeRoleControlPlaneLabels {
+		if _, ok := node.Labels[label]; ok {
+			return nodeRoleControlPlane
+		}
+	}
+
+	return nodeRoleWorker
+}
+
+// getNodeLeaseRenewTimes returns the last time each node renewed its lease. Errors are only logged, in that case the
+// heartbeats of the ready conditions are used
+func (r *Runner) getNodeLeaseRenewTimes() map[string]time.Time {
+	renewTimes := make(map[string]time.Time)
+
+	var leases *coordinationv1.LeaseList
+	err := r.withRetry(func() (err error) {
+		leases, err = r.client.Client().CoordinationV1().Leases(nodeLeaseNamespace).List(metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		if isPermanentError(err) {
+			log.Debug("Couldn't list node leases, using the heartbeats of the node conditions", "error", err)
+		} else {
+			log.Warn("Couldn't list node leases, using the heartbeats of the node conditions", "error", err)
+		}
+
+		return renewTimes
+	}
+
+	for _, lease := range leases.Items {
+		if lease.Spec.RenewTime != nil {
+			renewTimes[lease.Name] = lease.Spec.RenewTime.Time
+		}
+	}
+
+	return renewTimes
+}
+
+// getNodeHeartbeat returns the last heartbeat of the node and after which time without a heartbeat it is reported.
+// The heartbeat is the renew time of the node lease or, for nodes without a lease, the heartbeat of the ready
+// condition, which is checked with a timeout of at least minConditionHeartbeatTimeout
+func getNodeHeartbeat(node *v1.Node, renewTimes map[string]time.Time, timeout time.Duration) (time.Time, time.Duration) {
+	if renewTime, ok := renewTimes[node.Name]; ok {
+		return renewTime, timeout
+	}
+
+	if timeout < minConditionHeartbeatTimeout {
+		timeout = minConditionHeartbeatTimeout
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.LastHeartbeatTime.Time, timeout
+		}
+	}
+
+	return time.Time{}, timeout
+}
+
+func isNodeProblem(node *v1.Node, role string, heartbeat time.Time, heartbeatTimeout time.Duration) (*problemDesc, error) {
 	// Check for conditions
 	for _, condition := range node.Status.Conditions {
 		if condition.Type != v1.NodeReady && condition.Status != v1.ConditionFalse {
-			msg := fmt.Sprintf("Node '%s' has condition (%s): %s", node.Name, condition.Type, condition.Message)
+			msg := fmt.Sprintf("Node '%s' (%s) has condition (%s): %s", node.Name, role, condition.Type, condition.Message)
 			return &problemDesc{
 				problemType: problemTypeNodeCondition,
 				kind:        resourceKindNode,
@@ -115,7 +321,7 @@ func isNodeProblem(node *v1.Node) (*problemDesc, error) {
 				occured: time.Now(),
 			}, nil
 		} else if condition.Type == v1.NodeReady && condition.Status != v1.ConditionTrue {
-			msg := fmt.Sprintf("Node '%s' has ready status '%s': %s", node.Name, condition.Status, condition.Message)
+			msg := fmt.Sprintf("Node '%s' (%s) has ready status '%s': %s", node.Name, role, condition.Status, condition.Message)
 			return &problemDesc{
 				problemType: problemTypeNodeCondition,
 				kind:        resourceKindNode,
@@ -125,6 +331,18 @@ func isNodeProblem(node *v1.Node) (*problemDesc, error) {
 				id:      msg,
 				occured: time.Now(),
 			}, nil
+		} else if condition.Type == v1.NodeReady && !heartbeat.IsZero() && time.Since(heartbeat) > heartbeatTimeout {
+			// The node might have lost the connection to the api server without its conditions being updated
+			msg := fmt.Sprintf("Node '%s' (%s) has not sent a heartbeat for %v, it might have lost the connection to the api server", node.Name, role, time.Since(heartbeat).Round(time.Second))
+			return &problemDesc{
+				problemType: problemTypeNodeHeartbeatStale,
+				kind:        resourceKindNode,
+				name:        node.Name,
+
+				message: msg,
+				id:      node.Name + string(problemTypeNodeHeartbeatStale),
+				occured: time.Now(),
+			}, nil
 		}
 	}
 
@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestNode(name, capacity, allocatable string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Capacity:    v1.ResourceList{v1.ResourceMemory: resource.MustParse(capacity)},
+			Allocatable: v1.ResourceList{v1.ResourceMemory: resource.MustParse(allocatable)},
+		},
+	}
+}
+
+func TestCheckNodeAllocatableSkew(t *testing.T) {
+	tests := []struct {
+		name        string
+		node        *v1.Node
+		expectSkew  bool
+		expectAlert bool
+	}{
+		{
+			name: "small reservation",
+			node: newTestNode("node", "16Gi", "15Gi"),
+		},
+		{
+			name: "typical reservation of a small node",
+			node: newTestNode("node", "4Gi", "3Gi"),
+		},
+		{
+			name:        "large reservation",
+			node:        newTestNode("node", "16Gi", "8Gi"),
+			expectSkew:  true,
+			expectAlert: true,
+		},
+	}
+
+	for _, test := range tests {
+		notifier := slack.NewMockClient()
+		r := newTestRunner(notifier)
+		r.nodeAllocatableSkewThreshold = defaultNodeAllocatableSkewThreshold
+
+		err := r.checkNodeAllocatableSkew(test.node, nodeRoleWorker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.flushReports()
+
+		if _, ok := r.problems["node"+string(problemTypeNodeAllocatableSkew)]; ok != test.expectSkew {
+			t.Fatalf("%s: expected skew problem %v, got %v", test.name, test.expectSkew, ok)
+		}
+		if alerts := notifier.Alerts(); (len(alerts) == 1) != test.expectAlert {
+			t.Fatalf("%s: unexpected alerts %v", test.name, alerts)
+		}
+	}
+}
+
+func TestNodeSkewDoesNotMaskResolve(t *testing.T) {
+	notifier := slack.NewMockClient()
+	r := newTestRunner(notifier)
+	r.nodeAllocatableSkewThreshold = defaultNodeAllocatableSkewThreshold
+	node := newTestNode("node", "16Gi", "8Gi")
+
+	// The node is not ready and has a large memory reservation
+	err := r.reportNodeProblem(node, nodeRoleWorker, &problemDesc{
+		problemType: problemTypeNodeCondition,
+		kind:        resourceKindNode,
+		name:        "node",
+		id:          "node/condition",
+		message:     "Node node is not ready",
+		occured:     time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.checkNodeAllocatableSkew(node, nodeRoleWorker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.flushReports()
+	notifier.AssertAlertCount(t, 2)
+
+	// The node is ready again, which resolves the condition but not the skew
+	err = r.resolveProblemsOf(resourceKindNode, "node", "", problemTypeNodeAllocatableSkew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.checkNodeAllocatableSkew(node, nodeRoleWorker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.flushReports()
+
+	notifier.AssertResolveCount(t, 1)
+	if resolves := notifier.Resolves(); len(resolves) == 1 && resolves[0].Message != "Node node is not ready" {
+		t.Fatalf("expected the node condition to be resolved, got %v", resolves[0])
+	}
+	if len(r.problems) != 1 || r.problems["node"+string(problemTypeNodeAllocatableSkew)] == nil {
+		t.Fatalf("expected only the skew problem to remain, got %v", r.problems)
+	}
+
+	// The reservation was reduced
+	err = r.checkNodeAllocatableSkew(newTestNode("node", "16Gi", "15Gi"), nodeRoleWorker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.flushReports()
+	notifier.AssertResolveCount(t, 2)
+}
+
+func TestNodeHeartbeat(t *testing.T) {
+	now := time.Now()
+	newNode := func(heartbeat time.Time) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{
+					{Type: v1.NodeReady, Status: v1.ConditionTrue, LastHeartbeatTime: metav1.NewTime(heartbeat)},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		node          *v1.Node
+		renewTimes    map[string]time.Time
+		expectProblem bool
+	}{
+		{
+			name:       "lease renewed recently, condition updated 6 minutes ago",
+			node:       newNode(now.Add(-time.Minute * 6)),
+			renewTimes: map[string]time.Time{"node": now.Add(-time.Second * 10)},
+		},
+		{
+			name:          "lease not renewed",
+			node:          newNode(now.Add(-time.Minute * 6)),
+			renewTimes:    map[string]time.Time{"node": now.Add(-time.Minute * 6)},
+			expectProblem: true,
+		},
+		{
+			name: "no lease, condition updated 6 minutes ago",
+			node: newNode(now.Add(-time.Minute * 6)),
+		},
+		{
+			name:          "no lease, condition not updated",
+			node:          newNode(now.Add(-time.Minute * 11)),
+			expectProblem: true,
+		},
+	}
+
+	for _, test := range tests {
+		heartbeat, timeout := getNodeHeartbeat(test.node, test.renewTimes, defaultNodeHeartbeatTimeout)
+		problem, err := isNodeProblem(test.node, nodeRoleWorker, heartbeat, timeout)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (problem != nil) != test.expectProblem {
+			t.Fatalf("%s: expected problem %v, got %v", test.name, test.expectProblem, problem)
+		} else if problem != nil && problem.problemType != problemTypeNodeHeartbeatStale {
+			t.Fatalf("%s: expected a stale heartbeat, got %s", test.name, problem.problemType)
+		}
+	}
+}
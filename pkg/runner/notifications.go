@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+)
+
+type notificationKind int
+
+const (
+	notificationReport notificationKind = iota
+	notificationUpdate
+	notificationResolve
+)
+
+func (k notificationKind) String() string {
+	switch k {
+	case notificationUpdate:
+		return "update"
+	case notificationResolve:
+		return "resolve"
+	}
+
+	return "report"
+}
+
+// notification is a message about a problem, which is queued with the problems mutex locked and sent after it was
+// released, so the checks and the api are not blocked by slow notifiers
+type notification struct {
+	kind    notificationKind
+	problem *problemDesc
+
+	// alert is the problem that is sent by reports and resolves
+	alert notify.Problem
+	// thread is the thread of the problem for updates and resolves and message the text of an update
+	thread  string
+	message string
+}
+
+// queueNotification adds the notification to the notifications that are sent at the end of the check cycle. Needs to
+// be called with the problems mutex locked
+func (r *Runner) queueNotification(n notification) {
+	r.notifications = append(r.notifications, n)
+}
+
+// sendNotifications sends the notifications in the order they were queued. Errors are logged and counted instead of
+// returned, so a failing notifier neither stops the runner nor the remaining notifications. Needs to be called
+// without the problems mutex locked
+func (r *Runner) sendNotifications(notifications []notification) {
+	for _, n := range notifications {
+		var err error
+		switch n.kind {
+		case notificationReport:
+			err = r.sendReportMessage(n)
+		case notificationUpdate:
+			err = r.sendUpdateMessage(n)
+		case notificationResolve:
+			err = r.sendResolveMessage(n)
+		}
+		if err != nil {
+			log.Error("Error sending "+n.kind.String()+" message", append(n.problem.logFields(), "error", err)...)
+			prometheus.NotificationErrors.Inc(n.kind.String())
+		}
+	}
+}
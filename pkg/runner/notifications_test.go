@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	slackapi "github.com/nlopes/slack"
+)
+
+// failingNotifier fails every alert and resolve
+type failingNotifier struct {
+	calls int32
+}
+
+func (n *failingNotifier) Alert(p notify.Problem) error {
+	atomic.AddInt32(&n.calls, 1)
+	return errors.New("notifier unavailable")
+}
+
+func (n *failingNotifier) Resolve(p notify.Problem) error {
+	atomic.AddInt32(&n.calls, 1)
+	return errors.New("notifier unavailable")
+}
+
+func newPodProblem(name string) *problemDesc {
+	return &problemDesc{
+		problemType: problemTypePodStatus,
+		kind:        resourceKindPod,
+		name:        name,
+		namespace:   "default",
+		id:          "default/" + name + "/status",
+		message:     "Pod default/" + name + " has critical status CrashLoopBackOff",
+		occured:     time.Now(),
+	}
+}
+
+func TestNotifierErrorsAreCounted(t *testing.T) {
+	tests := []struct {
+		name          string
+		reports       int
+		resolves      int
+		expectedCalls int32
+	}{
+		{
+			name:          "reports",
+			reports:       3,
+			expectedCalls: 3,
+		},
+		{
+			name:          "reports and resolves",
+			reports:       2,
+			resolves:      2,
+			expectedCalls: 4,
+		},
+	}
+
+	for _, test := range tests {
+		notifier := &failingNotifier{}
+		r := newTestRunner(notifier)
+
+		for i := 0; i < test.reports; i++ {
+			err := r.reportProblem(newPodProblem(fmt.Sprintf("pod-%d", i)))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		r.flushReports()
+
+		for i := 0; i < test.resolves; i++ {
+			err := r.resolveProblemsOf(resourceKindPod, fmt.Sprintf("pod-%d", i), "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		r.flushReports()
+
+		// Every notification is tried even though the previous ones failed
+		if calls := atomic.LoadInt32(&notifier.calls); calls != test.expectedCalls {
+			t.Fatalf("%s: expected %d notifier calls, got %d", test.name, test.expectedCalls, calls)
+		}
+	}
+
+	if metrics := getMetrics(t); !strings.Contains(metrics, `kube_problem_notification_errors_total{type="report"}`) {
+		t.Fatalf("expected the failed reports to be counted: %s", metrics)
+	}
+}
+
+func TestSlackCircuitBreakerOpens(t *testing.T) {
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := slack.NewClient("token", "#alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.API = slackapi.New("token", slackapi.OptionAPIURL(server.URL+"/"))
+	client.SetRetry(0, time.Millisecond, time.Millisecond)
+	client.SetCircuitBreaker(2, time.Minute)
+
+	r := newTestRunner(client)
+	for i := 0; i < 5; i++ {
+		err := r.reportProblem(newPodProblem(fmt.Sprintf("pod-%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.flushReports()
+
+	// The breaker opens after the second failure, so the remaining alerts are not sent to slack
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected 2 slack requests, got %d", n)
+	}
+}
+
+func getMetrics(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	prometheus.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	out, err := ioutil.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(out)
+}
+
+func TestUpdateMessageWithoutThreadNotifier(t *testing.T) {
+	r := newTestRunner(&recordingNotifier{})
+	err := r.sendUpdateMessage(notification{kind: notificationUpdate, problem: newPodProblem("pod"), thread: "1234.5678", message: "update"})
+	if err == nil {
+		t.Fatal("Expected an error for a notifier without thread messages")
+	}
+}
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultOOMKillRateThreshold = 5
+const defaultOOMKillRateWindow = time.Minute * 5
+
+// oomKillRateBuckets is the number of time buckets the oomkill rate window is divided into
+const oomKillRateBuckets = 30
+
+// oomKillRate counts the oomkills of a pod in a sliding window. The window is a ring buffer of time buckets,
+// every slot holds the count of the bucket it was last used for
+type oomKillRate struct {
+	buckets [oomKillRateBuckets]int64
+	counts  [oomKillRateBuckets]int
+
+	// lastFinished and lastRestarts are the last seen termination and restart count per container,
+	// which are used to detect new oomkills
+	lastFinished map[string]time.Time
+	lastRestarts map[string]int32
+	lastKill     time.Time
+}
+
+func newOOMKillRate() *oomKillRate {
+	return &oomKillRate{
+		lastFinished: make(map[string]time.Time),
+		lastRestarts: make(map[string]int32),
+	}
+}
+
+// add adds count oomkills at the given time
+func (o *oomKillRate) add(t time.Time, count int, bucketWidth time.Duration) {
+	bucket := t.UnixNano() / int64(bucketWidth)
+	slot := bucket % oomKillRateBuckets
+	if o.buckets[slot] != bucket {
+		o.buckets[slot] = bucket
+		o.counts[slot] = 0
+	}
+
+	o.counts[slot] += count
+	if t.After(o.lastKill) {
+		o.lastKill = t
+	}
+}
+
+// count returns the number of oomkills in the window that ends now
+func (o *oomKillRate) count(now time.Time, bucketWidth time.Duration) int {
+	current := now.UnixNano() / int64(bucketWidth)
+	count := 0
+	for slot, bucket := range o.buckets {
+		if bucket > current-oomKillRateBuckets && bucket <= current {
+			count += o.counts[slot]
+		}
+	}
+
+	return count
+}
+
+// recordOOMKills records the new oomkills of the containers of the pod and returns the number of oomkills of the pod
+// within the oomkill rate window. If a container was restarted multiple times since the last check, all restarts
+// are counted as oomkills if the last one was an oomkill
+func (r *Runner) recordOOMKills(pod *v1.Pod) int {
+	r.oomKillRatesMutex.Lock()
+	defer r.oomKillRatesMutex.Unlock()
+
+	key := pod.Namespace + "/" + pod.Name
+	rate := r.oomKillRates[key]
+	bucketWidth := r.oomKillRateWindow / oomKillRateBuckets
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		terminated := containerStatus.LastTerminationState.Terminated
+		if terminated == nil || terminated.Reason != "OOMKilled" || time.Since(terminated.FinishedAt.Time) > r.oomKillRateWindow {
+			continue
+		}
+
+		if rate == nil {
+			rate = newOOMKillRate()
+			r.oomKillRates[key] = rate
+		}
+		if lastFinished, ok := rate.lastFinished[containerStatus.Name]; ok && !terminated.FinishedAt.Time.After(lastFinished) {
+			continue
+		}
+
+		kills := 1
+		if lastRestarts, ok := rate.lastRestarts[containerStatus.Name]; ok && containerStatus.RestartCount-lastRestarts > 1 {
+			kills = int(containerStatus.RestartCount - lastRestarts)
+		}
+
+		rate.lastFinished[containerStatus.Name] = terminated.FinishedAt.Time
+		rate.lastRestarts[containerStatus.Name] = containerStatus.RestartCount
+		rate.add(terminated.FinishedAt.Time, kills, bucketWidth)
+	}
+	if rate == nil {
+		return 0
+	}
+
+	return rate.count(time.Now(), bucketWidth)
+}
+
+// cleanupOOMKillRates removes the oomkill rates of pods without oomkills within the window
+func (r *Runner) cleanupOOMKillRates() {
+	r.oomKillRatesMutex.Lock()
+	defer r.oomKillRatesMutex.Unlock()
+
+	for key, rate := range r.oomKillRates {
+		if time.Since(rate.lastKill) > r.oomKillRateWindow {
+			delete(r.oomKillRates, key)
+		}
+	}
+}
+
+// getOOMKillProblem returns a problem if a container of the pod was OOMKilled within the last hour, regardless of the
+// phase and status of the pod. A pod in CrashLoopBackOff because its container is OOMKilled on every start is reported
+// as OOMKill, because the memory limit is the cause of the crashes
+func (r *Runner) getOOMKillProblem(pod *v1.Pod, status string, oomKills int) *problemDesc {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		terminated := getOOMKilledTermination(containerStatus)
+		if terminated == nil || time.Since(terminated.FinishedAt.Time) > time.Hour {
+			continue
+		}
+
+		msg := fmt.Sprintf("Container '%s' of pod '%s/%s' was OOMKilled %d seconds ago, consider increasing its memory limit (current limit: %s)", containerStatus.Name, pod.Namespace, pod.Name, time.Since(terminated.FinishedAt.Time)/time.Second, getContainerMemoryLimit(pod, containerStatus.Name))
+		if !OkayStatus[status] {
+			msg += fmt.Sprintf(". The pod has status '%s'", status)
+		}
+
+		// Frequent oomkills indicate a systemic problem like a memory leak
+		severity := r.getSeverity(problemTypePodOOMKill)
+		if oomKills > r.oomKillRateThreshold {
+			msg += fmt.Sprintf(". The pod was OOMKilled %d times within the last %v", oomKills, r.oomKillRateWindow)
+			severity = severityCritical
+		}
+
+		return &problemDesc{
+			problemType: problemTypePodOOMKill,
+			severity:    severity,
+
+			message: msg,
+			id:      pod.Name + "/" + pod.Namespace + string(problemTypePodOOMKill),
+
+			kind:      resourceKindPod,
+			name:      pod.Name,
+			namespace: pod.Namespace,
+			container: containerStatus.Name,
+			occured:   time.Now(),
+		}
+	}
+
+	return nil
+}
+
+// getOOMKilledTermination returns the termination of the container if it is or was last terminated because of an oomkill
+func getOOMKilledTermination(containerStatus v1.ContainerStatus) *v1.ContainerStateTerminated {
+	if containerStatus.State.Terminated != nil && containerStatus.State.Terminated.Reason == "OOMKilled" {
+		return containerStatus.State.Terminated
+	} else if containerStatus.LastTerminationState.Terminated != nil && containerStatus.LastTerminationState.Terminated.Reason == "OOMKilled" {
+		return containerStatus.LastTerminationState.Terminated
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newOOMKilledPod(phase v1.PodPhase, state, lastState v1.ContainerState) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app"}},
+		},
+		Status: v1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:                 "app",
+				State:                state,
+				LastTerminationState: lastState,
+				RestartCount:         3,
+			}},
+		},
+	}
+}
+
+func TestGetOOMKillProblem(t *testing.T) {
+	oomKilled := v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(time.Now().Add(-time.Minute))}}
+	oldOOMKilled := v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(time.Now().Add(-time.Hour * 2))}}
+	errored := v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1, FinishedAt: metav1.NewTime(time.Now().Add(-time.Minute))}}
+	running := v1.ContainerState{Running: &v1.ContainerStateRunning{}}
+	crashLoop := v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		expectOOM bool
+	}{
+		{
+			name:      "running after oomkill",
+			pod:       newOOMKilledPod(v1.PodRunning, running, oomKilled),
+			expectOOM: true,
+		},
+		{
+			name:      "crash loop caused by oomkill",
+			pod:       newOOMKilledPod(v1.PodRunning, crashLoop, oomKilled),
+			expectOOM: true,
+		},
+		{
+			name:      "currently oomkilled",
+			pod:       newOOMKilledPod(v1.PodRunning, oomKilled, v1.ContainerState{}),
+			expectOOM: true,
+		},
+		{
+			name: "crash loop caused by errors",
+			pod:  newOOMKilledPod(v1.PodRunning, crashLoop, errored),
+		},
+		{
+			name: "oomkill more than an hour ago",
+			pod:  newOOMKilledPod(v1.PodRunning, running, oldOOMKilled),
+		},
+	}
+
+	for _, test := range tests {
+		r := newTestRunner(slack.NewMockClient())
+		r.oomKillRateThreshold = defaultOOMKillRateThreshold
+
+		problem := r.getOOMKillProblem(test.pod, GetPodStatus(test.pod), 1)
+		if (problem != nil) != test.expectOOM {
+			t.Fatalf("%s: expected oomkill problem %v, got %v", test.name, test.expectOOM, problem)
+		}
+		if problem != nil && problem.container != "app" {
+			t.Fatalf("%s: expected container app, got %s", test.name, problem.container)
+		}
+	}
+}
+
+func TestGetOOMKillProblemRate(t *testing.T) {
+	r := newTestRunner(slack.NewMockClient())
+	r.oomKillRateThreshold = defaultOOMKillRateThreshold
+	r.oomKillRateWindow = defaultOOMKillRateWindow
+
+	pod := newOOMKilledPod(v1.PodRunning, v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}, v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.Now()}})
+	problem := r.getOOMKillProblem(pod, "CrashLoopBackOff", defaultOOMKillRateThreshold+1)
+	if problem == nil || problem.severity != severityCritical {
+		t.Fatalf("expected a critical oomkill problem, got %v", problem)
+	}
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"os"
+	"strings"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultCriticalPriorityClasses = "system-cluster-critical,system-node-critical"
+
+// getCriticalPriorityClassesFromEnv returns the priority classes of CRITICAL_PRIORITY_CLASSES
+func getCriticalPriorityClassesFromEnv() map[string]bool {
+	value := os.Getenv("CRITICAL_PRIORITY_CLASSES")
+	if strings.TrimSpace(value) == "" {
+		value = defaultCriticalPriorityClasses
+	}
+
+	priorityClasses := make(map[string]bool)
+	for _, priorityClass := range strings.Split(value, ",") {
+		if priorityClass = strings.TrimSpace(priorityClass); priorityClass != "" {
+			priorityClasses[priorityClass] = true
+		}
+	}
+
+	return priorityClasses
+}
+
+// setPodPriority sets the priority of the pod on its problem, problems of pods with a critical priority class are
+// always critical
+func (r *Runner) setPodPriority(problem *problemDesc, pod *v1.Pod) {
+	if pod.Spec.Priority != nil {
+		problem.priority = *pod.Spec.Priority
+	}
+	problem.priorityClassName = pod.Spec.PriorityClassName
+	if r.criticalPriorityClasses[problem.priorityClassName] {
+		problem.severity = severityCritical
+	}
+}
+
+// getAlertProblem returns the problem that is sent as alert, alerts of pods with a critical priority class start
+// with an emoji to stand out
+func (r *Runner) getAlertProblem(problem *problemDesc) notify.Problem {
+	alert := problem.toAlertProblem()
+	if r.criticalPriorityClasses[problem.priorityClassName] {
+		alert.Message = ":rotating_light: " + alert.Message
+	}
+
+	return alert
+}
@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// checkContainerProbes reports every container of the pod that has no liveness or readiness probe and resolves
+// the problem once both probes are set
+func (r *Runner) checkContainerProbes(pod *v1.Pod) error {
+	if len(r.probeCheckNamespaces) > 0 && !r.probeCheckNamespaces[pod.Namespace] {
+		return nil
+	}
+
+	for _, container := range pod.Spec.Containers {
+		missing := []string{}
+		if container.LivenessProbe == nil {
+			missing = append(missing, "liveness")
+		}
+		if container.ReadinessProbe == nil {
+			missing = append(missing, "readiness")
+		}
+
+		msg := ""
+		if len(missing) > 0 {
+			msg = fmt.Sprintf("Container '%s' of pod '%s/%s' has no %s probe", container.Name, pod.Namespace, pod.Name, strings.Join(missing, " and "))
+		}
+		err := r.reportContainerProblem(pod, container.Name, problemTypePodNoProbe, msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseProbeCheckNamespaces(value string) map[string]bool {
+	namespaces := make(map[string]bool)
+	for _, namespace := range strings.Split(value, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" {
+			namespaces[namespace] = true
+		}
+	}
+
+	return namespaces
+}
@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func (r *Runner) doWatchPVCs(namespace string) error {
+	var pvcList *v1.PersistentVolumeClaimList
+	err := r.withRetry(func() (err error) {
+		pvcList, err = r.client.Client().CoreV1().PersistentVolumeClaims(namespace).List(r.listOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pvc := range pvcList.Items {
+		if isIgnored(&pvc) {
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		if pvc.Status.Phase == v1.ClaimPending {
+			storageClass := "default"
+			if pvc.Spec.StorageClassName != nil {
+				storageClass = *pvc.Spec.StorageClassName
+			}
+
+			storageRequest := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+			msg := fmt.Sprintf("PersistentVolumeClaim '%s/%s' (storage class '%s', requested capacity %s) is pending for more than %v", pvc.Namespace, pvc.Name, storageClass, storageRequest.String(), r.pvcPendingTimeout)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypePVCPending,
+
+				message: msg,
+				id:      pvc.Name + "/" + pvc.Namespace + string(problemTypePVCPending),
+
+				kind:        resourceKindPVC,
+				name:        pvc.Name,
+				namespace:   pvc.Namespace,
+				occured:     time.Now(),
+				reportAfter: r.pvcPendingTimeout,
+			})
+			if err != nil {
+				return err
+			}
+		} else if pvc.Status.Phase == v1.ClaimBound {
+			err = r.resolveProblemsOf(resourceKindPVC, pvc.Name, pvc.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+)
+
+// quietPeriodEnvs holds the environment variable that configures the quiet period of each problem type
+var quietPeriodEnvs = map[problemType]string{
+	problemTypeNodeCondition:        "NODE_CONDITION_QUIET_PERIOD",
+	problemTypeNodeResourcePressure: "NODE_PRESSURE_QUIET_PERIOD",
+	problemTypeNodeDiskPressure:     "NODE_DISK_PRESSURE_QUIET_PERIOD",
+	problemTypeNodeHeartbeatStale:   "NODE_HEARTBEAT_STALE_QUIET_PERIOD",
+	problemTypeNodeAllocatableSkew:  "NODE_ALLOCATABLE_SKEW_QUIET_PERIOD",
+	problemTypeNodeFlapping:         "NODE_FLAPPING_QUIET_PERIOD",
+
+	problemTypePodStatus:   "POD_STATUS_QUIET_PERIOD",
+	problemTypePodRestarts: "POD_RESTART_QUIET_PERIOD",
+	problemTypePodPending:  "POD_PENDING_QUIET_PERIOD",
+	problemTypePodOOMKill:  "POD_OOM_KILL_QUIET_PERIOD",
+
+	problemTypePodStuckTerminating: "POD_STUCK_TERMINATING_QUIET_PERIOD",
+	problemTypeCPUThrottling:       "CPU_THROTTLING_QUIET_PERIOD",
+	problemTypePodResourceRatio:    "POD_RESOURCE_RATIO_QUIET_PERIOD",
+	problemTypePodOnNotReadyNode:   "POD_ON_NOT_READY_NODE_QUIET_PERIOD",
+	problemTypePodUnready:          "POD_UNREADY_QUIET_PERIOD",
+
+	problemTypeDeploymentStall:      "DEPLOYMENT_STALL_QUIET_PERIOD",
+	problemTypeDeploymentRollback:   "DEPLOYMENT_ROLLBACK_QUIET_PERIOD",
+	problemTypeStatefulSetDegraded:  "STATEFULSET_DEGRADED_QUIET_PERIOD",
+	problemTypeDaemonSetUnavailable: "DAEMONSET_UNAVAIL_QUIET_PERIOD",
+	problemTypeHPAAtMax:             "HPA_AT_MAX_QUIET_PERIOD",
+	problemTypeJobFailed:            "JOB_FAILED_QUIET_PERIOD",
+	problemTypeCronJobMissed:        "CRONJOB_MISSED_QUIET_PERIOD",
+	problemTypeCronJobStuck:         "CRONJOB_STUCK_QUIET_PERIOD",
+
+	problemTypePVCPending:      "PVC_PENDING_QUIET_PERIOD",
+	problemTypeQuotaExhaustion: "QUOTA_EXHAUSTION_QUIET_PERIOD",
+	problemTypeCRDStatus:       "CRD_STATUS_QUIET_PERIOD",
+
+	problemTypeNoReadyEndpoints: "NO_READY_ENDPOINTS_QUIET_PERIOD",
+
+	problemTypeCertExpiry: "CERT_EXPIRY_QUIET_PERIOD",
+
+	problemTypeWarningEvent: "WARNING_EVENT_QUIET_PERIOD",
+
+	problemTypeNamespaceStuck: "NAMESPACE_STUCK_QUIET_PERIOD",
+}
+
+// QuietPeriodEnvName returns the environment variable that configures the quiet period of the given problem type
+func QuietPeriodEnvName(name string) (string, bool) {
+	env, ok := quietPeriodEnvs[problemType(name)]
+	return env, ok
+}
+
+// newQuietPeriodsFromEnv returns the configured quiet periods, problem types without a quiet period are omitted
+func newQuietPeriodsFromEnv() map[problemType]time.Duration {
+	quietPeriods := make(map[problemType]time.Duration)
+	for problemType, name := range quietPeriodEnvs {
+		quietPeriod := getDurationFromEnv(name, 0)
+		if quietPeriod > 0 {
+			log.Info("Using quiet period", "problem_type", string(problemType), "quiet_period", quietPeriod)
+			quietPeriods[problemType] = quietPeriod
+		}
+	}
+
+	return quietPeriods
+}
+
+// recordResolved remembers until when a reported problem that was removed should not be alerted again.
+// Needs to be called with the problems mutex locked
+func (r *Runner) recordResolved(problem *problemDesc) {
+	if quietPeriod := r.quietPeriod[problem.problemType]; problem.reported && quietPeriod > 0 {
+		r.quietUntil[problem.id] = time.Now().Add(quietPeriod)
+	}
+}
+
+// inQuietPeriod returns true if the problem was resolved less than its quiet period ago.
+// Needs to be called with the problems mutex locked
+func (r *Runner) inQuietPeriod(problem *problemDesc) bool {
+	quietUntil, ok := r.quietUntil[problem.id]
+	if !ok {
+		return false
+	} else if time.Now().Before(quietUntil) {
+		return true
+	}
+
+	// The quiet period expired, so this is a new problem
+	delete(r.quietUntil, problem.id)
+	return false
+}
+
+// cleanupQuietPeriods forgets resolved problems whose quiet period expired.
+// Needs to be called with the problems mutex locked
+func (r *Runner) cleanupQuietPeriods() {
+	now := time.Now()
+	for id, quietUntil := range r.quietUntil {
+		if now.After(quietUntil) {
+			delete(r.quietUntil, id)
+		}
+	}
+}
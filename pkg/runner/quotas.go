@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// quotaResources are the resources of a resource quota that are checked
+var quotaResources = []v1.ResourceName{
+	v1.ResourceRequestsCPU,
+	v1.ResourceLimitsCPU,
+	v1.ResourceRequestsMemory,
+	v1.ResourceLimitsMemory,
+}
+
+func (r *Runner) doWatchResourceQuotas(namespace string) error {
+	var quotaList *v1.ResourceQuotaList
+	err := r.withRetry(func() (err error) {
+		quotaList, err = r.client.Client().CoreV1().ResourceQuotas(namespace).List(r.listOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, quota := range quotaList.Items {
+		if isIgnored(&quota) {
+			continue
+		}
+
+		exhausted := false
+		for _, resourceName := range quotaResources {
+			hard, ok := quota.Status.Hard[resourceName]
+			if !ok || hard.IsZero() {
+				continue
+			}
+
+			used := quota.Status.Used[resourceName]
+			if float64(used.MilliValue())/float64(hard.MilliValue()) < r.quotaAlertThreshold {
+				continue
+			}
+
+			exhausted = true
+			msg := fmt.Sprintf("ResourceQuota '%s/%s' has used %s of %s %s (over %d%%), new pods might not be scheduled", quota.Namespace, quota.Name, used.String(), hard.String(), resourceName, int(r.quotaAlertThreshold*100))
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeQuotaExhaustion,
+
+				message: msg,
+				id:      quota.Name + "/" + quota.Namespace + string(problemTypeQuotaExhaustion) + "/" + string(resourceName),
+
+				kind:      resourceKindResourceQuota,
+				name:      quota.Name,
+				namespace: quota.Namespace,
+				occured:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Handle problem resolving
+		if !exhausted {
+			err = r.resolveProblemsOf(resourceKindResourceQuota, quota.Name, quota.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultMinMemRatio = 1.5
+
+// checkContainerRatios reports every restarted container of the pod whose limit is too close to its request
+func (r *Runner) checkContainerRatios(pod *v1.Pod) error {
+	for _, container := range pod.Spec.Containers {
+		anomalies := []string{}
+		if getRestartCount(pod, container.Name) > 0 {
+			for resourceName, minRatio := range map[v1.ResourceName]float64{v1.ResourceMemory: r.minMemRatio, v1.ResourceCPU: r.minCPURatio} {
+				ratio, ok := getLimitRequestRatio(container, resourceName)
+				if ok && minRatio > 0 && ratio < minRatio {
+					anomalies = append(anomalies, fmt.Sprintf("%s limit/request ratio of %.2f (minimum %.2f)", resourceName, ratio, minRatio))
+				}
+			}
+		}
+
+		msg := ""
+		if len(anomalies) > 0 {
+			msg = fmt.Sprintf("Container '%s' of pod '%s/%s' has restarted and has a %s, it might not survive load spikes", container.Name, pod.Namespace, pod.Name, strings.Join(anomalies, " and a "))
+		}
+		err := r.reportContainerProblem(pod, container.Name, problemTypePodResourceRatio, msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getLimitRequestRatio returns the ratio of the limit to the request of the container. It returns false if
+// the container has no request or no limit for the resource
+func getLimitRequestRatio(container v1.Container, resourceName v1.ResourceName) (float64, bool) {
+	request, ok := container.Resources.Requests[resourceName]
+	if !ok || request.IsZero() {
+		return 0, false
+	}
+
+	limit, ok := container.Resources.Limits[resourceName]
+	if !ok || limit.IsZero() {
+		return 0, false
+	}
+
+	return float64(limit.MilliValue()) / float64(request.MilliValue()), true
+}
+
+func getRestartCount(pod *v1.Pod, containerName string) int32 {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name == containerName {
+			return containerStatus.RestartCount
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckContainerRatiosResolve(t *testing.T) {
+	notifier := &recordingNotifier{}
+	r := newTestRunner(notifier)
+	r.thresholds = &ThresholdConfig{}
+	r.minMemRatio = defaultMinMemRatio
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name: "container",
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")},
+				Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")},
+			},
+		}}},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{Name: "container", RestartCount: 1}}},
+	}
+	err := r.checkContainerRatios(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.flushReports()
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected 1 alert for the restarted container with a limit equal to its request, got %d", len(notifier.alerts))
+	}
+
+	pod.Spec.Containers[0].Resources.Limits[v1.ResourceMemory] = resource.MustParse("256Mi")
+	err = r.checkContainerRatios(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.flushReports()
+	if len(r.problems) != 0 {
+		t.Fatal("Expected the problem to be resolved once the limit is raised")
+	} else if len(notifier.resolves) != 1 || notifier.resolves[0] != notifier.alerts[0] {
+		t.Fatalf("Expected the resolve message of %s, got %v", notifier.alerts[0], notifier.resolves)
+	}
+}
@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watchReconnectBaseBackoff is the backoff before the first reconnect after a watch broke, it is doubled for every
+// consecutive failed list or watch request up to WATCH_RECONNECT_MAX_BACKOFF
+const watchReconnectBaseBackoff = time.Second
+
+const defaultWatchReconnectMaxBackoff = time.Second * 60
+
+// errWatchClosed is the reason of a reconnect if the api server closed the watch
+var errWatchClosed = errors.New("watch closed by the api server")
+
+// listWatchDescriber is implemented by list watchers that describe the watched resource and namespace for the logs
+type listWatchDescriber interface {
+	describe() (string, string)
+}
+
+// informerHolder is implemented by list watchers that get the informer watchWithReconnect runs for them
+type informerHolder interface {
+	setInformer(informer cache.SharedIndexInformer)
+}
+
+// watchWithReconnect runs an informer for the objects of type T of the list watcher that calls the handler for every
+// change until ctx is done. If the watch breaks, the objects are listed and watched again with an exponential backoff.
+// An informerHolder gets the informer, e.g. to read the objects of its store
+func watchWithReconnect[T any](ctx context.Context, listWatcher cache.ListerWatcher, handler cache.ResourceEventHandlerFuncs) error {
+	exampleObject, ok := any(new(T)).(runtime.Object)
+	if !ok {
+		return fmt.Errorf("Error watching %T: not a kubernetes object", *new(T))
+	}
+
+	resource, namespace := fmt.Sprintf("%T", *new(T)), ""
+	if describer, ok := listWatcher.(listWatchDescriber); ok {
+		resource, namespace = describer.describe()
+	}
+
+	informer := cache.NewSharedIndexInformer(&reconnectListWatch{
+		ListerWatcher: listWatcher,
+
+		stop:       ctx.Done(),
+		resource:   resource,
+		namespace:  namespace,
+		maxBackoff: getDurationFromEnv("WATCH_RECONNECT_MAX_BACKOFF", defaultWatchReconnectMaxBackoff),
+	}, exampleObject, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	informer.AddEventHandler(handler)
+	if holder, ok := listWatcher.(informerHolder); ok {
+		holder.setInformer(informer)
+	}
+
+	informer.Run(ctx.Done())
+	return nil
+}
+
+// reconnectListWatch delays the list and watch requests of the informer after the previous watch broke or a request
+// failed. The informer lists and watches again on its own, this only adds the backoff and logs the reconnects
+type reconnectListWatch struct {
+	cache.ListerWatcher
+
+	stop       <-chan struct{}
+	resource   string
+	namespace  string
+	maxBackoff time.Duration
+
+	mutex    sync.Mutex
+	broken   error
+	failures int
+}
+
+func (l *reconnectListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	l.waitForReconnect()
+
+	list, err := l.ListerWatcher.List(options)
+	l.setResult(err)
+	return list, err
+}
+
+func (l *reconnectListWatch) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	l.waitForReconnect()
+
+	w, err := l.ListerWatcher.Watch(options)
+	l.setResult(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return newReportingWatch(w, l.setBroken), nil
+}
+
+// waitForReconnect waits for the backoff if the last watch broke or the last request failed
+func (l *reconnectListWatch) waitForReconnect() {
+	l.mutex.Lock()
+	broken, failures := l.broken, l.failures
+	l.broken = nil
+	l.mutex.Unlock()
+	if broken == nil {
+		return
+	}
+
+	backoff := l.getBackoff(failures)
+	if broken == errWatchClosed {
+		log.Debug("Watch closed, reconnecting", "resource", l.resource, "namespace", l.namespace, "retry_in", backoff)
+	} else {
+		log.Warn("Error watching resources, reconnecting", "resource", l.resource, "namespace", l.namespace, "attempt", failures+1, "retry_in", backoff, "error", broken)
+	}
+
+	select {
+	case <-l.stop:
+	case <-time.After(backoff):
+	}
+}
+
+// getBackoff returns the backoff after the given number of consecutive failed requests
+func (l *reconnectListWatch) getBackoff(failures int) time.Duration {
+	backoff := watchReconnectBaseBackoff
+	for i := 0; i < failures && backoff < l.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > l.maxBackoff {
+		backoff = l.maxBackoff
+	}
+
+	return backoff
+}
+
+// setResult counts consecutive failed requests, the backoff grows with every failure and is reset after a success
+func (l *reconnectListWatch) setResult(err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err == nil {
+		l.broken = nil
+		l.failures = 0
+		return
+	}
+
+	l.broken = err
+	l.failures++
+}
+
+func (l *reconnectListWatch) setBroken(err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.broken = err
+}
+
+// reportingWatch forwards the events of a watch and reports if the api server closed it or sent an error
+type reportingWatch struct {
+	watch.Interface
+
+	result   chan watch.Event
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func newReportingWatch(w watch.Interface, broken func(error)) *reportingWatch {
+	rw := &reportingWatch{
+		Interface: w,
+
+		result:  make(chan watch.Event),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(rw.result)
+		for {
+			select {
+			case <-rw.stopped:
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					select {
+					case <-rw.stopped:
+					default:
+						broken(errWatchClosed)
+					}
+					return
+				}
+				if event.Type == watch.Error {
+					broken(apierrors.FromObject(event.Object))
+				}
+
+				select {
+				case rw.result <- event:
+				case <-rw.stopped:
+					return
+				}
+			}
+		}
+	}()
+
+	return rw
+}
+
+func (rw *reportingWatch) ResultChan() <-chan watch.Event {
+	return rw.result
+}
+
+func (rw *reportingWatch) Stop() {
+	rw.stopOnce.Do(func() {
+		close(rw.stopped)
+		rw.Interface.Stop()
+	})
+}
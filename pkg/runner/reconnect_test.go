@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestPod(name string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", ResourceVersion: "1"}}
+}
+
+func TestWatchWithReconnect(t *testing.T) {
+	watches := make(chan *watch.FakeWatcher, 10)
+	listWatch := newResourceListWatch("pods", "default", func(options metav1.ListOptions) (runtime.Object, error) {
+		return &v1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: []v1.Pod{*newTestPod("a")}}, nil
+	}, func(options metav1.ListOptions) (watch.Interface, error) {
+		w := watch.NewFake()
+		watches <- w
+		return w, nil
+	})
+
+	added := make(chan string, 10)
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			added <- obj.(*v1.Pod).Name
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		done <- watchWithReconnect[v1.Pod](ctx, listWatch, handler)
+	}()
+
+	expectAdded := func(name string) {
+		select {
+		case got := <-added:
+			if got != name {
+				t.Fatalf("expected pod %s to be added, got %s", name, got)
+			}
+		case <-time.After(time.Second * 10):
+			t.Fatalf("pod %s was not added", name)
+		}
+	}
+	nextWatch := func() *watch.FakeWatcher {
+		select {
+		case w := <-watches:
+			return w
+		case <-time.After(time.Second * 10):
+			t.Fatal("the watch was not started")
+		}
+
+		return nil
+	}
+
+	expectAdded("a")
+	listWatch.WaitForSync(ctx.Done())
+	if objects := listWatch.Objects(); len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+
+	// Closing the watch channel simulates a broken watch, the pods are watched again afterwards
+	nextWatch().Stop()
+	nextWatch().Add(newTestPod("b"))
+	expectAdded("b")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second * 10):
+		t.Fatal("watchWithReconnect did not return after the context was cancelled")
+	}
+}
+
+func TestWatchWithReconnectInvalidType(t *testing.T) {
+	err := watchWithReconnect[string](context.Background(), &cache.ListWatch{}, cache.ResourceEventHandlerFuncs{})
+	if err == nil {
+		t.Fatal("expected an error for a type that is not a kubernetes object")
+	}
+}
+
+func TestReconnectListWatchBackoff(t *testing.T) {
+	errFailed := errors.New("connection refused")
+
+	tests := []struct {
+		name             string
+		results          []error
+		broken           error
+		expectedFailures int
+		expectBroken     bool
+	}{
+		{
+			name:    "success",
+			results: []error{nil},
+		},
+		{
+			name:             "consecutive failures",
+			results:          []error{errFailed, errFailed, errFailed},
+			expectedFailures: 3,
+			expectBroken:     true,
+		},
+		{
+			name:    "success after failures",
+			results: []error{errFailed, errFailed, nil},
+		},
+		{
+			name:         "closed watch",
+			results:      []error{nil},
+			broken:       errWatchClosed,
+			expectBroken: true,
+		},
+	}
+
+	for _, test := range tests {
+		l := &reconnectListWatch{maxBackoff: time.Millisecond}
+		for _, err := range test.results {
+			l.setResult(err)
+		}
+		if test.broken != nil {
+			l.setBroken(test.broken)
+		}
+
+		if l.failures != test.expectedFailures {
+			t.Fatalf("%s: expected %d failures, got %d", test.name, test.expectedFailures, l.failures)
+		}
+		if (l.broken != nil) != test.expectBroken {
+			t.Fatalf("%s: expected broken %v, got %v", test.name, test.expectBroken, l.broken)
+		}
+
+		// The next request waits for the backoff and starts a new watch
+		l.waitForReconnect()
+		if l.broken != nil {
+			t.Fatalf("%s: expected the reconnect to reset the broken watch", test.name)
+		}
+	}
+}
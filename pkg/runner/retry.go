@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const defaultAPIMaxRetries = 5
+const defaultAPIRetryBaseMs = 500
+const maxAPIRetryBackoff = time.Second * 30
+
+// withRetry calls fn until it succeeds, returns a permanent error or the maximum number of attempts is reached.
+// Between the attempts it waits an exponential backoff with full jitter, which is cut short when the runner is stopped
+func (r *Runner) withRetry(fn func() error) error {
+	policy := retry.Policy{Base: r.apiRetryBase, MaxBackoff: maxAPIRetryBackoff}
+
+	var err error
+	for attempt := 0; attempt < r.apiMaxRetries; attempt++ {
+		err = fn()
+		if err == nil || isPermanentError(err) {
+			return err
+		}
+
+		if attempt+1 < r.apiMaxRetries {
+			backoff := policy.Backoff(attempt)
+			log.Warn("Kubernetes api request failed, retrying", "attempt", attempt+1, "retry_in", backoff, "error", err)
+			if !retry.Wait(r.stop, backoff) {
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+// isPermanentError returns true for errors that won't go away by retrying
+func isPermanentError(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsNotFound(err)
+}
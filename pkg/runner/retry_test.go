@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRetryTransientErrors(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: "default"}})
+
+	// The api server fails twice before the list succeeds
+	attempts := 0
+	clientset.PrependReactor("list", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		attempts++
+		if attempts <= 2 {
+			return true, nil, apierrors.NewServiceUnavailable("api server is upgrading")
+		}
+
+		return false, nil, nil
+	})
+
+	r := &Runner{
+		client:        &fakeClient{clientset: clientset},
+		notifier:      &recordingNotifier{},
+		problems:      make(map[string]*problemDesc),
+		apiMaxRetries: 5,
+		apiRetryBase:  time.Millisecond,
+
+		namespaceSelector: fields.Everything(),
+	}
+
+	err := r.doWatchDeployments("default")
+	if err != nil {
+		t.Fatalf("Expected the list to succeed after two failures, got %v", err)
+	} else if attempts != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		expectedAttempts int
+	}{
+		{
+			name:             "forbidden",
+			err:              apierrors.NewForbidden(schema.GroupResource{Resource: "deployments"}, "", errors.New("no access")),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "not found",
+			err:              apierrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, "deployment"),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "transient",
+			err:              apierrors.NewServiceUnavailable("api server is upgrading"),
+			expectedAttempts: 3,
+		},
+	}
+
+	for _, test := range tests {
+		r := &Runner{apiMaxRetries: 3, apiRetryBase: time.Millisecond}
+
+		attempts := 0
+		err := r.withRetry(func() error {
+			attempts++
+			return test.err
+		})
+		if err != test.err {
+			t.Fatalf("%s: expected error %v, got %v", test.name, test.err, err)
+		} else if attempts != test.expectedAttempts {
+			t.Fatalf("%s: expected %d attempts, got %d", test.name, test.expectedAttempts, attempts)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	errTransient := errors.New("connection refused")
+	errForbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "test", errors.New("forbidden"))
+
+	tests := []struct {
+		name          string
+		errors        []error
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "success",
+			errors:        []error{nil},
+			expectedCalls: 1,
+		},
+		{
+			name:          "success after transient errors",
+			errors:        []error{errTransient, errTransient, nil},
+			expectedCalls: 3,
+		},
+		{
+			name:          "permanent error",
+			errors:        []error{errForbidden, nil},
+			expectedCalls: 1,
+			expectedErr:   errForbidden,
+		},
+		{
+			name:          "attempts exhausted",
+			errors:        []error{errTransient, errTransient, errTransient, errTransient},
+			expectedCalls: 3,
+			expectedErr:   errTransient,
+		},
+	}
+
+	for _, test := range tests {
+		r := &Runner{apiMaxRetries: 3, apiRetryBase: time.Millisecond}
+
+		calls := 0
+		err := r.withRetry(func() error {
+			err := test.errors[calls]
+			calls++
+			return err
+		})
+
+		if calls != test.expectedCalls {
+			t.Fatalf("%s: expected %d calls, got %d", test.name, test.expectedCalls, calls)
+		}
+		if err != test.expectedErr {
+			t.Fatalf("%s: expected error %v, got %v", test.name, test.expectedErr, err)
+		}
+	}
+}
+
+func TestWithRetryStopped(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+
+	r := &Runner{apiMaxRetries: 5, apiRetryBase: time.Hour, stop: stop}
+
+	calls := 0
+	done := make(chan error)
+	go func() {
+		done <- r.withRetry(func() error {
+			calls++
+			return errors.New("connection refused")
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if calls != 1 {
+			t.Fatalf("expected 1 call, got %d", calls)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("withRetry did not return after the runner was stopped")
+	}
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+)
+
+// parseSlackRouting parses a semicolon separated list of problemType=channel or severity=channel rules into the
+// channels per problem type and per severity
+func parseSlackRouting(value string) (map[problemType]string, map[severity]string, error) {
+	problemTypes := make(map[problemType]string)
+	severities := make(map[severity]string)
+	for _, rule := range strings.Split(value, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		splitted := strings.Split(rule, "=")
+		if len(splitted) != 2 || strings.TrimSpace(splitted[0]) == "" || strings.TrimSpace(splitted[1]) == "" {
+			return nil, nil, fmt.Errorf("invalid routing rule '%s' (expected problemType=channel or severity=channel)", rule)
+		}
+
+		key, channel := strings.TrimSpace(splitted[0]), strings.TrimSpace(splitted[1])
+		if _, ok := defaultSeverities[problemType(key)]; ok {
+			problemTypes[problemType(key)] = channel
+			continue
+		}
+
+		switch severity(key) {
+		case severityCritical, severityWarning, severityInfo:
+			severities[severity(key)] = channel
+		default:
+			return nil, nil, fmt.Errorf("invalid routing rule '%s' (%s is neither a problem type nor a severity)", rule, key)
+		}
+	}
+
+	return problemTypes, severities, nil
+}
+
+// ValidateSlackRouting returns an error if the value is not a valid list of slack routing rules
+func ValidateSlackRouting(value string) error {
+	_, _, err := parseSlackRouting(value)
+	return err
+}
+
+// newSlackRoutingFromEnv returns the channels per problem type and per severity of SLACK_ROUTING
+func newSlackRoutingFromEnv() (map[problemType]string, map[severity]string, error) {
+	problemTypes, severities, err := parseSlackRouting(os.Getenv("SLACK_ROUTING"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing SLACK_ROUTING: %v", err)
+	}
+
+	for problemType, channel := range problemTypes {
+		log.Info("Routing alerts to slack channel", "problem_type", string(problemType), "channel", channel)
+	}
+	for severity, channel := range severities {
+		log.Info("Routing alerts to slack channel", "severity", string(severity), "channel", channel)
+	}
+
+	return problemTypes, severities, nil
+}
+
+// getSlackChannel returns the slack channel the alerts of the problem are routed to. Problem type rules take
+// precedence over severity rules, an empty channel means the default SLACK_CHANNEL
+func (r *Runner) getSlackChannel(problem *problemDesc) string {
+	if channel, ok := r.slackRouting[problem.problemType]; ok {
+		return channel
+	}
+
+	return r.slackSeverityRouting[problem.severity]
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"testing"
+)
+
+func TestParseSlackRouting(t *testing.T) {
+	tests := []struct {
+		value                string
+		expectedProblemTypes map[problemType]string
+		expectedSeverities   map[severity]string
+		expectErr            bool
+	}{
+		{
+			value: "",
+		},
+		{
+			value:                "NodeCondition=#infra; PodRestarts=#app;critical=#oncall",
+			expectedProblemTypes: map[problemType]string{problemTypeNodeCondition: "#infra", problemTypePodRestarts: "#app"},
+			expectedSeverities:   map[severity]string{severityCritical: "#oncall"},
+		},
+		{
+			value:     "NodeCondition",
+			expectErr: true,
+		},
+		{
+			value:     "NodeCondition=",
+			expectErr: true,
+		},
+		{
+			value:     "NodeConditions=#infra",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		problemTypes, severities, err := parseSlackRouting(test.value)
+		if (err != nil) != test.expectErr {
+			t.Fatalf("%s: unexpected error %v", test.value, err)
+		}
+		if test.expectErr {
+			continue
+		}
+
+		if len(problemTypes) != len(test.expectedProblemTypes) || len(severities) != len(test.expectedSeverities) {
+			t.Fatalf("%s: expected %v and %v, got %v and %v", test.value, test.expectedProblemTypes, test.expectedSeverities, problemTypes, severities)
+		}
+		for problemType, channel := range test.expectedProblemTypes {
+			if problemTypes[problemType] != channel {
+				t.Fatalf("%s: expected channel %s for %s, got %s", test.value, channel, problemType, problemTypes[problemType])
+			}
+		}
+		for severity, channel := range test.expectedSeverities {
+			if severities[severity] != channel {
+				t.Fatalf("%s: expected channel %s for %s, got %s", test.value, channel, severity, severities[severity])
+			}
+		}
+	}
+}
+
+func TestGetSlackChannel(t *testing.T) {
+	r := &Runner{
+		slackRouting:         map[problemType]string{problemTypeNodeCondition: "#infra"},
+		slackSeverityRouting: map[severity]string{severityCritical: "#oncall"},
+	}
+
+	tests := []struct {
+		problem         *problemDesc
+		expectedChannel string
+	}{
+		{
+			problem:         &problemDesc{problemType: problemTypeNodeCondition, severity: severityCritical},
+			expectedChannel: "#infra",
+		},
+		{
+			problem:         &problemDesc{problemType: problemTypePodStatus, severity: severityCritical},
+			expectedChannel: "#oncall",
+		},
+		{
+			problem: &problemDesc{problemType: problemTypePodRestarts, severity: severityWarning},
+		},
+	}
+
+	for _, test := range tests {
+		if channel := r.getSlackChannel(test.problem); channel != test.expectedChannel {
+			t.Fatalf("%s: expected channel %q, got %q", test.problem.problemType, test.expectedChannel, channel)
+		}
+	}
+}
@@ -1,29 +1,109 @@
 package runner
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/FabianKramm/kube-problem/pkg/api"
 	"github.com/FabianKramm/kube-problem/pkg/kube"
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/maintenance"
 	"github.com/FabianKramm/kube-problem/pkg/metrics"
-	"github.com/FabianKramm/kube-problem/pkg/slack"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+	"github.com/FabianKramm/kube-problem/pkg/state"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const defaultInterval = time.Second * 60
 const reportInterval = time.Minute * 60
 
+const defaultNodeCPUThreshold = 0.95
+const defaultNodeMemThreshold = 0.95
+const defaultNodeDiskThreshold = 0.90
+const defaultNodeAllocatableSkewThreshold = 0.4
+const defaultQuotaAlertThreshold = 0.85
+
+const defaultDeploymentStallTimeout = time.Minute * 5
+const defaultPVCPendingTimeout = time.Minute * 5
+const defaultStatefulSetDegradedTimeout = time.Minute * 5
+const defaultDaemonSetUnavailableTimeout = time.Minute * 2
+const defaultHPAAtMaxTimeout = time.Minute * 10
+const defaultPodTerminationBuffer = time.Second * 60
+const defaultPodUnreadyTimeout = time.Minute * 2
+const defaultNodeHeartbeatTimeout = time.Minute * 5
+const defaultCronJobMaxDuration = time.Hour
+const defaultNamespaceTerminatingTimeout = time.Minute * 10
+
+const defaultAcknowledgeDuration = time.Hour
+
+const defaultNamespaceWorkers = 5
+
+// minThreadUpdateInterval is the minimum time between two updates in the thread of a problem
+const minThreadUpdateInterval = time.Minute * 10
+
+// IgnoreAnnotation can be set to "true" on a watched resource to suppress all alerts for it
+const IgnoreAnnotation = "kube-problem/ignore"
+
 type problemType string
 
 const (
 	problemTypeNodeCondition        problemType = "NodeCondition"
 	problemTypeNodeResourcePressure problemType = "NodeResourcePressure"
+	problemTypeNodeDiskPressure     problemType = "NodeDiskPressure"
+	problemTypeNodeHeartbeatStale   problemType = "NodeHeartbeatStale"
+	problemTypeNodeAllocatableSkew  problemType = "NodeAllocatableSkew"
+	problemTypeNodeFlapping         problemType = "NodeFlapping"
 
 	problemTypePodStatus   problemType = "PodStatus"
 	problemTypePodRestarts problemType = "PodRestarts"
 	problemTypePodPending  problemType = "PodPending"
+	problemTypePodOOMKill  problemType = "PodOOMKill"
+	problemTypePodNoLimits problemType = "PodNoLimits"
+	problemTypePodNoProbe  problemType = "PodNoProbe"
+
+	problemTypePodLatestTag problemType = "PodLatestTag"
+
+	problemTypePodStuckTerminating problemType = "PodStuckTerminating"
+	problemTypeCPUThrottling       problemType = "CPUThrottling"
+	problemTypePodResourceRatio    problemType = "PodResourceRatio"
+	problemTypePodOnNotReadyNode   problemType = "PodOnNotReadyNode"
+	problemTypePodUnready          problemType = "PodUnready"
+
+	problemTypeDeploymentStall      problemType = "DeploymentStall"
+	problemTypeDeploymentRollback   problemType = "DeploymentRollback"
+	problemTypeStatefulSetDegraded  problemType = "StatefulSetDegraded"
+	problemTypeDaemonSetUnavailable problemType = "DaemonSetUnavailable"
+	problemTypeHPAAtMax             problemType = "HPAAtMax"
+	problemTypeJobFailed            problemType = "JobFailed"
+	problemTypeCronJobMissed        problemType = "CronJobMissed"
+	problemTypeCronJobStuck         problemType = "CronJobStuck"
+
+	problemTypePVCPending problemType = "PVCPending"
+
+	problemTypeQuotaExhaustion problemType = "QuotaExhaustion"
+
+	problemTypeCRDStatus problemType = "CRDStatus"
+
+	problemTypeNoReadyEndpoints problemType = "NoReadyEndpoints"
+
+	problemTypeCertExpiry problemType = "CertExpiry"
+
+	problemTypeWarningEvent problemType = "WarningEvent"
+
+	problemTypeNamespaceStuck problemType = "NamespaceStuck"
+
+	problemTypeAlertmanagerAlert problemType = "AlertmanagerAlert"
 )
 
 type resourceKind string
@@ -31,22 +111,190 @@ type resourceKind string
 const (
 	resourceKindPod  resourceKind = "Pod"
 	resourceKindNode resourceKind = "Node"
+
+	resourceKindDeployment  resourceKind = "Deployment"
+	resourceKindStatefulSet resourceKind = "StatefulSet"
+	resourceKindDaemonSet   resourceKind = "DaemonSet"
+	resourceKindHPA         resourceKind = "HorizontalPodAutoscaler"
+	resourceKindJob         resourceKind = "Job"
+	resourceKindCronJob     resourceKind = "CronJob"
+
+	resourceKindPVC resourceKind = "PersistentVolumeClaim"
+
+	resourceKindResourceQuota resourceKind = "ResourceQuota"
+
+	resourceKindService resourceKind = "Service"
+	resourceKindSecret  resourceKind = "Secret"
+
+	resourceKindNamespace resourceKind = "Namespace"
+
+	resourceKindAlert resourceKind = "Alert"
 )
 
 // Runner is continously checking for problems in a cluster
 type Runner struct {
 	client        kube.Client
 	metricsClient *metrics.Client
-	slackClient   *slack.Client
+	notifier      notify.Notifier
+
+	// checkResourceLimits reports containers without cpu or memory limits
+	checkResourceLimits bool
+	// checkMissingProbes reports containers without liveness or readiness probes in the probeCheckNamespaces (all if empty)
+	checkMissingProbes   bool
+	probeCheckNamespaces map[string]bool
+	// checkResourceRatios reports restarted containers whose limit is less than minMemRatio (minCPURatio) times the request
+	checkResourceRatios bool
+	minMemRatio         float64
+	minCPURatio         float64
+	// checkLatestTag reports containers whose image uses the latest tag
+	checkLatestTag bool
+	// checkTLSSecrets reports expiring certificates of tls secrets, which requires the permission to read secrets
+	checkTLSSecrets bool
+	// includePodLogs adds the last lines of the log of crashed containers to CrashLoopBackOff alerts
+	includePodLogs bool
+	podLogLines    int
+
+	// criticalPriorityClasses are the priority classes of pods whose problems are critical
+	criticalPriorityClasses map[string]bool
+
+	// watchEventReasons are the reasons of the warning events that are reported
+	watchEventReasons map[string]bool
+
+	// optInAnnotation restricts the checked pods to pods that have the annotation set to optInValue if not empty
+	optInAnnotation string
+	optInValue      string
 
-	watchNodes      bool
+	// dryRun only logs the messages instead of sending them
+	dryRun bool
+	// checkOnce runs a single check cycle, which reports all found problems regardless of thresholds and quiet periods
+	checkOnce bool
+
+	// alertBatching sends the reports of a check cycle grouped by problem type at the end of the cycle
+	alertBatching bool
+	// slackThreads sends changes of reported problems as replies in the thread of their alert
+	slackThreads bool
+	// resolveInThread sends resolve messages as replies in the thread of the alert if the problem has a thread
+	resolveInThread bool
+	batchedReports  []*problemDesc
+
+	watchNodes bool
+	// nodeGroupLabel groups the alerts of node problems of the same type by the value of this node label
+	nodeGroupLabel  string
 	watchNamespaces []string
+	// namespaceSelector excludes the excluded namespaces if all namespaces are watched
+	namespaceSelector  fields.Selector
+	excludedNamespaces map[string]bool
+	// watchNamespaceStatus reports watched namespaces that are stuck in terminating
+	watchNamespaceStatus bool
+	namespaceWorkers     int
+
+	// watchCRDs are the custom resources that are checked for a Ready=False condition
+	watchCRDs []schema.GroupVersionResource
+
+	// apiMaxRetries is the number of attempts for kubernetes api requests
+	apiMaxRetries int
+	apiRetryBase  time.Duration
+	// stop is closed when the runner is stopped and aborts waiting for retries
+	stop <-chan struct{}
+
+	// namespaceIntervals overwrites the check interval of single namespaces
+	namespaceIntervals map[string]time.Duration
+	lastChecked        map[string]time.Time
+	lastNodesChecked   time.Time
+
+	nodeCPUThreshold  float64
+	nodeMemThreshold  float64
+	nodeDiskThreshold float64
+	// nodeAllocatableSkewThreshold is the ratio of a node's memory capacity that may be reserved (not allocatable)
+	nodeAllocatableSkewThreshold float64
+	quotaAlertThreshold          float64
+	certWarnDays                 int
+	thresholds                   *ThresholdConfig
+	// resolveThresholds is the number of consecutive checks a problem has to be gone before it is resolved
+	resolveThresholds map[problemType]int
+	severities        map[problemType]severity
+
+	// slackRouting and slackSeverityRouting hold the slack channels alerts of certain problem types and severities
+	// are sent to instead of SLACK_CHANNEL
+	slackRouting         map[problemType]string
+	slackSeverityRouting map[severity]string
+	// controlPlaneSeverity overwrites the severity of problems of control plane nodes if not empty
+	controlPlaneSeverity severity
+
+	deploymentStallTimeout      time.Duration
+	statefulSetDegradedTimeout  time.Duration
+	daemonSetUnavailableTimeout time.Duration
+	hpaAtMaxTimeout             time.Duration
+	pvcPendingTimeout           time.Duration
+	podTerminationBuffer        time.Duration
+	podUnreadyTimeout           time.Duration
+	nodeHeartbeatTimeout        time.Duration
+	cronJobMaxDuration          time.Duration
+	namespaceTerminatingTimeout time.Duration
+
+	// nodeWatch and podWatches keep the watched nodes and the pods of the watched namespaces up to date
+	nodeWatch  *resourceListWatch
+	podWatches map[string]*resourceListWatch
 
-	problems map[string]*problemDesc
+	// nodeReadyStatus holds the ready status of the watched nodes of the last node check or node update
+	nodeReadyStatus      map[string]bool
+	nodeReadyStatusMutex sync.RWMutex
+
+	// nodeConditionHistory holds the times of the Ready to NotReady transitions per node within the nodeFlapWindow,
+	// more than nodeFlapCount transitions are reported as flapping
+	nodeConditionHistory      map[string][]time.Time
+	nodeConditionHistoryMutex sync.Mutex
+	nodeFlapCount             int
+	nodeFlapWindow            time.Duration
+
+	// oomKillRates counts the oomkills per pod, more than oomKillRateThreshold oomkills within the oomKillRateWindow
+	// are critical
+	oomKillRates         map[string]*oomKillRate
+	oomKillRatesMutex    sync.Mutex
+	oomKillRateThreshold int
+	oomKillRateWindow    time.Duration
+
+	// deploymentRevisions is the last seen revision per deployment and deploymentTemplateRevisions the revision
+	// per pod template hash, which are used to detect rollbacks
+	deploymentRevisions         map[string]string
+	deploymentTemplateRevisions map[string]map[string]string
+	deploymentRevisionsMutex    sync.Mutex
+
+	// problems is accessed concurrently by the namespace workers, the informer event handlers and the api
+	problems      map[string]*problemDesc
+	problemsMutex sync.RWMutex
+	// notifications are queued with the problems mutex locked and sent after it was released
+	notifications []notification
+
+	// history holds the last resolved problems
+	history    *problemHistory
+	stateStore *state.Store
+	digest     *digest
+
+	// quietPeriod is the time after a reported problem was resolved in which it is not alerted again
+	quietPeriod map[problemType]time.Duration
+	// problemTTL is the time after which a problem is removed, so it is alerted again if it still exists
+	problemTTL map[problemType]time.Duration
+	quietUntil map[string]time.Time
+
+	// acknowledged holds the problem ids that should not be alerted until the given time
+	acknowledged         map[string]time.Time
+	acknowledgedMutex    sync.Mutex
+	acknowledgedDuration time.Duration
+
+	// maintenanceWindows suppress all alerts while they are active
+	maintenanceWindows maintenance.Windows
+	inMaintenance      bool
+
+	// ready is set to 1 after the first check cycle completed
+	ready int32
+	// cronJobsUnsupported is set to 1 if the cluster does not serve batch/v1 cron jobs
+	cronJobsUnsupported int32
 }
 
 type problemDesc struct {
 	problemType problemType
+	severity    severity
 	kind        resourceKind
 	name        string
 	namespace   string
@@ -59,26 +307,141 @@ type problemDesc struct {
 
 	reported bool
 	occured  time.Time
+
+	// reportAfter is the time the problem has to exist before it is reported
+	reportAfter time.Duration
+
+	// threadTS is the slack thread of the alert if slack threads are enabled
+	threadTS   string
+	lastUpdate time.Time
+
+	// correlatedWith are the problems of the node of a pod problem or the pod problems of a node problem,
+	// which are updated after every check cycle
+	correlatedWith []*problemDesc
+
+	// container is the crashed container of a CrashLoopBackOff problem, the snippet of its log is added to the
+	// alert if pod logs are included
+	container string
+
+	// priority and priorityClassName are the priority of the pod of a pod problem
+	priority          int32
+	priorityClassName string
+
+	// nodeGroup is the value of the node group label of the node of a node problem
+	nodeGroup string
+
+	// eventNamespace is the namespace of the event a problem was found by, which is resolved by the event check of
+	// this namespace once the event does not occur anymore. It is cleared when a check finds the same problem
+	eventNamespace string
+}
+
+func isIgnored(obj metav1.Object) bool {
+	return obj.GetAnnotations()[IgnoreAnnotation] == "true"
+}
+
+// parseOptInAnnotation parses an annotation in the form key=value or key: value, the value defaults to "true"
+func parseOptInAnnotation(value string) (string, string) {
+	splitted := strings.SplitN(value, "=", 2)
+	if len(splitted) == 1 {
+		splitted = strings.SplitN(value, ":", 2)
+	}
+	if len(splitted) == 1 {
+		return strings.TrimSpace(value), "true"
+	}
+
+	return strings.TrimSpace(splitted[0]), strings.Trim(strings.TrimSpace(splitted[1]), `"'`)
+}
+
+func (p *problemDesc) metricLabels() []string {
+	return []string{string(p.problemType), string(p.kind), p.namespace, p.name}
+}
+
+// logFields returns the structured log fields of the problem
+func (p *problemDesc) logFields() []interface{} {
+	return []interface{}{"problem_id", p.id, "problem_type", string(p.problemType), "severity", string(p.severity), "resource_kind", string(p.kind), "resource_name", p.name, "namespace", p.namespace, "message", p.message}
+}
+
+func (p *problemDesc) toAPIProblem() *api.Problem {
+	return &api.Problem{
+		ID:        p.id,
+		Type:      string(p.problemType),
+		Severity:  string(p.severity),
+		Kind:      string(p.kind),
+		Name:      p.name,
+		Namespace: p.namespace,
+
+		Message: p.message,
+		Occured: p.occured,
+
+		Reported:        p.reported,
+		OccuredCounter:  p.occuredCounter,
+		ResolvedCounter: p.resolvedCounter,
+	}
+}
+
+func (p *problemDesc) toNotifyProblem() notify.Problem {
+	return notify.Problem{
+		ID:        p.id,
+		Type:      string(p.problemType),
+		Severity:  string(p.severity),
+		Kind:      string(p.kind),
+		Name:      p.name,
+		Namespace: p.namespace,
+		NodeGroup: p.nodeGroup,
+
+		Message: p.message,
+		Occured: p.occured,
+
+		Reported:        p.reported,
+		OccuredCounter:  p.occuredCounter,
+		ResolvedCounter: p.resolvedCounter,
+	}
 }
 
-// NewRunner creates a new runner
-func NewRunner(client kube.Client, slackClient *slack.Client, watchNodes bool, watchNamespaces []string) (*Runner, error) {
+// NewRunner creates a new runner. If watchNamespaces only contains metav1.NamespaceAll, all namespaces except the excludedNamespaces are watched.
+// Only nodes matching the nodeSelector and pods matching the podSelector are checked. If stateStore is not nil, the problems are persisted across restarts. No alerts are sent during the maintenanceWindows
+func NewRunner(client kube.Client, notifier notify.Notifier, watchNodes bool, watchNamespaces, excludedNamespaces []string, nodeSelector, podSelector labels.Selector, stateStore *state.Store, maintenanceWindows maintenance.Windows) (*Runner, error) {
 	metricsClient, err := metrics.NewMetricsClient(client)
 	if err != nil {
 		return nil, err
 	}
 
+	var nodeWatch *resourceListWatch
 	if watchNodes {
 		// Check if we can access nodes
-		_, err := client.Client().CoreV1().Nodes().List(metav1.ListOptions{})
+		_, err := client.Client().CoreV1().Nodes().List(metav1.ListOptions{LabelSelector: nodeSelector.String()})
 		if err != nil {
 			return nil, fmt.Errorf("Error retrieving nodes: %v", err)
 		}
 
-		log.Println("Watching nodes")
+		nodeWatch = newNodeListWatch(client, nodeSelector)
+
+		if nodeSelector.Empty() {
+			log.Info("Watching nodes")
+		} else {
+			log.Info("Watching nodes with matching labels", "selector", nodeSelector.String())
+		}
 	}
 
-	if len(watchNamespaces) > 0 {
+	podWatches := make(map[string]*resourceListWatch)
+	namespaceSelector := fields.Everything()
+	excluded := make(map[string]bool)
+	for _, namespace := range excludedNamespaces {
+		excluded[namespace] = true
+	}
+	if len(watchNamespaces) == 1 && watchNamespaces[0] == metav1.NamespaceAll {
+		namespaceSelector = newNamespaceSelector(excludedNamespaces)
+		podWatches[metav1.NamespaceAll] = newPodListWatch(client, metav1.NamespaceAll, podSelector, namespaceSelector)
+		if len(excludedNamespaces) == 0 {
+			log.Info("Watching mode: all namespaces")
+		} else {
+			log.Info("Watching mode: all namespaces", "excluded", strings.Join(excludedNamespaces, ","))
+		}
+	} else if len(excludedNamespaces) > 0 {
+		return nil, fmt.Errorf("Excluded namespaces (%s) are only supported when all namespaces are watched", strings.Join(excludedNamespaces, ","))
+	} else if len(watchNamespaces) > 0 {
+		log.Info("Watching mode: specific namespaces", "namespaces", strings.Join(watchNamespaces, ","))
+
 		// Check if namespaces exist
 		for _, namespace := range watchNamespaces {
 			_, err := client.Client().CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
@@ -86,32 +449,314 @@ func NewRunner(client kube.Client, slackClient *slack.Client, watchNodes bool, w
 				return nil, fmt.Errorf("Error retrieving namespace %s: %v", namespace, err)
 			}
 
-			log.Printf("Watching namespace: %s", namespace)
+			podWatches[namespace] = newPodListWatch(client, namespace, podSelector, fields.Everything())
+			log.Info("Watching namespace", "namespace", namespace)
+		}
+	}
+
+	optInAnnotation, optInValue := parseOptInAnnotation(os.Getenv("OPT_IN_ANNOTATION"))
+	if optInAnnotation != "" {
+		log.Info("Only checking pods with opt in annotation", "annotation", optInAnnotation, "value", optInValue)
+	}
+
+	namespaceIntervals := getNamespaceIntervalsFromEnv("NAMESPACE_INTERVALS")
+	for namespace, interval := range namespaceIntervals {
+		if podWatches[namespace] == nil {
+			log.Warn("Ignoring interval for namespace, because it is not watched separately (add it to WATCH_NAMESPACES)", "namespace", namespace)
+			delete(namespaceIntervals, namespace)
+			continue
 		}
+
+		log.Info("Using custom check interval for namespace", "namespace", namespace, "interval", interval)
+	}
+
+	slackRouting, slackSeverityRouting, err := newSlackRoutingFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	watchCRDs, err := parseWatchCRDs(os.Getenv("WATCH_CRDS"))
+	if err != nil {
+		return nil, err
+	}
+	for _, gvr := range watchCRDs {
+		log.Info("Watching custom resources", "resource", gvr.String())
+	}
+
+	dryRun := os.Getenv("DRY_RUN") == "true"
+	if dryRun {
+		log.Info("Dry run enabled, alerts are only logged")
 	}
 
-	return &Runner{
+	runner := &Runner{
 		client:        client,
 		metricsClient: metricsClient,
-		slackClient:   slackClient,
+		notifier:      notifier,
+
+		checkResourceLimits:  os.Getenv("CHECK_RESOURCE_LIMITS") == "true",
+		checkMissingProbes:   os.Getenv("CHECK_MISSING_PROBES") == "true",
+		probeCheckNamespaces: parseProbeCheckNamespaces(os.Getenv("PROBE_CHECK_NAMESPACES")),
+		checkResourceRatios:  os.Getenv("CHECK_RESOURCE_RATIOS") == "true",
+		minMemRatio:          getFactorFromEnv("MIN_MEM_RATIO", defaultMinMemRatio),
+		minCPURatio:          getFactorFromEnv("MIN_CPU_RATIO", 0),
+		checkLatestTag:       os.Getenv("CHECK_LATEST_TAG") == "true",
+		checkTLSSecrets:      os.Getenv("CHECK_TLS_SECRETS") == "true",
+		includePodLogs:       os.Getenv("INCLUDE_POD_LOGS") == "true",
+		podLogLines:          getCountFromEnv("POD_LOG_LINES", defaultPodLogLines),
+
+		watchEventReasons: getWatchEventReasonsFromEnv(),
+
+		criticalPriorityClasses: getCriticalPriorityClassesFromEnv(),
+
+		optInAnnotation: optInAnnotation,
+		optInValue:      optInValue,
+
+		dryRun:          dryRun,
+		checkOnce:       os.Getenv("CHECK_ONCE") == "true",
+		alertBatching:   os.Getenv("ALERT_BATCHING") == "true",
+		slackThreads:    os.Getenv("SLACK_THREADS") == "true",
+		resolveInThread: os.Getenv("RESOLVE_IN_THREAD") == "true",
+
+		watchNodes:           watchNodes,
+		nodeGroupLabel:       os.Getenv("NODE_GROUP_LABEL"),
+		watchNamespaces:      watchNamespaces,
+		namespaceSelector:    namespaceSelector,
+		excludedNamespaces:   excluded,
+		watchNamespaceStatus: os.Getenv("WATCH_NAMESPACE_STATUS") == "true",
+		namespaceWorkers:     getCountFromEnv("NAMESPACE_WORKERS", defaultNamespaceWorkers),
+		watchCRDs:            watchCRDs,
+
+		apiMaxRetries: getCountFromEnv("API_MAX_RETRIES", defaultAPIMaxRetries),
+		apiRetryBase:  time.Duration(getCountFromEnv("API_RETRY_BASE_MS", defaultAPIRetryBaseMs)) * time.Millisecond,
+
+		namespaceIntervals: namespaceIntervals,
+		lastChecked:        make(map[string]time.Time),
+
+		nodeCPUThreshold:             getRatioFromEnv("NODE_CPU_THRESHOLD", defaultNodeCPUThreshold),
+		nodeMemThreshold:             getRatioFromEnv("NODE_MEM_THRESHOLD", defaultNodeMemThreshold),
+		nodeDiskThreshold:            getRatioFromEnv("NODE_DISK_THRESHOLD", defaultNodeDiskThreshold),
+		nodeAllocatableSkewThreshold: getRatioFromEnv("NODE_ALLOCATABLE_SKEW_THRESHOLD", defaultNodeAllocatableSkewThreshold),
+		quotaAlertThreshold:          getRatioFromEnv("QUOTA_ALERT_THRESHOLD", defaultQuotaAlertThreshold),
+		certWarnDays:                 getCountFromEnv("CERT_WARN_DAYS", defaultCertWarnDays),
+		oomKillRates:                 make(map[string]*oomKillRate),
+		nodeConditionHistory:         make(map[string][]time.Time),
+		nodeFlapCount:                getCountFromEnv("NODE_FLAP_COUNT", defaultNodeFlapCount),
+		nodeFlapWindow:               getDurationFromEnv("NODE_FLAP_WINDOW", defaultNodeFlapWindow),
+		deploymentRevisions:          make(map[string]string),
+		deploymentTemplateRevisions:  make(map[string]map[string]string),
+		oomKillRateThreshold:         getCountFromEnv("OOMKILL_RATE_THRESHOLD", defaultOOMKillRateThreshold),
+		oomKillRateWindow:            getDurationFromEnv("OOMKILL_RATE_WINDOW", defaultOOMKillRateWindow),
+		thresholds:                   NewThresholdConfigFromEnv(),
+		resolveThresholds:            newResolveThresholdsFromEnv(),
+		severities:                   newSeveritiesFromEnv(),
+		slackRouting:                 slackRouting,
+		slackSeverityRouting:         slackSeverityRouting,
+		controlPlaneSeverity:         getControlPlaneSeverityFromEnv(),
+
+		deploymentStallTimeout:      getDurationFromEnv("DEPLOYMENT_STALL_TIMEOUT", defaultDeploymentStallTimeout),
+		statefulSetDegradedTimeout:  getDurationFromEnv("STATEFULSET_DEGRADED_TIMEOUT", defaultStatefulSetDegradedTimeout),
+		daemonSetUnavailableTimeout: getDurationFromEnv("DAEMONSET_UNAVAIL_TIMEOUT", defaultDaemonSetUnavailableTimeout),
+		hpaAtMaxTimeout:             getDurationFromEnv("HPA_AT_MAX_TIMEOUT", defaultHPAAtMaxTimeout),
+		pvcPendingTimeout:           getDurationFromEnv("PVC_PENDING_TIMEOUT", defaultPVCPendingTimeout),
+		podTerminationBuffer:        getDurationFromEnv("POD_TERMINATION_BUFFER", defaultPodTerminationBuffer),
+		podUnreadyTimeout:           getDurationFromEnv("POD_UNREADY_TIMEOUT", defaultPodUnreadyTimeout),
+		nodeHeartbeatTimeout:        getDurationFromEnv("NODE_HEARTBEAT_TIMEOUT", defaultNodeHeartbeatTimeout),
+		cronJobMaxDuration:          getDurationFromEnv("CRONJOB_MAX_DURATION", defaultCronJobMaxDuration),
+		namespaceTerminatingTimeout: getDurationFromEnv("NS_TERMINATING_TIMEOUT", defaultNamespaceTerminatingTimeout),
+
+		nodeWatch:  nodeWatch,
+		podWatches: podWatches,
+
+		nodeReadyStatus: make(map[string]bool),
+
+		problems:   make(map[string]*problemDesc),
+		history:    newProblemHistory(getCountFromEnv("PROBLEM_HISTORY_SIZE", defaultProblemHistorySize)),
+		stateStore: stateStore,
+		digest:     newDigestFromEnv(),
+
+		quietPeriod: newQuietPeriodsFromEnv(),
+		problemTTL:  newProblemTTLsFromEnv(),
+		quietUntil:  make(map[string]time.Time),
+
+		acknowledged:         make(map[string]time.Time),
+		acknowledgedDuration: getDurationFromEnv("ACKNOWLEDGE_DURATION", defaultAcknowledgeDuration),
+
+		maintenanceWindows: maintenanceWindows,
+	}
+
+	err = runner.validateProblemTTLs()
+	if err != nil {
+		return nil, err
+	}
+
+	return runner, nil
+}
+
+// newNamespaceSelector returns a field selector that excludes the given namespaces
+func newNamespaceSelector(excludedNamespaces []string) fields.Selector {
+	selectors := []fields.Selector{}
+	for _, namespace := range excludedNamespaces {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.namespace", namespace))
+	}
+
+	return fields.AndSelectors(selectors...)
+}
+
+// listOptions returns the list options for the namespace checks, which exclude the excluded namespaces and
+// additionally filter by the given field selectors
+func (r *Runner) listOptions(selectors ...fields.Selector) metav1.ListOptions {
+	terms := []string{}
+	for _, selector := range append([]fields.Selector{r.namespaceSelector}, selectors...) {
+		if !selector.Empty() {
+			terms = append(terms, selector.String())
+		}
+	}
+
+	return metav1.ListOptions{FieldSelector: strings.Join(terms, ",")}
+}
+
+// listResources lists the resources of the namespace with the dynamic client and converts them into the typed list.
+// This is used for api versions the vendored clientset does not support, the typed list has to be of an older
+// version with the same schema
+func (r *Runner) listResources(gvr schema.GroupVersionResource, namespace string, into runtime.Object) error {
+	return r.withRetry(func() error {
+		list, err := r.client.Dynamic().Resource(gvr).Namespace(namespace).List(r.listOptions())
+		if err != nil {
+			return err
+		}
+
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(list.UnstructuredContent(), into)
+	})
+}
+
+// Acknowledge suppresses alerts for the given problem id for the configured acknowledge duration
+func (r *Runner) Acknowledge(problemID string) error {
+	r.acknowledgedMutex.Lock()
+	defer r.acknowledgedMutex.Unlock()
+
+	r.acknowledged[problemID] = time.Now().Add(r.acknowledgedDuration)
+	log.Info("Problem acknowledged", "problem_id", problemID, "duration", r.acknowledgedDuration)
+	return nil
+}
+
+func (r *Runner) isAcknowledged(problemID string) bool {
+	r.acknowledgedMutex.Lock()
+	defer r.acknowledgedMutex.Unlock()
+
+	if until, ok := r.acknowledged[problemID]; ok {
+		if time.Now().Before(until) {
+			return true
+		}
+
+		delete(r.acknowledged, problemID)
+	}
+
+	return false
+}
+
+// Problems returns a snapshot of the currently active problems
+func (r *Runner) Problems() map[string]*api.Problem {
+	r.problemsMutex.RLock()
+	defer r.problemsMutex.RUnlock()
 
-		watchNodes:      watchNodes,
-		watchNamespaces: watchNamespaces,
+	problems := make(map[string]*api.Problem, len(r.problems))
+	for id, problem := range r.problems {
+		problems[id] = problem.toAPIProblem()
+	}
+
+	return problems
+}
 
-		problems: make(map[string]*problemDesc),
-	}, nil
+// Ready returns true if the runner has completed at least one check cycle
+func (r *Runner) Ready() bool {
+	return atomic.LoadInt32(&r.ready) == 1
 }
 
-// Start starts the runner (blocking)
-func (r *Runner) Start() error {
-	log.Printf("Starting runner with interval of %d seconds", defaultInterval/time.Second)
+// Start starts the runner and blocks until the context is cancelled
+func (r *Runner) Start(ctx context.Context) error {
+	r.stop = ctx.Done()
+
+	// Start the informers and wait until they have retrieved the initial state
+	if r.nodeWatch != nil {
+		go func() {
+			err := watchWithReconnect[v1.Node](ctx, r.nodeWatch, r.nodeEventHandler())
+			if err != nil {
+				log.Error("Error watching nodes", "error", err)
+			}
+		}()
+	}
+	for _, podWatch := range r.podWatches {
+		go func(podWatch *resourceListWatch) {
+			err := watchWithReconnect[v1.Pod](ctx, podWatch, r.podEventHandler())
+			if err != nil {
+				log.Error("Error watching pods", "namespace", podWatch.namespace, "error", err)
+			}
+		}(podWatch)
+	}
+	if r.nodeWatch != nil {
+		r.nodeWatch.WaitForSync(ctx.Done())
+	}
+	for _, podWatch := range r.podWatches {
+		podWatch.WaitForSync(ctx.Done())
+	}
+
+	// Load the problems of the last run
+	if r.stateStore != nil {
+		err := r.loadState()
+		if err != nil {
+			log.Error("Error loading state", "error", err)
+		}
+	}
+
+	// Start sending the weekly digest
+	if r.digest != nil {
+		sender, ok := r.notifier.(notify.MessageSender)
+		if ok {
+			go r.digest.run(ctx, sender)
+		} else {
+			log.Warn("Weekly digest is enabled, but no notifier supports sending it")
+		}
+	}
+
+	log.Info("Starting runner", "interval", defaultInterval)
+	loopInterval := r.loopInterval()
 
 	for {
+		// Stop if the context was cancelled, all messages of the last check cycle are sent at this point
+		select {
+		case <-ctx.Done():
+			log.Info("Runner stopped")
+			return nil
+		default:
+		}
+
 		start := time.Now()
 
+		// Problems are still tracked during maintenance, but not reported
+		inMaintenance := r.maintenanceWindows.IsActive(start)
+		if inMaintenance != r.inMaintenance {
+			if inMaintenance {
+				log.Info("Maintenance window started, alerts are suppressed")
+			} else {
+				log.Info("Maintenance window ended")
+			}
+
+			r.inMaintenance = inMaintenance
+		}
+
 		// Watch nodes
-		if r.watchNodes {
-			err := r.doWatchNodes()
+		if r.watchNodes && start.Sub(r.lastNodesChecked) >= defaultInterval {
+			r.lastNodesChecked = start
+
+			err := timeCheck("nodes", "", r.doWatchNodes)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Watch the status of the namespaces
+		if r.watchNamespaceStatus && len(r.watchNamespaces) > 0 {
+			err := timeCheck("namespaceStatus", "", r.doWatchNamespaceStatus)
 			if err != nil {
 				return err
 			}
@@ -119,175 +764,397 @@ func (r *Runner) Start() error {
 
 		// Watch namespaces
 		if len(r.watchNamespaces) > 0 {
-			for _, namespace := range r.watchNamespaces {
-				err := r.doWatchNamespace(namespace)
-				if err != nil {
-					return err
-				}
+			err := r.doWatchNamespaces(r.namespacesDue(start))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Link the pod problems with the problems of their nodes
+		r.correlateProblems()
+
+		// Send the alerts and resolves of this cycle, which mention the correlated problems
+		r.flushReports()
+
+		// Persist the problems
+		if r.stateStore != nil {
+			err := r.saveState()
+			if err != nil {
+				log.Error("Error saving state", "error", err)
 			}
 		}
 
+		// Mark the runner as ready after the first check cycle
+		atomic.StoreInt32(&r.ready, 1)
+		if r.checkOnce {
+			log.Info("Check cycle done, stopping runner", "problems", len(r.Problems()))
+			return nil
+		}
+
 		// Sleep for the remainding interval duration
-		wait := defaultInterval - time.Since(start)
+		wait := loopInterval - time.Since(start)
 		if wait > 0 {
-			time.Sleep(wait)
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
 		}
 
 		// Cleanup old problems
+		r.problemsMutex.Lock()
 		for key, problem := range r.problems {
-			if time.Since(problem.occured) > time.Minute*30 {
-				delete(r.problems, key)
+			if time.Since(problem.occured) > r.getProblemTTL(problem.problemType) {
+				r.expireProblem(key)
 			}
 		}
+		r.cleanupQuietPeriods()
+		r.cleanupOOMKillRates()
+		r.problemsMutex.Unlock()
 	}
 }
 
-func (r *Runner) reportProblem(problem *problemDesc) error {
-	if r.problems[problem.id] == nil {
-		r.problems[problem.id] = problem
+// loopInterval returns the shortest configured interval, which is the interval the check loop runs with
+func (r *Runner) loopInterval() time.Duration {
+	interval := defaultInterval
+	for _, namespaceInterval := range r.namespaceIntervals {
+		if namespaceInterval < interval {
+			interval = namespaceInterval
+		}
 	}
 
-	r.problems[problem.id].occuredCounter++
-	if r.problems[problem.id].reported == false {
-		log.Printf("Problem occured (not reported yet, counter: %d): %s", r.problems[problem.id].occuredCounter, problem.message)
+	return interval
+}
+
+// namespacesDue returns the namespaces whose interval has passed since their last check and marks them as checked
+func (r *Runner) namespacesDue(now time.Time) []string {
+	due := []string{}
+	for _, namespace := range r.watchNamespaces {
+		interval, ok := r.namespaceIntervals[namespace]
+		if !ok {
+			interval = defaultInterval
+		}
+
+		if now.Sub(r.lastChecked[namespace]) >= interval {
+			r.lastChecked[namespace] = now
+			due = append(due, namespace)
+		}
+	}
+
+	return due
+}
+
+// doWatchNamespaces checks the given namespaces concurrently with a pool of workers
+// and returns the first error that occured
+func (r *Runner) doWatchNamespaces(watchNamespaces []string) error {
+	namespaces := make(chan string, len(watchNamespaces))
+	errs := make(chan error, len(watchNamespaces))
+	for _, namespace := range watchNamespaces {
+		namespaces <- namespace
 	}
+	close(namespaces)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < r.namespaceWorkers && i < len(watchNamespaces); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-	// Node condition
-	if r.problems[problem.id].problemType == problemTypeNodeCondition {
-		return r.sendReportMessage(r.problems[problem.id])
+			for namespace := range namespaces {
+				errs <- timeCheck("namespace", namespace, func() error {
+					return r.doWatchNamespaceResources(namespace)
+				})
+			}
+		}()
 	}
 
-	// Node resource pressure
-	if r.problems[problem.id].problemType == problemTypeNodeResourcePressure && r.problems[problem.id].occuredCounter >= 10 {
-		return r.sendReportMessage(r.problems[problem.id])
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
 	}
 
-	// Pod critical status
-	if r.problems[problem.id].problemType == problemTypePodStatus {
-		return r.sendReportMessage(r.problems[problem.id])
+	return nil
+}
+
+// timeCheck runs the given check and records its duration and result
+func timeCheck(checkType, namespace string, check func() error) error {
+	start := time.Now()
+	err := check()
+	prometheus.CheckDuration.Observe(time.Since(start).Seconds(), checkType, namespace)
+	if err != nil {
+		prometheus.ChecksTotal.Inc("error")
+	} else {
+		prometheus.ChecksTotal.Inc("success")
 	}
 
-	// Pod pending
-	if r.problems[problem.id].problemType == problemTypePodPending && r.problems[problem.id].occuredCounter >= 30 {
-		return r.sendReportMessage(r.problems[problem.id])
+	return err
+}
+
+// doWatchNamespaceResources runs all checks of the namespace. Failed checks are logged and skipped
+func (r *Runner) doWatchNamespaceResources(namespace string) error {
+	checks := []struct {
+		resource string
+		check    func(namespace string) error
+	}{
+		{"pods", r.doWatchNamespace},
+		{"deployments", r.doWatchDeployments},
+		{"statefulsets", r.doWatchStatefulSets},
+		{"daemonsets", r.doWatchDaemonSets},
+		{"horizontalpodautoscalers", r.doWatchHPAs},
+		{"jobs", r.doWatchJobs},
+		{"persistentvolumeclaims", r.doWatchPVCs},
+		{"resourcequotas", r.doWatchResourceQuotas},
+		{"customresources", r.doWatchCRDs},
+		{"endpoints", r.doWatchEndpoints},
+		{"secrets", r.doWatchTLSSecrets},
+		{"events", r.doWatchEvents},
 	}
 
-	// Pod restarts
-	if r.problems[problem.id].problemType == problemTypePodRestarts {
-		return r.sendReportMessage(r.problems[problem.id])
+	// A check that fails, e.g. because the resource is forbidden or not served by the cluster, is skipped, so the
+	// other checks of the namespace still run and the problems found so far are still sent
+	for _, check := range checks {
+		err := check.check(namespace)
+		if err != nil {
+			log.Error("Error checking resources, skipping them", "resource", check.resource, "namespace", namespace, "error", err)
+		}
 	}
 
 	return nil
 }
 
-func (r *Runner) resolveProblem(problem *problemDesc) error {
-	problem = r.problems[problem.id]
-	problem.resolvedCounter++
-	if problem.reported == true {
-		log.Printf("Problem resolved ('%s') (resolving not reported yet, counter: %d)", problem.message, problem.resolvedCounter)
+// reportProblem counts the occurrence of the problem and queues its alert once its threshold is reached. The alert is
+// claimed with the problems mutex locked, so concurrent namespace workers reporting the same problem only alert it
+// once, and sent by flushReports at the end of the check cycle after the problems were correlated
+func (r *Runner) reportProblem(problem *problemDesc) error {
+	r.problemsMutex.Lock()
+	defer r.problemsMutex.Unlock()
+
+	if r.problems[problem.id] == nil {
+		r.addProblem(problem)
 	}
 
-	// Node condition
-	if problem.problemType == problemTypeNodeCondition {
-		delete(r.problems, problem.id)
-		if problem.reported {
-			return r.sendResolveMessage(problem)
-		}
+	message, severity := problem.message, problem.severity
+	priority, priorityClassName := problem.priority, problem.priorityClassName
+	if problem.eventNamespace == "" {
+		// The check that found the problem resolves it from now on
+		r.problems[problem.id].eventNamespace = ""
+	}
+	problem = r.problems[problem.id]
+	if severity != "" {
+		problem.severity = severity
+	}
+	problem.priority, problem.priorityClassName = priority, priorityClassName
+	problem.occuredCounter++
+	if r.digest != nil {
+		r.digest.record(problem)
+	}
+	if problem.reported == false {
+		log.Info("Problem occured (not reported yet)", append(problem.logFields(), "counter", problem.occuredCounter)...)
+	}
 
+	if r.inMaintenance {
+		return nil
+	} else if !problem.reported && !r.checkOnce && r.inQuietPeriod(problem) {
+		log.Debug("Problem occured within its quiet period, not reporting", append(problem.logFields(), "counter", problem.occuredCounter)...)
 		return nil
 	}
 
-	// Node resource pressure
-	if problem.problemType == problemTypeNodeResourcePressure && problem.resolvedCounter >= 5 {
-		delete(r.problems, problem.id)
-		if problem.reported {
-			return r.sendResolveMessage(problem)
-		}
-
+	if problem.reported && problem.threadTS != "" && message != problem.message && time.Since(problem.lastUpdate) >= minThreadUpdateInterval {
+		problem.message = message
+		problem.lastUpdate = time.Now()
+		r.queueNotification(notification{kind: notificationUpdate, problem: problem, thread: problem.threadTS, message: message})
 		return nil
 	}
 
-	// Pod critical status
-	if problem.problemType == problemTypePodStatus && problem.resolvedCounter >= 10 {
-		delete(r.problems, problem.id)
-		if problem.reported {
-			return r.sendResolveMessage(problem)
-		}
+	if r.checkOnce || (problem.occuredCounter >= r.thresholds.Get(problem.problemType) && time.Since(problem.occured) >= problem.reportAfter) {
+		r.claimReport(problem)
+	}
 
-		return nil
+	return nil
+}
+
+// resolveProblemsOf resolves all problems of the given resource except the problems of the excluded types and the
+// problems found by events, which are resolved once the events stop
+func (r *Runner) resolveProblemsOf(kind resourceKind, name, namespace string, excluded ...problemType) error {
+	r.problemsMutex.Lock()
+	for _, problem := range r.problems {
+		if problem.kind == kind && problem.name == name && problem.namespace == namespace && !containsProblemType(excluded, problem.problemType) && problem.eventNamespace == "" {
+			r.resolveProblem(problem)
+		}
 	}
+	r.problemsMutex.Unlock()
 
-	// Pod pending
-	if problem.problemType == problemTypePodPending && problem.resolvedCounter >= 10 {
-		delete(r.problems, problem.id)
-		if problem.reported {
-			return r.sendResolveMessage(problem)
+	return nil
+}
+
+func containsProblemType(problemTypes []problemType, problemType problemType) bool {
+	for _, t := range problemTypes {
+		if t == problemType {
+			return true
 		}
+	}
 
-		return nil
+	return false
+}
+
+// resolveProblemWithID resolves the problem with the given id immediately if it exists
+func (r *Runner) resolveProblemWithID(id string) error {
+	r.problemsMutex.Lock()
+	problem := r.problems[id]
+	if problem != nil {
+		r.deleteProblem(id)
+		r.queueResolve(problem)
 	}
+	r.problemsMutex.Unlock()
 
 	return nil
 }
 
-func (r *Runner) sendResolveMessage(problem *problemDesc) error {
-	msg := fmt.Sprintf("%s do you remember the problem with %s '%s'? Good news, seems like this is not a problem anymore :tada:", getGreeting(), problem.kind, problem.name)
-	log.Printf("Sending resolve message to slack (%s)", msg)
-	return r.slackClient.SendMessage(msg)
+// reportContainerProblem reports the problem of the container of the pod or resolves it if msg is empty, because the
+// container problems are found per container and not per pod
+func (r *Runner) reportContainerProblem(pod *v1.Pod, containerName string, problemType problemType, msg string) error {
+	id := pod.Name + "/" + pod.Namespace + string(problemType) + "/" + containerName
+	if msg == "" {
+		return r.resolveProblemWithID(id)
+	}
+
+	return r.reportProblem(&problemDesc{
+		problemType: problemType,
+
+		message: msg,
+		id:      id,
+
+		kind:      resourceKindPod,
+		name:      pod.Name,
+		namespace: pod.Namespace,
+		occured:   time.Now(),
+	})
+}
+
+// resolveProblem counts that the problem is gone and resolves it once its resolve threshold is reached.
+// Needs to be called with the problems mutex locked, the resolve message is queued
+func (r *Runner) resolveProblem(problem *problemDesc) {
+	problem = r.problems[problem.id]
+	problem.resolvedCounter++
+	if problem.reported == true {
+		log.Info("Problem resolved (resolving not reported yet)", append(problem.logFields(), "counter", problem.resolvedCounter)...)
+	}
+
+	if immediateResolve[problem.problemType] {
+		r.deleteProblem(problem.id)
+		r.queueResolve(problem)
+		return
+	}
+
+	// Node resource & disk pressure, pod critical status & pod pending
+	if resolveThreshold, ok := r.resolveThresholds[problem.problemType]; ok && problem.resolvedCounter >= resolveThreshold {
+		r.deleteProblem(problem.id)
+		r.queueResolve(problem)
+	}
+}
+
+func (r *Runner) addProblem(problem *problemDesc) {
+	if problem.severity == "" {
+		problem.severity = r.getSeverity(problem.problemType)
+	}
+	r.problems[problem.id] = problem
+	prometheus.ActiveProblems.Inc(problem.metricLabels()...)
+}
+
+func (r *Runner) deleteProblem(id string) {
+	problem := r.problems[id]
+	if problem == nil {
+		return
+	}
+
+	r.recordResolved(problem)
+	r.history.add(problem, time.Now())
+	delete(r.problems, id)
+	prometheus.ActiveProblems.Dec(problem.metricLabels()...)
 }
 
-func (r *Runner) sendReportMessage(problem *problemDesc) error {
+// queueResolve queues the resolve message of the problem if it was reported. Needs to be called with the problems
+// mutex locked
+func (r *Runner) queueResolve(problem *problemDesc) {
 	if problem.reported {
+		r.queueNotification(notification{kind: notificationResolve, problem: problem, alert: problem.toNotifyProblem(), thread: problem.threadTS})
+	}
+}
+
+// sendResolveMessage sends the resolve message of a resolved problem. Needs to be called without the problems mutex locked
+func (r *Runner) sendResolveMessage(n notification) error {
+	if r.dryRun {
+		log.Info("Dry run: not sending resolve message", n.problem.logFields()...)
 		return nil
 	}
 
+	n.alert.Channel = r.getSlackChannel(n.problem)
+	log.Info("Sending resolve message", n.problem.logFields()...)
+	if threadNotifier, ok := r.notifier.(notify.ThreadNotifier); ok && r.resolveInThread && n.thread != "" {
+		return threadNotifier.ResolveThread(n.thread, n.alert)
+	}
+
+	return r.notifier.Resolve(n.alert)
+}
+
+// claimReport queues the alert of the problem if it was not reported yet. Needs to be called with the problems mutex
+// locked, reported is set when the alert is queued, so only the first caller for a problem id sends it
+func (r *Runner) claimReport(problem *problemDesc) {
+	if problem.reported {
+		return
+	} else if r.isAcknowledged(problem.id) {
+		return
+	}
+
 	problem.reported = true
-	if problem.namespace != "" {
-		msg := fmt.Sprintf("%s there seems to be a problem with %s '%s' in namespace '%s': %s", getGreeting(), problem.kind, problem.name, problem.namespace, problem.message)
-		log.Printf("Sending report message to slack (%s)", msg)
-		return r.slackClient.SendMessage(msg)
-	}
-
-	msg := fmt.Sprintf("%s there seems to be a problem with %s '%s': %s", getGreeting(), problem.kind, problem.name, problem.message)
-	log.Printf("Sending report message to slack (%s)", msg)
-	return r.slackClient.SendMessage(msg)
-}
-
-var greetings = []string{
-	"Guys real talk :point_up:,",
-	"It's me again, the lovely bot from the neighborhood and",
-	"Alright, so",
-	"Yo bois :dark_sunglasses:,",
-	"Sorry to interrupt,",
-	"I'm back :v:,",
-	"Yes I know I'm annoying :grin:, but",
-	"Where is the cluster admin :face_with_monocle:, because",
-	"I just wanted to chill :expressionless: and then I checked the cluster one more time and",
-	"What would you do without me? I just checked the cluster again and",
-}
-
-func getGreeting() string {
-	rand.Seed(time.Now().Unix())
-
-	num := rand.Intn(len(greetings) + 1)
-	if num == len(greetings) {
-		now := time.Now()
-		if now.Weekday() == time.Sunday {
-			return "Damn sorry to interrupt your Sunday :face_with_rolling_eyes:, but"
-		} else if now.Weekday() == time.Saturday {
-			return "Yes I know it's weekend, but"
-		}
+	if r.alertBatching || problem.isGroupedNodeProblem() {
+		r.queueReport(problem)
+		return
+	}
 
-		if now.Hour() < 12 {
-			return "Good morning everyone :wave:,"
-		} else if now.Hour() < 15 {
-			return "Hello everyone :wave:,"
-		} else if now.Hour() < 18 {
-			return "Good afternoon everyone :wave:,"
-		}
+	r.queueNotification(notification{kind: notificationReport, problem: problem})
+}
+
+// sendReportMessage sends the alert of a claimed problem. Needs to be called without the problems mutex locked
+func (r *Runner) sendReportMessage(n notification) error {
+	if r.dryRun {
+		log.Info("Dry run: not sending report message", n.problem.logFields()...)
+		return nil
+	}
+
+	r.addPodLogs(&n.alert, n.problem)
+
+	n.alert.Channel = r.getSlackChannel(n.problem)
+	log.Info("Sending report message", n.problem.logFields()...)
+	if threadNotifier, ok := r.notifier.(notify.ThreadNotifier); ok && r.slackThreads {
+		thread, err := threadNotifier.AlertThread(n.alert)
+
+		r.problemsMutex.Lock()
+		n.problem.threadTS = thread
+		n.problem.lastUpdate = time.Now()
+		r.problemsMutex.Unlock()
+		return err
+	}
+
+	return r.notifier.Alert(n.alert)
+}
+
+// sendUpdateMessage replies with the message in the thread of a reported problem. Needs to be called without the
+// problems mutex locked
+func (r *Runner) sendUpdateMessage(n notification) error {
+	if r.dryRun {
+		log.Info("Dry run: not sending update message", "thread", n.thread, "message", n.message)
+		return nil
+	}
 
-		return "Good evening everyone :wave:,"
+	threadNotifier, ok := r.notifier.(notify.ThreadNotifier)
+	if !ok {
+		return fmt.Errorf("Notifier doesn't support thread messages")
 	}
 
-	return greetings[num]
+	log.Info("Sending update message", "thread", n.thread, "message", n.message)
+	return threadNotifier.SendThreadMessage(n.thread, n.message)
 }
@@ -0,0 +1,421 @@
+package runner
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/kube"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// fakeClient is a kube client backed by a fake clientset and dynamic client
+type fakeClient struct {
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
+}
+
+func newFakeClient(objects ...runtime.Object) *fakeClient {
+	clientset := fake.NewSimpleClientset(objects...)
+
+	// The fake clientset has no rest client for the heapster fallback, so the metrics api is advertised instead. The
+	// requests to it fail, because nothing listens at the host of the config
+	clientset.Resources = []*metav1.APIResourceList{{GroupVersion: "metrics.k8s.io/v1beta1"}}
+	return &fakeClient{clientset: clientset, dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())}
+}
+
+func (c *fakeClient) Config() *rest.Config {
+	return &rest.Config{Host: "http://127.0.0.1:1"}
+}
+
+func (c *fakeClient) Client() kubernetes.Interface {
+	return c.clientset
+}
+
+func (c *fakeClient) Dynamic() dynamic.Interface {
+	return c.dynamic
+}
+
+// newFakeRunner creates a runner for the fake client that checks all pods of the watched namespaces
+func newFakeRunner(t *testing.T, client kube.Client, notifier notify.Notifier, watchNodes bool, watchNamespaces []string) *Runner {
+	r, err := NewRunner(client, notifier, watchNodes, watchNamespaces, nil, labels.Everything(), labels.Everything(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+// startWatches runs the list watches of the runner until the test is finished and waits for their initial sync
+func startWatches(t *testing.T, r *Runner) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	if r.nodeWatch != nil {
+		go watchWithReconnect[v1.Node](ctx, r.nodeWatch, r.nodeEventHandler())
+		r.nodeWatch.WaitForSync(ctx.Done())
+	}
+	for _, podWatch := range r.podWatches {
+		go watchWithReconnect[v1.Pod](ctx, podWatch, r.podEventHandler())
+		podWatch.WaitForSync(ctx.Done())
+	}
+}
+
+// newTestRunner returns a runner that reports problems after their first occurrence and resolves them immediately
+func newTestRunner(notifier notify.Notifier) *Runner {
+	return &Runner{
+		notifier: notifier,
+
+		apiMaxRetries: 1,
+
+		thresholds:        &ThresholdConfig{NodeCondition: 1, PodStatus: 1, PodPending: 1},
+		resolveThresholds: map[problemType]int{problemTypePodStatus: 1, problemTypePodPending: 1},
+
+		problems:     make(map[string]*problemDesc),
+		history:      newProblemHistory(defaultProblemHistorySize),
+		quietUntil:   make(map[string]time.Time),
+		acknowledged: make(map[string]time.Time),
+	}
+}
+
+func TestNodeThresholdsFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		cpu         string
+		mem         string
+		expectedCPU float64
+		expectedMem float64
+	}{
+		{
+			name:        "unset",
+			expectedCPU: defaultNodeCPUThreshold,
+			expectedMem: defaultNodeMemThreshold,
+		},
+		{
+			name:        "valid",
+			cpu:         "0.8",
+			mem:         "0.9",
+			expectedCPU: 0.8,
+			expectedMem: 0.9,
+		},
+		{
+			name:        "invalid",
+			cpu:         "high",
+			mem:         "1.5",
+			expectedCPU: defaultNodeCPUThreshold,
+			expectedMem: defaultNodeMemThreshold,
+		},
+	}
+
+	for _, test := range tests {
+		t.Setenv("NODE_CPU_THRESHOLD", test.cpu)
+		t.Setenv("NODE_MEM_THRESHOLD", test.mem)
+
+		r := newFakeRunner(t, newFakeClient(), &recordingNotifier{}, false, nil)
+		if r.nodeCPUThreshold != test.expectedCPU {
+			t.Fatalf("%s: expected cpu threshold %v, got %v", test.name, test.expectedCPU, r.nodeCPUThreshold)
+		} else if r.nodeMemThreshold != test.expectedMem {
+			t.Fatalf("%s: expected memory threshold %v, got %v", test.name, test.expectedMem, r.nodeMemThreshold)
+		}
+	}
+}
+
+func TestActiveProblemsMetric(t *testing.T) {
+	server := httptest.NewServer(prometheus.Handler())
+	defer server.Close()
+
+	scrape := func() string {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		out, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(out)
+	}
+
+	r := &Runner{notifier: &recordingNotifier{}, thresholds: &ThresholdConfig{NodeCondition: 1}, problems: make(map[string]*problemDesc), history: newProblemHistory(defaultProblemHistorySize)}
+	problem := &problemDesc{
+		problemType: problemTypeNodeCondition,
+		kind:        resourceKindNode,
+		name:        "node",
+		id:          "node/condition",
+		message:     "Node node is not ready",
+		occured:     time.Now(),
+	}
+
+	err := r.reportProblem(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series := `kube_problem_active_total{kind="Node",name="node",namespace="",problem_type="NodeCondition"} 1`
+	if out := scrape(); !strings.Contains(out, series) {
+		t.Fatalf("Expected %s in:\n%s", series, out)
+	}
+
+	err = r.resolveProblemsOf(resourceKindNode, "node", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out := scrape(); strings.Contains(out, `kind="Node",name="node",namespace="",problem_type="NodeCondition"`) {
+		t.Fatalf("Expected the resolved problem to be removed from:\n%s", out)
+	}
+}
+
+func TestReadyAfterFirstCheck(t *testing.T) {
+	r := newFakeRunner(t, newFakeClient(), &recordingNotifier{}, false, nil)
+	if r.Ready() {
+		t.Fatal("Expected the runner not to be ready before the first check")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Start(ctx)
+
+	timeout := time.After(time.Second * 10)
+	for !r.Ready() {
+		select {
+		case <-timeout:
+			t.Fatal("Runner did not become ready after the first check")
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+}
+
+func TestStartStopsOnCancel(t *testing.T) {
+	r := newFakeRunner(t, newFakeClient(), &recordingNotifier{}, false, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error)
+	go func() {
+		errChan <- r.Start(ctx)
+	}()
+
+	// Cancel while the runner waits for the next check cycle
+	for !r.Ready() {
+		time.Sleep(time.Millisecond * 10)
+	}
+	cancel()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Fatalf("Expected Start to return nil after the context was cancelled, got %v", err)
+		}
+	case <-time.After(defaultInterval):
+		t.Fatal("Start did not return within one check interval after the context was cancelled")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	t.Setenv("DRY_RUN", "true")
+
+	notifier := &recordingNotifier{}
+	r := newFakeRunner(t, newFakeClient(), notifier, false, nil)
+	if !r.dryRun {
+		t.Fatal("Expected dry run to be enabled")
+	}
+
+	problem := &problemDesc{
+		problemType: problemTypeNodeCondition,
+		kind:        resourceKindNode,
+		name:        "node",
+		id:          "node/condition",
+		message:     "Node node is not ready",
+		occured:     time.Now(),
+	}
+
+	err := r.reportProblem(problem)
+	if err != nil {
+		t.Fatal(err)
+	} else if !r.problems[problem.id].reported {
+		t.Fatal("Expected the problem to be marked as reported in dry run mode")
+	}
+
+	err = r.resolveProblemsOf(resourceKindNode, "node", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notifier.alerts) != 0 || len(notifier.resolves) != 0 {
+		t.Fatalf("Expected no messages in dry run mode, got alerts %v and resolves %v", notifier.alerts, notifier.resolves)
+	}
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	notifier := &recordingNotifier{}
+	r := &Runner{notifier: notifier, thresholds: &ThresholdConfig{NodeCondition: 1}, problems: make(map[string]*problemDesc), inMaintenance: true}
+	problem := &problemDesc{
+		problemType: problemTypeNodeCondition,
+		kind:        resourceKindNode,
+		name:        "node",
+		id:          "node/condition",
+		message:     "Node node is not ready",
+		occured:     time.Now(),
+	}
+
+	err := r.reportProblem(problem)
+	r.flushReports()
+	if err != nil {
+		t.Fatal(err)
+	} else if r.problems[problem.id] == nil {
+		t.Fatal("Expected the problem to be tracked during maintenance")
+	} else if len(notifier.alerts) != 0 {
+		t.Fatalf("Expected no alert during maintenance, got %v", notifier.alerts)
+	}
+
+	// The problem is reported as soon as the maintenance window is over
+	r.inMaintenance = false
+	err = r.reportProblem(problem)
+	r.flushReports()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected 1 alert after the maintenance window, got %d", len(notifier.alerts))
+	}
+}
+
+func TestReportProblemConcurrently(t *testing.T) {
+	notifier := &recordingNotifier{}
+	r := &Runner{notifier: notifier, thresholds: &ThresholdConfig{PodStatus: 1}, problems: make(map[string]*problemDesc), acknowledged: make(map[string]time.Time)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := r.reportProblem(&problemDesc{
+				problemType: problemTypePodStatus,
+				kind:        resourceKindPod,
+				name:        "pod",
+				namespace:   "default",
+				id:          "default/pod/status",
+				message:     "Pod default/pod has critical status CrashLoopBackOff",
+				occured:     time.Now(),
+			})
+			r.flushReports()
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected exactly 1 alert, got %d", len(notifier.alerts))
+	}
+}
+
+// blockingNotifier blocks every alert and resolve until release is closed
+type blockingNotifier struct {
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (n *blockingNotifier) Alert(p notify.Problem) error {
+	n.sending <- struct{}{}
+	<-n.release
+	return nil
+}
+
+func (n *blockingNotifier) Resolve(p notify.Problem) error {
+	n.sending <- struct{}{}
+	<-n.release
+	return nil
+}
+
+func TestNotifierDoesNotBlockProblems(t *testing.T) {
+	notifier := &blockingNotifier{sending: make(chan struct{}), release: make(chan struct{})}
+	r := newTestRunner(notifier)
+
+	problem := &problemDesc{
+		problemType: problemTypeNodeCondition,
+		kind:        resourceKindNode,
+		name:        "node",
+		id:          "node/condition",
+		message:     "Node node is not ready",
+		occured:     time.Now(),
+	}
+
+	tests := []struct {
+		name string
+		send func() error
+	}{
+		{
+			name: "report",
+			send: func() error {
+				err := r.reportProblem(problem)
+				if err != nil {
+					return err
+				}
+
+				r.flushReports()
+				return nil
+			},
+		},
+		{
+			name: "resolve",
+			send: func() error {
+				err := r.resolveProblemsOf(resourceKindNode, "node", "")
+				if err != nil {
+					return err
+				}
+
+				r.flushReports()
+				return nil
+			},
+		},
+	}
+
+	for _, test := range tests {
+		done := make(chan error)
+		go func() {
+			done <- test.send()
+		}()
+
+		select {
+		case <-notifier.sending:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("%s: notifier was not called", test.name)
+		}
+
+		// The problems can be read while the notifier is blocked
+		problems := make(chan int)
+		go func() {
+			problems <- len(r.Problems())
+		}()
+		select {
+		case <-problems:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("%s: problems mutex is held while sending", test.name)
+		}
+
+		notifier.release <- struct{}{}
+		err := <-done
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+)
+
+type severity string
+
+const (
+	severityCritical severity = "critical"
+	severityWarning  severity = "warning"
+	severityInfo     severity = "info"
+)
+
+// defaultSeverities holds the severity of each problem type, which can be overwritten with SEVERITY_<PROBLEM_TYPE>
+var defaultSeverities = map[problemType]severity{
+	problemTypeNodeCondition:        severityCritical,
+	problemTypeNodeResourcePressure: severityWarning,
+	problemTypeNodeDiskPressure:     severityWarning,
+	problemTypeNodeHeartbeatStale:   severityCritical,
+	problemTypeNodeAllocatableSkew:  severityInfo,
+	problemTypeNodeFlapping:         severityCritical,
+
+	problemTypePodStatus:   severityCritical,
+	problemTypePodRestarts: severityWarning,
+	problemTypePodPending:  severityWarning,
+	problemTypePodOOMKill:  severityWarning,
+	problemTypePodNoLimits: severityInfo,
+	problemTypePodNoProbe:  severityInfo,
+
+	problemTypePodLatestTag: severityWarning,
+
+	problemTypePodStuckTerminating: severityWarning,
+	problemTypeCPUThrottling:       severityWarning,
+	problemTypePodResourceRatio:    severityInfo,
+	problemTypePodOnNotReadyNode:   severityCritical,
+	problemTypePodUnready:          severityWarning,
+
+	problemTypeDeploymentStall:      severityCritical,
+	problemTypeDeploymentRollback:   severityWarning,
+	problemTypeStatefulSetDegraded:  severityCritical,
+	problemTypeDaemonSetUnavailable: severityWarning,
+	problemTypeHPAAtMax:             severityWarning,
+	problemTypeJobFailed:            severityWarning,
+	problemTypeCronJobMissed:        severityWarning,
+	problemTypeCronJobStuck:         severityWarning,
+
+	problemTypePVCPending: severityWarning,
+
+	problemTypeQuotaExhaustion: severityWarning,
+
+	problemTypeCRDStatus: severityWarning,
+
+	problemTypeNoReadyEndpoints: severityCritical,
+
+	problemTypeCertExpiry: severityWarning,
+
+	problemTypeWarningEvent: severityWarning,
+
+	problemTypeNamespaceStuck: severityWarning,
+
+	problemTypeAlertmanagerAlert: severityWarning,
+}
+
+// newSeveritiesFromEnv returns the severity per problem type with the overrides from the environment
+func newSeveritiesFromEnv() map[problemType]severity {
+	severities := make(map[problemType]severity, len(defaultSeverities))
+	for problemType, defaultSeverity := range defaultSeverities {
+		severities[problemType] = defaultSeverity
+
+		name := SeverityEnvName(string(problemType))
+		value := severity(strings.ToLower(os.Getenv(name)))
+		switch value {
+		case "":
+		case severityCritical, severityWarning, severityInfo:
+			severities[problemType] = value
+		default:
+			log.Warn("Invalid value, expected critical, warning or info, using default", "env", name, "value", os.Getenv(name), "default", string(defaultSeverity))
+		}
+	}
+
+	return severities
+}
+
+// ProblemTypes returns the names of all problem types
+func ProblemTypes() []string {
+	problemTypes := make([]string, 0, len(defaultSeverities))
+	for problemType := range defaultSeverities {
+		problemTypes = append(problemTypes, string(problemType))
+	}
+
+	sort.Strings(problemTypes)
+	return problemTypes
+}
+
+// SeverityEnvName returns the environment variable that overwrites the severity of the given problem type
+func SeverityEnvName(problemType string) string {
+	return "SEVERITY_" + toEnvName(problemType)
+}
+
+// toEnvName converts a camel case name to upper snake case, e.g. PodOOMKill to POD_OOM_KILL
+func toEnvName(name string) string {
+	runes := []rune(name)
+	out := []rune{}
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			out = append(out, '_')
+		}
+
+		out = append(out, unicode.ToUpper(r))
+	}
+
+	return string(out)
+}
+
+// getControlPlaneSeverityFromEnv returns the severity of CONTROL_PLANE_SEVERITY or an empty severity if it is not set
+func getControlPlaneSeverityFromEnv() severity {
+	value := severity(strings.ToLower(os.Getenv("CONTROL_PLANE_SEVERITY")))
+	switch value {
+	case "", severityCritical, severityWarning, severityInfo:
+		return value
+	}
+
+	log.Warn("Invalid value, expected critical, warning or info, using the severity of the problem type", "env", "CONTROL_PLANE_SEVERITY", "value", os.Getenv("CONTROL_PLANE_SEVERITY"))
+	return ""
+}
+
+func (r *Runner) getSeverity(problemType problemType) severity {
+	if severity, ok := r.severities[problemType]; ok {
+		return severity
+	}
+
+	return severityWarning
+}
@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/state"
+)
+
+func (r *Runner) loadState() error {
+	problems, err := r.stateStore.Load()
+	if err != nil {
+		return err
+	}
+
+	r.problemsMutex.Lock()
+	defer r.problemsMutex.Unlock()
+
+	for _, problem := range problems {
+		r.addProblem(&problemDesc{
+			problemType: problemType(problem.Type),
+			kind:        resourceKind(problem.Kind),
+			name:        problem.Name,
+			namespace:   problem.Namespace,
+
+			id:      problem.ID,
+			message: problem.Message,
+
+			resolvedCounter: problem.ResolvedCounter,
+			occuredCounter:  problem.OccuredCounter,
+
+			reported:    problem.Reported,
+			occured:     problem.Occured,
+			reportAfter: problem.ReportAfter,
+			threadTS:    problem.Thread,
+
+			eventNamespace: problem.EventNamespace,
+		})
+	}
+
+	log.Info("Loaded problems from configmap", "count", len(problems), "configmap", state.ConfigMapName)
+	return nil
+}
+
+func (r *Runner) saveState() error {
+	r.problemsMutex.RLock()
+	problems := make(map[string]*state.Problem, len(r.problems))
+	for id, problem := range r.problems {
+		problems[id] = &state.Problem{
+			ID:        problem.id,
+			Type:      string(problem.problemType),
+			Kind:      string(problem.kind),
+			Name:      problem.name,
+			Namespace: problem.namespace,
+
+			Message: problem.message,
+
+			ResolvedCounter: problem.resolvedCounter,
+			OccuredCounter:  problem.occuredCounter,
+
+			Reported:    problem.reported,
+			Occured:     problem.occured,
+			ReportAfter: problem.reportAfter,
+			Thread:      problem.threadTS,
+
+			EventNamespace: problem.eventNamespace,
+		}
+	}
+	r.problemsMutex.RUnlock()
+
+	return r.stateStore.Save(problems)
+}
@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/state"
+)
+
+func TestStateSurvivesRestart(t *testing.T) {
+	client := newFakeClient()
+	newProblem := func() *problemDesc {
+		return &problemDesc{
+			problemType: problemTypePodStatus,
+			kind:        resourceKindPod,
+			name:        "pod",
+			namespace:   "default",
+			id:          "default/pod/status",
+			message:     "Pod default/pod has critical status CrashLoopBackOff",
+			occured:     time.Now(),
+		}
+	}
+
+	notifier := &recordingNotifier{}
+	r := newFakeRunner(t, client, notifier, false, nil)
+	r.stateStore = state.NewStore(client, "kube-problem")
+
+	err := r.reportProblem(newProblem())
+	r.flushReports()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(notifier.alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(notifier.alerts))
+	}
+
+	err = r.saveState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The restarted runner knows that the problem was already reported
+	notifier = &recordingNotifier{}
+	r = newFakeRunner(t, client, notifier, false, nil)
+	r.stateStore = state.NewStore(client, "kube-problem")
+
+	err = r.loadState()
+	if err != nil {
+		t.Fatal(err)
+	} else if r.problems["default/pod/status"] == nil || !r.problems["default/pod/status"].reported {
+		t.Fatalf("Expected the reported problem to be loaded, got %#v", r.problems)
+	}
+
+	err = r.reportProblem(newProblem())
+	if err != nil {
+		t.Fatal(err)
+	} else if len(notifier.alerts) != 0 {
+		t.Fatalf("Expected no alert for the already reported problem, got %d", len(notifier.alerts))
+	}
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (r *Runner) doWatchStatefulSets(namespace string) error {
+	var statefulSetList *appsv1.StatefulSetList
+	err := r.withRetry(func() (err error) {
+		statefulSetList, err = r.client.Client().AppsV1().StatefulSets(namespace).List(r.listOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, statefulSet := range statefulSetList.Items {
+		if isIgnored(&statefulSet) {
+			continue
+		}
+
+		// Handle problem reporting or resolving
+		if statefulSet.Status.ReadyReplicas != statefulSet.Status.Replicas {
+			failingOrdinals, err := r.getFailingOrdinals(&statefulSet)
+			if err != nil {
+				return err
+			}
+
+			msg := fmt.Sprintf("StatefulSet '%s/%s' (revision '%s') has %d of %d replica(s) not ready for more than %v, failing ordinal(s): %s", statefulSet.Namespace, statefulSet.Name, statefulSet.Status.CurrentRevision, statefulSet.Status.Replicas-statefulSet.Status.ReadyReplicas, statefulSet.Status.Replicas, r.statefulSetDegradedTimeout, failingOrdinals)
+			err = r.reportProblem(&problemDesc{
+				problemType: problemTypeStatefulSetDegraded,
+
+				message: msg,
+				id:      statefulSet.Name + "/" + statefulSet.Namespace + string(problemTypeStatefulSetDegraded),
+
+				kind:        resourceKindStatefulSet,
+				name:        statefulSet.Name,
+				namespace:   statefulSet.Namespace,
+				occured:     time.Now(),
+				reportAfter: r.statefulSetDegradedTimeout,
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.resolveProblemsOf(resourceKindStatefulSet, statefulSet.Name, statefulSet.Namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getFailingOrdinals returns the ordinals of the stateful set pods that are missing or not ready
+func (r *Runner) getFailingOrdinals(statefulSet *appsv1.StatefulSet) (string, error) {
+	var podList *v1.PodList
+	err := r.withRetry(func() (err error) {
+		podList, err = r.client.Client().CoreV1().Pods(statefulSet.Namespace).List(metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(statefulSet.Spec.Selector)})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ready := make(map[string]bool, len(podList.Items))
+	for _, pod := range podList.Items {
+		ready[pod.Name] = isPodReady(&pod)
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	failing := []string{}
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		if !ready[statefulSet.Name+"-"+strconv.Itoa(int(ordinal))] {
+			failing = append(failing, strconv.Itoa(int(ordinal)))
+		}
+	}
+	if len(failing) == 0 {
+		return "unknown", nil
+	}
+
+	return strings.Join(failing, ", "), nil
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+
+	return false
+}
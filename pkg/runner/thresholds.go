@@ -0,0 +1,116 @@
+package runner
+
+// ThresholdConfig holds how many consecutive checks a problem has to occur before it is reported
+type ThresholdConfig struct {
+	NodeCondition        int
+	NodeResourcePressure int
+
+	PodStatus   int
+	PodRestarts int
+	PodPending  int
+	PodNoLimits int
+	PodNoProbe  int
+
+	CPUThrottling int
+	PodLatestTag  int
+
+	NoReadyEndpoints int
+}
+
+// NewThresholdConfigFromEnv creates a new threshold config from the environment and
+// falls back to the default thresholds for unset or invalid values
+func NewThresholdConfigFromEnv() *ThresholdConfig {
+	return &ThresholdConfig{
+		NodeCondition:        getCountFromEnv("NODE_CONDITION_THRESHOLD", 1),
+		NodeResourcePressure: getCountFromEnv("NODE_PRESSURE_THRESHOLD", 10),
+
+		PodStatus:   getCountFromEnv("POD_STATUS_THRESHOLD", 1),
+		PodRestarts: getCountFromEnv("POD_RESTARTS_THRESHOLD", 1),
+		PodPending:  getCountFromEnv("POD_PENDING_THRESHOLD", 30),
+		PodNoLimits: getCountFromEnv("POD_NO_LIMITS_THRESHOLD", 5),
+		PodNoProbe:  getCountFromEnv("POD_NO_PROBE_THRESHOLD", 60),
+
+		CPUThrottling: getCountFromEnv("CPU_THROTTLE_THRESHOLD_COUNT", 10),
+		PodLatestTag:  getCountFromEnv("POD_LATEST_TAG_THRESHOLD", 20),
+
+		NoReadyEndpoints: getCountFromEnv("NO_READY_ENDPOINTS_THRESHOLD", 3),
+	}
+}
+
+// Get returns the occurrence threshold for the given problem type
+func (t *ThresholdConfig) Get(problemType problemType) int {
+	switch problemType {
+	case problemTypeNodeCondition:
+		return t.NodeCondition
+	case problemTypeNodeResourcePressure, problemTypeNodeDiskPressure:
+		return t.NodeResourcePressure
+	case problemTypePodStatus:
+		return t.PodStatus
+	case problemTypePodRestarts:
+		return t.PodRestarts
+	case problemTypePodPending:
+		return t.PodPending
+	case problemTypePodNoLimits:
+		return t.PodNoLimits
+	case problemTypePodNoProbe:
+		return t.PodNoProbe
+	case problemTypeCPUThrottling:
+		return t.CPUThrottling
+	case problemTypePodLatestTag:
+		return t.PodLatestTag
+	case problemTypeNoReadyEndpoints:
+		return t.NoReadyEndpoints
+	}
+
+	return 1
+}
+
+// immediateResolve holds the problem types that are resolved as soon as a check doesn't find them anymore
+var immediateResolve = map[problemType]bool{
+	problemTypeNodeCondition:       true,
+	problemTypeNodeHeartbeatStale:  true,
+	problemTypeNodeAllocatableSkew: true,
+
+	problemTypeDeploymentStall:      true,
+	problemTypeStatefulSetDegraded:  true,
+	problemTypeDaemonSetUnavailable: true,
+	problemTypeHPAAtMax:             true,
+	problemTypeJobFailed:            true,
+	problemTypeCronJobMissed:        true,
+	problemTypeCronJobStuck:         true,
+
+	problemTypePVCPending:      true,
+	problemTypeQuotaExhaustion: true,
+	problemTypeCRDStatus:       true,
+
+	problemTypePodStuckTerminating: true,
+	problemTypePodOnNotReadyNode:   true,
+	problemTypePodUnready:          true,
+
+	problemTypeNoReadyEndpoints: true,
+	problemTypeCertExpiry:       true,
+	problemTypeNamespaceStuck:   true,
+}
+
+// resolveThresholdEnvs holds the environment variable and the default of the number of consecutive checks a problem
+// has to be gone before it is resolved. Problem types without a resolve threshold are resolved immediately or never
+var resolveThresholdEnvs = map[problemType]struct {
+	env          string
+	defaultValue int
+}{
+	problemTypeNodeResourcePressure: {"NODE_PRESSURE_RESOLVE_THRESHOLD", 5},
+	problemTypeNodeDiskPressure:     {"NODE_PRESSURE_RESOLVE_THRESHOLD", 5},
+
+	problemTypePodStatus:  {"POD_STATUS_RESOLVE_THRESHOLD", 10},
+	problemTypePodPending: {"POD_PENDING_RESOLVE_THRESHOLD", 10},
+}
+
+// newResolveThresholdsFromEnv returns the resolve threshold per problem type with the overrides from the environment
+func newResolveThresholdsFromEnv() map[problemType]int {
+	resolveThresholds := make(map[problemType]int, len(resolveThresholdEnvs))
+	for problemType, resolveThreshold := range resolveThresholdEnvs {
+		resolveThresholds[problemType] = getCountFromEnv(resolveThreshold.env, resolveThreshold.defaultValue)
+	}
+
+	return resolveThresholds
+}
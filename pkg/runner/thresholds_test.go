@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholdConfigFromEnv(t *testing.T) {
+	t.Setenv("NODE_CONDITION_THRESHOLD", "2")
+	t.Setenv("NODE_PRESSURE_THRESHOLD", "20")
+	t.Setenv("POD_STATUS_THRESHOLD", "3")
+	t.Setenv("POD_RESTARTS_THRESHOLD", "invalid")
+	t.Setenv("POD_PENDING_THRESHOLD", "0")
+
+	thresholds := NewThresholdConfigFromEnv()
+	expected := map[problemType]int{
+		problemTypeNodeCondition:        2,
+		problemTypeNodeResourcePressure: 20,
+		problemTypePodStatus:            3,
+		// Invalid values fall back to the defaults
+		problemTypePodRestarts: 1,
+		problemTypePodPending:  30,
+	}
+	for problemType, threshold := range expected {
+		if thresholds.Get(problemType) != threshold {
+			t.Fatalf("Expected threshold %d for %s, got %d", threshold, problemType, thresholds.Get(problemType))
+		}
+	}
+}
+
+func TestReportProblemThreshold(t *testing.T) {
+	thresholds := &ThresholdConfig{
+		NodeCondition:        1,
+		NodeResourcePressure: 2,
+		PodStatus:            3,
+		PodRestarts:          4,
+		PodPending:           5,
+	}
+
+	for _, problemType := range []problemType{problemTypeNodeCondition, problemTypeNodeResourcePressure, problemTypePodStatus, problemTypePodRestarts, problemTypePodPending} {
+		notifier := &recordingNotifier{}
+		r := &Runner{notifier: notifier, thresholds: thresholds, problems: make(map[string]*problemDesc)}
+
+		threshold := thresholds.Get(problemType)
+		for i := 1; i <= threshold; i++ {
+			err := r.reportProblem(&problemDesc{
+				problemType: problemType,
+				kind:        resourceKindPod,
+				name:        "pod",
+				namespace:   "default",
+				id:          "default/pod/" + string(problemType),
+				message:     "Problem " + string(problemType),
+				occured:     time.Now(),
+			})
+			r.flushReports()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if i < threshold && len(notifier.alerts) != 0 {
+				t.Fatalf("%s: expected no alert after %d of %d occurrences", problemType, i, threshold)
+			}
+		}
+
+		if len(notifier.alerts) != 1 {
+			t.Fatalf("%s: expected 1 alert after %d occurrences, got %d", problemType, threshold, len(notifier.alerts))
+		}
+	}
+}
+
+func TestResolveThresholdsFromEnv(t *testing.T) {
+	t.Setenv("NODE_PRESSURE_RESOLVE_THRESHOLD", "3")
+	t.Setenv("POD_STATUS_RESOLVE_THRESHOLD", "invalid")
+
+	resolveThresholds := newResolveThresholdsFromEnv()
+	expected := map[problemType]int{
+		problemTypeNodeResourcePressure: 3,
+		problemTypeNodeDiskPressure:     3,
+		// Invalid values fall back to the defaults
+		problemTypePodStatus:  10,
+		problemTypePodPending: 10,
+	}
+	for problemType, resolveThreshold := range expected {
+		if resolveThresholds[problemType] != resolveThreshold {
+			t.Fatalf("Expected resolve threshold %d for %s, got %d", resolveThreshold, problemType, resolveThresholds[problemType])
+		}
+	}
+
+	// Problem types without a resolve threshold are resolved immediately
+	if _, ok := resolveThresholds[problemTypeNodeCondition]; ok {
+		t.Fatalf("Expected no resolve threshold for %s", problemTypeNodeCondition)
+	}
+}
+
+func TestImmediateResolve(t *testing.T) {
+	resolveThresholds := newResolveThresholdsFromEnv()
+	for problemType := range immediateResolve {
+		if _, ok := resolveThresholds[problemType]; ok {
+			t.Fatalf("Expected no resolve threshold for %s, which is resolved immediately", problemType)
+		}
+	}
+
+	r := newTestRunner(&recordingNotifier{})
+	r.resolveThresholds = resolveThresholds
+	r.problems["node/condition"] = &problemDesc{problemType: problemTypeNodeCondition, kind: resourceKindNode, name: "node", id: "node/condition", occured: time.Now()}
+	r.problems["default/pod/status"] = &problemDesc{problemType: problemTypePodStatus, kind: resourceKindPod, name: "pod", namespace: "default", id: "default/pod/status", occured: time.Now()}
+	err := r.resolveProblemsOf(resourceKindNode, "node", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.resolveProblemsOf(resourceKindPod, "pod", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.problems["node/condition"] != nil {
+		t.Fatal("Expected the node condition to be resolved immediately")
+	} else if r.problems["default/pod/status"] == nil {
+		t.Fatal("Expected the pod status to wait for its resolve threshold")
+	}
+}
@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics"
+)
+
+// cpuThrottleRatio is the ratio of the cpu limit above which a container is probably throttled
+const cpuThrottleRatio = 0.9
+
+// getPodMetrics returns the metrics of the pods in the namespace by namespace/name or nil if no metrics are available
+func (r *Runner) getPodMetrics(namespace string) map[string]*metricsapi.PodMetrics {
+	podMetrics, err := r.metricsClient.GetPodMetrics(namespace, "", "", namespace == "")
+	if err != nil {
+		log.Debug("Couldn't get metrics for pods", "namespace", namespace, "error", err)
+		return nil
+	}
+
+	podMetricsMap := make(map[string]*metricsapi.PodMetrics, len(podMetrics.Items))
+	for i := range podMetrics.Items {
+		podMetricsMap[podMetrics.Items[i].Namespace+"/"+podMetrics.Items[i].Name] = &podMetrics.Items[i]
+	}
+
+	return podMetricsMap
+}
+
+// checkCPUThrottling reports every container of the pod that uses nearly all of its cpu limit
+func (r *Runner) checkCPUThrottling(pod *v1.Pod, podMetrics *metricsapi.PodMetrics) error {
+	for _, containerMetrics := range podMetrics.Containers {
+		id := pod.Name + "/" + pod.Namespace + string(problemTypeCPUThrottling) + "/" + containerMetrics.Name
+		usage := containerMetrics.Usage[v1.ResourceCPU]
+		limit := getContainerCPULimit(pod, containerMetrics.Name)
+
+		ratio, ok := getCPUUsageRatio(usage, limit)
+		if !ok || ratio < cpuThrottleRatio {
+			err := r.resolveProblemWithID(id)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		msg := fmt.Sprintf("Container '%s' of pod '%s/%s' is using %s of its cpu limit %s (%d%%), it is probably throttled", containerMetrics.Name, pod.Namespace, pod.Name, usage.String(), limit.String(), int(ratio*100))
+		err := r.reportProblem(&problemDesc{
+			problemType: problemTypeCPUThrottling,
+
+			message: msg,
+			id:      id,
+
+			kind:      resourceKindPod,
+			name:      pod.Name,
+			namespace: pod.Namespace,
+			occured:   time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getContainerCPULimit(pod *v1.Pod, containerName string) resource.Quantity {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == containerName {
+			return container.Resources.Limits[v1.ResourceCPU]
+		}
+	}
+
+	return resource.Quantity{}
+}
+
+// getCPUUsageRatio returns the ratio of the cpu usage to the cpu limit. It returns false if there is no limit
+func getCPUUsageRatio(usage, limit resource.Quantity) (float64, bool) {
+	if limit.IsZero() {
+		return 0, false
+	}
+
+	return float64(usage.MilliValue()) / float64(limit.MilliValue()), true
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+)
+
+// defaultProblemTTL is the time after which a problem is removed, so it is alerted again if it still exists
+const defaultProblemTTL = time.Minute * 30
+
+// ProblemTTLEnvName returns the environment variable that configures the ttl of the given problem type
+func ProblemTTLEnvName(problemType string) string {
+	return toEnvName(problemType) + "_TTL"
+}
+
+// newProblemTTLsFromEnv returns the configured ttl per problem type, problem types without a ttl are omitted
+func newProblemTTLsFromEnv() map[problemType]time.Duration {
+	problemTTL := make(map[problemType]time.Duration)
+	for problemType := range defaultSeverities {
+		if os.Getenv(ProblemTTLEnvName(string(problemType))) != "" {
+			problemTTL[problemType] = getDurationFromEnv(ProblemTTLEnvName(string(problemType)), defaultProblemTTL)
+		}
+	}
+
+	return problemTTL
+}
+
+func (r *Runner) getProblemTTL(problemType problemType) time.Duration {
+	if ttl, ok := r.problemTTL[problemType]; ok {
+		return ttl
+	}
+
+	return defaultProblemTTL
+}
+
+// reportDelay is the minimum time a problem has to exist before it is reported and the setting that causes it
+type reportDelay struct {
+	delay   time.Duration
+	setting string
+}
+
+// getReportDelays returns the problem types that are not reported right away because of their threshold or timeout
+func (r *Runner) getReportDelays() map[problemType]reportDelay {
+	delays := make(map[problemType]reportDelay)
+	for problemType := range defaultSeverities {
+		if threshold := r.thresholds.Get(problemType); threshold > 1 {
+			delays[problemType] = reportDelay{delay: time.Duration(threshold-1) * defaultInterval, setting: fmt.Sprintf("threshold of %d checks", threshold)}
+		}
+	}
+
+	timeouts := map[problemType]reportDelay{
+		problemTypeDeploymentStall:      {r.deploymentStallTimeout, "DEPLOYMENT_STALL_TIMEOUT"},
+		problemTypeStatefulSetDegraded:  {r.statefulSetDegradedTimeout, "STATEFULSET_DEGRADED_TIMEOUT"},
+		problemTypeDaemonSetUnavailable: {r.daemonSetUnavailableTimeout, "DAEMONSET_UNAVAIL_TIMEOUT"},
+		problemTypeHPAAtMax:             {r.hpaAtMaxTimeout, "HPA_AT_MAX_TIMEOUT"},
+		problemTypePVCPending:           {r.pvcPendingTimeout, "PVC_PENDING_TIMEOUT"},
+	}
+	for problemType, timeout := range timeouts {
+		if timeout.delay > delays[problemType].delay {
+			delays[problemType] = timeout
+		}
+	}
+
+	return delays
+}
+
+// validateProblemTTLs returns an error if problems are not reported at least one check interval before their
+// configured ttl, because they would expire before they could ever be alerted. Problem types without a configured
+// ttl are kept long enough instead
+func (r *Runner) validateProblemTTLs() error {
+	for problemType, delay := range r.getReportDelays() {
+		ttl, ok := r.problemTTL[problemType]
+		if !ok && delay.delay+defaultInterval >= defaultProblemTTL {
+			r.problemTTL[problemType] = delay.delay + defaultProblemTTL
+			log.Info("Extending the ttl of problem type to its report delay", "problem_type", string(problemType), "ttl", r.problemTTL[problemType], "setting", delay.setting)
+		} else if ok && delay.delay+defaultInterval >= ttl {
+			return fmt.Errorf("%s problems are reported after %v (%s), which has to be at least one check interval shorter than their ttl %v (%s)", problemType, delay.delay, delay.setting, ttl, ProblemTTLEnvName(string(problemType)))
+		}
+	}
+
+	return nil
+}
+
+// expireProblem removes a problem whose ttl was reached, so it is tracked and alerted as a new problem if it still
+// exists. In contrast to resolving, it neither starts the quiet period nor adds the problem to the history.
+// Needs to be called with the problems mutex locked
+func (r *Runner) expireProblem(id string) {
+	problem := r.problems[id]
+	if problem == nil {
+		return
+	}
+
+	log.Debug("Problem expired", problem.logFields()...)
+	delete(r.problems, id)
+	prometheus.ActiveProblems.Dec(problem.metricLabels()...)
+}
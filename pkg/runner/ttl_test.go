@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/slack"
+)
+
+func TestValidateProblemTTLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		problemTTL  map[problemType]time.Duration
+		thresholds  ThresholdConfig
+		stall       time.Duration
+		expectErr   bool
+		expectedTTL map[problemType]time.Duration
+	}{
+		{
+			name:        "defaults",
+			problemTTL:  map[problemType]time.Duration{},
+			thresholds:  ThresholdConfig{PodPending: 30, PodNoProbe: 60},
+			stall:       defaultDeploymentStallTimeout,
+			expectedTTL: map[problemType]time.Duration{problemTypePodPending: time.Minute * 59, problemTypePodNoProbe: time.Minute * 89, problemTypeDeploymentStall: defaultProblemTTL},
+		},
+		{
+			name:        "timeout longer than the default ttl",
+			problemTTL:  map[problemType]time.Duration{},
+			stall:       time.Hour,
+			expectedTTL: map[problemType]time.Duration{problemTypeDeploymentStall: time.Minute * 90},
+		},
+		{
+			name:        "timeout shorter than the configured ttl",
+			problemTTL:  map[problemType]time.Duration{problemTypeDeploymentStall: time.Hour * 2},
+			stall:       time.Hour,
+			expectedTTL: map[problemType]time.Duration{problemTypeDeploymentStall: time.Hour * 2},
+		},
+		{
+			name:       "timeout longer than the configured ttl",
+			problemTTL: map[problemType]time.Duration{problemTypeDeploymentStall: time.Minute * 10},
+			stall:      time.Minute * 15,
+			expectErr:  true,
+		},
+		{
+			name:       "threshold longer than the configured ttl",
+			problemTTL: map[problemType]time.Duration{problemTypePodPending: time.Minute * 10},
+			thresholds: ThresholdConfig{PodPending: 30},
+			expectErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		thresholds := test.thresholds
+		r := &Runner{
+			problemTTL:             test.problemTTL,
+			thresholds:             &thresholds,
+			deploymentStallTimeout: test.stall,
+		}
+
+		err := r.validateProblemTTLs()
+		if (err != nil) != test.expectErr {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		for problemType, expected := range test.expectedTTL {
+			if ttl := r.getProblemTTL(problemType); ttl != expected {
+				t.Fatalf("%s: expected ttl %v for %s, got %v", test.name, expected, problemType, ttl)
+			}
+		}
+	}
+}
+
+func TestExpireProblem(t *testing.T) {
+	r := newTestRunner(slack.NewMockClient())
+	r.quietPeriod = map[problemType]time.Duration{problemTypePodStatus: time.Hour}
+
+	problem := newPodProblem("pod")
+	err := r.reportProblem(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.problemsMutex.Lock()
+	r.expireProblem(problem.id)
+	r.problemsMutex.Unlock()
+
+	if len(r.problems) != 0 {
+		t.Fatalf("expected the problem to be removed, got %d problems", len(r.problems))
+	}
+	if _, ok := r.quietUntil[problem.id]; ok {
+		t.Fatal("expected no quiet period after the problem expired")
+	}
+	if resolved := r.history.list(); len(resolved) != 0 {
+		t.Fatalf("expected no resolved problems after the problem expired, got %d", len(resolved))
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// getUnreadyContainer returns the first running container of the pod that is not ready and since when the pod is
+// not ready. The time is taken from the pod's Ready condition and falls back to the start of the container
+func getUnreadyContainer(pod *v1.Pod) (string, time.Time, bool) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Ready || containerStatus.State.Running == nil {
+			continue
+		}
+
+		since := containerStatus.State.Running.StartedAt.Time
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == v1.PodReady && condition.Status != v1.ConditionTrue && condition.LastTransitionTime.After(since) {
+				since = condition.LastTransitionTime.Time
+			}
+		}
+
+		return containerStatus.Name, since, true
+	}
+
+	return "", time.Time{}, false
+}
+
+// getPodUnreadyProblem returns a problem if a container of the running pod is not passing its readiness checks for
+// longer than the pod unready timeout
+func (r *Runner) getPodUnreadyProblem(pod *v1.Pod) *problemDesc {
+	if pod.Status.Phase != v1.PodRunning {
+		return nil
+	}
+
+	container, since, ok := getUnreadyContainer(pod)
+	if !ok || time.Since(since) <= r.podUnreadyTimeout {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Container '%s' of pod '%s/%s' is running but not ready for %v", container, pod.Namespace, pod.Name, time.Since(since).Round(time.Second))
+	return &problemDesc{
+		problemType: problemTypePodUnready,
+
+		message: msg,
+		id:      pod.Name + "/" + pod.Namespace + string(problemTypePodUnready),
+
+		kind:      resourceKindPod,
+		name:      pod.Name,
+		namespace: pod.Namespace,
+		occured:   time.Now(),
+	}
+}
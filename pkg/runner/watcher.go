@@ -0,0 +1,191 @@
+package runner
+
+import (
+	"sync"
+
+	"github.com/FabianKramm/kube-problem/pkg/kube"
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// resourceListWatch lists and watches a resource of a namespace. It holds the informer watchWithReconnect runs for it,
+// which keeps a local copy of the objects up to date. The objects are listed once and afterwards only the add, update
+// and delete events the api server pushes are processed, which is a lot cheaper than listing everything on every check
+type resourceListWatch struct {
+	cache.ListWatch
+
+	resource  string
+	namespace string
+
+	informerMutex sync.Mutex
+	informer      cache.SharedIndexInformer
+	started       chan struct{}
+}
+
+func newResourceListWatch(resource, namespace string, list cache.ListFunc, watch cache.WatchFunc) *resourceListWatch {
+	return &resourceListWatch{
+		ListWatch: cache.ListWatch{ListFunc: list, WatchFunc: watch},
+
+		resource:  resource,
+		namespace: namespace,
+
+		started: make(chan struct{}),
+	}
+}
+
+// newNodeListWatch returns the list watch of the nodes matching the selector
+func newNodeListWatch(client kube.Client, selector labels.Selector) *resourceListWatch {
+	return newResourceListWatch("nodes", "", func(options metav1.ListOptions) (runtime.Object, error) {
+		options.LabelSelector = selector.String()
+		return client.Client().CoreV1().Nodes().List(options)
+	}, func(options metav1.ListOptions) (watch.Interface, error) {
+		options.LabelSelector = selector.String()
+		return client.Client().CoreV1().Nodes().Watch(options)
+	})
+}
+
+// newPodListWatch returns the list watch of the pods of the namespace matching the selectors
+func newPodListWatch(client kube.Client, namespace string, selector labels.Selector, fieldSelector fields.Selector) *resourceListWatch {
+	return newResourceListWatch("pods", namespace, func(options metav1.ListOptions) (runtime.Object, error) {
+		options.LabelSelector = selector.String()
+		options.FieldSelector = fieldSelector.String()
+		return client.Client().CoreV1().Pods(namespace).List(options)
+	}, func(options metav1.ListOptions) (watch.Interface, error) {
+		options.LabelSelector = selector.String()
+		options.FieldSelector = fieldSelector.String()
+		return client.Client().CoreV1().Pods(namespace).Watch(options)
+	})
+}
+
+func (l *resourceListWatch) describe() (string, string) {
+	return l.resource, l.namespace
+}
+
+func (l *resourceListWatch) setInformer(informer cache.SharedIndexInformer) {
+	l.informerMutex.Lock()
+	defer l.informerMutex.Unlock()
+
+	l.informer = informer
+	close(l.started)
+}
+
+func (l *resourceListWatch) getInformer() cache.SharedIndexInformer {
+	l.informerMutex.Lock()
+	defer l.informerMutex.Unlock()
+
+	return l.informer
+}
+
+// WaitForSync blocks until the informer has retrieved the initial list or stopChan is closed
+func (l *resourceListWatch) WaitForSync(stopChan <-chan struct{}) {
+	select {
+	case <-l.started:
+		cache.WaitForCacheSync(stopChan, l.getInformer().HasSynced)
+	case <-stopChan:
+	}
+}
+
+// Objects returns all currently known objects
+func (l *resourceListWatch) Objects() []interface{} {
+	informer := l.getInformer()
+	if informer == nil {
+		return nil
+	}
+
+	return informer.GetStore().List()
+}
+
+// Get returns the object with the key (namespace/name or name for cluster scoped resources) or nil if it is not known
+func (l *resourceListWatch) Get(key string) interface{} {
+	informer := l.getInformer()
+	if informer == nil {
+		return nil
+	}
+
+	obj, exists, err := informer.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		return nil
+	}
+
+	return obj
+}
+
+// podEventHandler returns the handler of the pod informers. OOMKills are recorded as soon as the pod is updated, so
+// several OOMKills between two checks are all counted, and the problems of deleted pods are resolved right away
+func (r *Runner) podEventHandler() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*v1.Pod); ok {
+				r.recordOOMKills(pod)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if pod, ok := newObj.(*v1.Pod); ok {
+				r.recordOOMKills(pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			pod, ok := getDeletedObject(obj).(*v1.Pod)
+			if !ok {
+				return
+			}
+
+			err := r.resolveProblemsOf(resourceKindPod, pod.Name, pod.Namespace)
+			if err != nil {
+				log.Error("Error resolving the problems of a deleted pod", "namespace", pod.Namespace, "pod", pod.Name, "error", err)
+			}
+		},
+	}
+}
+
+// nodeEventHandler returns the handler of the node informer. The ready status of the nodes is updated as soon as it
+// changes, so pods on not ready nodes are detected without waiting for the next node check, and the problems of
+// deleted nodes are resolved right away
+func (r *Runner) nodeEventHandler() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if node, ok := obj.(*v1.Node); ok {
+				r.updateNodeReadyStatus(node.Name, isNodeReady(node))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			node, ok := newObj.(*v1.Node)
+			if !ok {
+				return
+			}
+
+			r.updateNodeReadyStatus(node.Name, isNodeReady(node))
+			if oldNode, ok := oldObj.(*v1.Node); ok && isNodeReady(oldNode) != isNodeReady(node) {
+				r.recordNodeTransitions(node)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			node, ok := getDeletedObject(obj).(*v1.Node)
+			if !ok {
+				return
+			}
+
+			r.deleteNodeReadyStatus(node.Name)
+			r.deleteNodeTransitions(node.Name)
+			err := r.resolveProblemsOf(resourceKindNode, node.Name, "")
+			if err != nil {
+				log.Error("Error resolving the problems of a deleted node", "node", node.Name, "error", err)
+			}
+		},
+	}
+}
+
+// getDeletedObject returns the deleted object, which is wrapped in a tombstone if the informer missed the delete event
+func getDeletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+
+	return obj
+}
@@ -0,0 +1,170 @@
+package runner
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// recordingNotifier records the ids of the sent alerts and resolves and the messages of the alerts
+type recordingNotifier struct {
+	mutex         sync.Mutex
+	alerts        []string
+	alertMessages []string
+	resolves      []string
+}
+
+func (n *recordingNotifier) Alert(p notify.Problem) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.alerts = append(n.alerts, p.ID)
+	n.alertMessages = append(n.alertMessages, p.Message)
+	return nil
+}
+
+func (n *recordingNotifier) Resolve(p notify.Problem) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.resolves = append(n.resolves, p.ID)
+	return nil
+}
+
+func TestPodEventHandlerDelete(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+
+	tests := []struct {
+		name string
+		obj  interface{}
+	}{
+		{
+			name: "deleted pod",
+			obj:  pod,
+		},
+		{
+			name: "missed delete",
+			obj:  cache.DeletedFinalStateUnknown{Key: "default/pod", Obj: pod},
+		},
+	}
+
+	for _, test := range tests {
+		notifier := &recordingNotifier{}
+		r := &Runner{notifier: notifier, problems: make(map[string]*problemDesc), history: newProblemHistory(defaultProblemHistorySize), resolveThresholds: newResolveThresholdsFromEnv()}
+		r.problems["default/pod/status"] = &problemDesc{
+			problemType: problemTypePodStatus,
+			kind:        resourceKindPod,
+			name:        "pod",
+			namespace:   "default",
+			id:          "default/pod/status",
+			message:     "Pod default/pod has critical status CrashLoopBackOff",
+			occured:     time.Now(),
+			reported:    true,
+			// The critical status is resolved after 10 checks without the problem
+			resolvedCounter: 9,
+		}
+
+		r.podEventHandler().OnDelete(test.obj)
+		r.flushReports()
+		if len(r.problems) != 0 {
+			t.Fatalf("%s: expected the problem to be resolved", test.name)
+		} else if len(notifier.resolves) != 1 {
+			t.Fatalf("%s: expected 1 resolve message, got %d", test.name, len(notifier.resolves))
+		}
+	}
+}
+
+func TestNodeEventHandlerDelete(t *testing.T) {
+	notifier := &recordingNotifier{}
+	r := &Runner{notifier: notifier, problems: make(map[string]*problemDesc), history: newProblemHistory(defaultProblemHistorySize)}
+	r.problems["node/condition"] = &problemDesc{
+		problemType: problemTypeNodeCondition,
+		kind:        resourceKindNode,
+		name:        "node",
+		id:          "node/condition",
+		message:     "Node node is not ready",
+		occured:     time.Now(),
+		reported:    true,
+	}
+
+	r.nodeEventHandler().OnDelete(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}})
+	r.flushReports()
+	if len(r.problems) != 0 {
+		t.Fatal("Expected the problem to be resolved")
+	} else if len(notifier.resolves) != 1 {
+		t.Fatalf("Expected 1 resolve message, got %d", len(notifier.resolves))
+	}
+}
+
+func TestPodEventHandlerRecordsOOMKills(t *testing.T) {
+	r := &Runner{oomKillRates: make(map[string]*oomKillRate), oomKillRateWindow: time.Minute * 5}
+	oomKilledPod := func(restarts int32, finished time.Time) *v1.Pod {
+		pod := newOOMKilledPod(v1.PodRunning, v1.ContainerState{Running: &v1.ContainerStateRunning{}}, v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(finished)}})
+		pod.Status.ContainerStatuses[0].RestartCount = restarts
+		return pod
+	}
+
+	// Both oomkills are counted although the pod was only checked after the second one
+	first := oomKilledPod(1, time.Now().Add(-time.Minute*2))
+	second := oomKilledPod(2, time.Now().Add(-time.Minute))
+	r.podEventHandler().OnAdd(first)
+	r.podEventHandler().OnUpdate(first, second)
+	if oomKills := r.recordOOMKills(second); oomKills != 2 {
+		t.Fatalf("Expected 2 oomkills, got %d", oomKills)
+	}
+}
+
+func TestNodeEventHandlerReadyStatus(t *testing.T) {
+	r := &Runner{problems: make(map[string]*problemDesc), history: newProblemHistory(defaultProblemHistorySize)}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}},
+	}
+	notReadyNode := node.DeepCopy()
+	notReadyNode.Status.Conditions[0].Status = v1.ConditionFalse
+
+	r.nodeEventHandler().OnAdd(node)
+	if r.isNodeNotReady("node") {
+		t.Fatal("Expected the added node to be ready")
+	}
+
+	r.nodeEventHandler().OnUpdate(node, notReadyNode)
+	if !r.isNodeNotReady("node") {
+		t.Fatal("Expected the node to be not ready right after the update")
+	}
+
+	r.nodeEventHandler().OnDelete(notReadyNode)
+	if r.isNodeNotReady("node") {
+		t.Fatal("Expected the deleted node to be unknown")
+	}
+}
+
+func TestNodeEventHandlerRecordsFlapping(t *testing.T) {
+	r := &Runner{nodeConditionHistory: make(map[string][]time.Time), nodeFlapWindow: time.Minute * 10}
+	node := func(status v1.ConditionStatus, transition time.Time) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(transition)}}},
+		}
+	}
+
+	// The node is not ready twice between two checks, which only see the ready node
+	ready := node(v1.ConditionTrue, time.Now().Add(-time.Minute*5))
+	notReady := node(v1.ConditionFalse, time.Now().Add(-time.Minute*3))
+	readyAgain := node(v1.ConditionTrue, time.Now().Add(-time.Minute*2))
+	notReadyAgain := node(v1.ConditionFalse, time.Now().Add(-time.Minute))
+	readyNow := node(v1.ConditionTrue, time.Now())
+
+	r.nodeEventHandler().OnUpdate(ready, notReady)
+	r.nodeEventHandler().OnUpdate(notReady, readyAgain)
+	r.nodeEventHandler().OnUpdate(readyAgain, notReadyAgain)
+	r.nodeEventHandler().OnUpdate(notReadyAgain, readyNow)
+	if transitions := r.recordNodeTransitions(readyNow); transitions != 2 {
+		t.Fatalf("Expected 2 transitions to not ready, got %d", transitions)
+	}
+}
@@ -0,0 +1,36 @@
+package slack
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/version"
+	slackapi "github.com/nlopes/slack"
+)
+
+func newProblemBlocks(p notify.Problem) []slackapi.Block {
+	return newBlocks(fmt.Sprintf("%s *Problem with %s* (%s) _kube-problem %s_", getSeverityEmoji(p.Severity), p.Resource(), p.Severity, version.Version), p, p.Message)
+}
+
+func newResolveBlocks(p notify.Problem) []slackapi.Block {
+	return newBlocks(fmt.Sprintf(":white_check_mark: *Resolved problem with %s* _kube-problem %s_", p.Resource(), version.Version), p, "Good news, seems like this is not a problem anymore :tada:\n>"+p.Message)
+}
+
+func newBlocks(header string, p notify.Problem, message string) []slackapi.Block {
+	details := []slackapi.MixedElement{
+		slackapi.NewTextBlockObject(slackapi.MarkdownType, fmt.Sprintf("*Kind:* %s", p.Kind), false, false),
+		slackapi.NewTextBlockObject(slackapi.MarkdownType, fmt.Sprintf("*Name:* %s", p.Name), false, false),
+	}
+	if p.Namespace != "" {
+		details = append(details, slackapi.NewTextBlockObject(slackapi.MarkdownType, fmt.Sprintf("*Namespace:* %s", p.Namespace), false, false))
+	}
+	details = append(details, slackapi.NewTextBlockObject(slackapi.MarkdownType, fmt.Sprintf("*Time:* %s", time.Now().Format(time.RFC1123)), false, false))
+
+	return []slackapi.Block{
+		slackapi.NewSectionBlock(slackapi.NewTextBlockObject(slackapi.MarkdownType, header, false, false), nil, nil),
+		slackapi.NewContextBlock("", details...),
+		slackapi.NewSectionBlock(slackapi.NewTextBlockObject(slackapi.MarkdownType, message, false, false), nil, nil),
+		slackapi.NewContextBlock("", slackapi.NewTextBlockObject(slackapi.MarkdownType, getGreeting()+" I thought you should know :wink:", false, false)),
+	}
+}
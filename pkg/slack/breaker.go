@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling the slack api while the circuit breaker is open
+var ErrCircuitOpen = errors.New("slack circuit breaker is open, not sending message")
+
+const (
+	// DefaultCircuitBreakerThreshold is the default number of consecutive failures that open the circuit
+	DefaultCircuitBreakerThreshold = 5
+	// DefaultCircuitBreakerTimeout is the default time the circuit stays open before a call is tried again
+	DefaultCircuitBreakerTimeout = time.Second * 30
+
+	circuitBreakerWindow = time.Second * 60
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops calling the slack api after too many consecutive failures
+type circuitBreaker struct {
+	threshold int
+	timeout   time.Duration
+
+	// now returns the current time and is replaced in tests
+	now func() time.Time
+
+	mutex        sync.Mutex
+	state        circuitState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+}
+
+func newCircuitBreaker(threshold int, timeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		timeout:   timeout,
+		now:       time.Now,
+	}
+}
+
+// allow returns ErrCircuitOpen if no call should be made. After the timeout a single call is allowed
+func (b *circuitBreaker) allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.timeout {
+			return ErrCircuitOpen
+		}
+
+		b.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		// Only the single trial call is allowed
+		return ErrCircuitOpen
+	}
+
+	return nil
+}
+
+func (b *circuitBreaker) success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.state = circuitClosed
+	b.failures = 0
+}
+
+func (b *circuitBreaker) failure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+		b.openedAt = now
+		return
+	}
+
+	// Only failures within the window count as consecutive
+	if b.failures == 0 || now.Sub(b.firstFailure) > circuitBreakerWindow {
+		b.failures = 0
+		b.firstFailure = now
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.state = circuitOpen
+		b.openedAt = now
+		b.failures = 0
+	}
+}
@@ -0,0 +1,157 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	slackapi "github.com/nlopes/slack"
+)
+
+// newTestBreaker returns a circuit breaker with a clock that is advanced by the returned function
+func newTestBreaker(threshold int, timeout time.Duration) (*circuitBreaker, func(time.Duration)) {
+	now := time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC)
+	breaker := newCircuitBreaker(threshold, timeout)
+	breaker.now = func() time.Time {
+		return now
+	}
+
+	return breaker, func(d time.Duration) {
+		now = now.Add(d)
+	}
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	breaker, _ := newTestBreaker(3, time.Second*30)
+	for i := 0; i < 3; i++ {
+		if err := breaker.allow(); err != nil {
+			t.Fatalf("Expected the closed circuit to allow call %d, got %v", i+1, err)
+		}
+
+		breaker.failure()
+	}
+
+	// closed -> open
+	if breaker.state != circuitOpen {
+		t.Fatalf("Expected the circuit to be open after 3 consecutive failures, got state %d", breaker.state)
+	} else if err := breaker.allow(); err != ErrCircuitOpen {
+		t.Fatalf("Expected ErrCircuitOpen from the open circuit, got %v", err)
+	}
+}
+
+func TestCircuitBreakerFailureWindow(t *testing.T) {
+	breaker, advance := newTestBreaker(3, time.Second*30)
+	breaker.failure()
+	breaker.failure()
+
+	// The first failures are outside of the window now, so they don't count anymore
+	advance(circuitBreakerWindow + time.Second)
+	breaker.failure()
+	if breaker.state != circuitClosed {
+		t.Fatal("Expected failures outside of the window not to open the circuit")
+	}
+
+	// A success resets the consecutive failures
+	breaker.failure()
+	breaker.success()
+	breaker.failure()
+	breaker.failure()
+	if breaker.state != circuitClosed {
+		t.Fatal("Expected a success to reset the consecutive failures")
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name          string
+		trialSuccess  bool
+		expectedState circuitState
+	}{
+		{
+			name:          "trial call succeeds",
+			trialSuccess:  true,
+			expectedState: circuitClosed,
+		},
+		{
+			name:          "trial call fails",
+			trialSuccess:  false,
+			expectedState: circuitOpen,
+		},
+	}
+
+	for _, test := range tests {
+		breaker, advance := newTestBreaker(1, time.Second*30)
+		breaker.failure()
+
+		advance(time.Second * 29)
+		if err := breaker.allow(); err != ErrCircuitOpen {
+			t.Fatalf("%s: expected the circuit to stay open before the timeout, got %v", test.name, err)
+		}
+
+		// open -> half open, only a single trial call is allowed
+		advance(time.Second)
+		if err := breaker.allow(); err != nil {
+			t.Fatalf("%s: expected a trial call after the timeout, got %v", test.name, err)
+		} else if breaker.state != circuitHalfOpen {
+			t.Fatalf("%s: expected the circuit to be half open, got state %d", test.name, breaker.state)
+		} else if err := breaker.allow(); err != ErrCircuitOpen {
+			t.Fatalf("%s: expected only a single trial call, got %v", test.name, err)
+		}
+
+		// half open -> closed or open
+		if test.trialSuccess {
+			breaker.success()
+		} else {
+			breaker.failure()
+		}
+		if breaker.state != test.expectedState {
+			t.Fatalf("%s: expected state %d, got %d", test.name, test.expectedState, breaker.state)
+		}
+
+		err := breaker.allow()
+		if test.trialSuccess && err != nil {
+			t.Fatalf("%s: expected the closed circuit to allow calls, got %v", test.name, err)
+		} else if !test.trialSuccess && err != ErrCircuitOpen {
+			t.Fatalf("%s: expected the reopened circuit to reject calls, got %v", test.name, err)
+		}
+	}
+}
+
+func TestSendMessageCircuitBreaker(t *testing.T) {
+	var (
+		requests      int
+		requestsMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestsMutex.Lock()
+		requests++
+		requestsMutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":false,"error":"internal_error"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient("token", "channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.API = slackapi.New("token", slackapi.OptionAPIURL(server.URL+"/"))
+	client.SetCircuitBreaker(2, time.Minute)
+
+	for i := 0; i < 5; i++ {
+		err = client.SendMessage("", "message")
+		if i < 2 && (err == nil || err == ErrCircuitOpen) {
+			t.Fatalf("Expected the slack error for message %d, got %v", i+1, err)
+		} else if i >= 2 && err != ErrCircuitOpen {
+			t.Fatalf("Expected ErrCircuitOpen for message %d, got %v", i+1, err)
+		}
+	}
+
+	if requests != 2 {
+		t.Fatalf("Expected 2 requests to slack before the circuit opened, got %d", requests)
+	}
+}
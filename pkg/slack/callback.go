@@ -0,0 +1,90 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	slackapi "github.com/nlopes/slack"
+)
+
+// AcknowledgeActionID is the action id of the acknowledge button
+const AcknowledgeActionID = "acknowledge"
+
+// AcknowledgeFunc is called with the problem id when a user clicks the acknowledge button
+type AcknowledgeFunc func(problemID string) error
+
+// NewCallbackHandler creates a new http handler for slack's interactive components callback.
+// Requests are verified with the slack signing secret
+func (c *Client) NewCallbackHandler(signingSecret string, acknowledge AcknowledgeFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Verify the request was sent by slack
+		verifier, err := slackapi.NewSecretsVerifier(req.Header, signingSecret)
+		if err != nil {
+			log.Warn("Error verifying slack callback", "error", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		verifier.Write(body)
+		err = verifier.Ensure()
+		if err != nil {
+			log.Warn("Error verifying slack callback", "error", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		callback := slackapi.InteractionCallback{}
+		err = json.Unmarshal([]byte(values.Get("payload")), &callback)
+		if err != nil {
+			log.Warn("Error parsing slack callback payload", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if callback.Type == slackapi.InteractionTypeBlockActions {
+			for _, action := range callback.ActionCallback.BlockActions {
+				if action.ActionID != AcknowledgeActionID {
+					continue
+				}
+
+				err = acknowledge(action.Value)
+				if err != nil {
+					log.Error("Error acknowledging problem", "problem_id", action.Value, "error", err)
+					continue
+				}
+
+				log.Info("User acknowledged problem", "user", callback.User.Name, "problem_id", action.Value)
+				err = c.sendMessage(callback.Channel.ID, newTextOption(fmt.Sprintf("<@%s> acknowledged the problem :ok_hand:", callback.User.ID)), slackapi.MsgOptionTS(callback.Message.Timestamp))
+				if err != nil {
+					log.Error("Error sending acknowledge message", "problem_id", action.Value, "error", err)
+				}
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func newAcknowledgeBlock(problemID string) slackapi.Block {
+	return slackapi.NewActionBlock("", slackapi.NewButtonBlockElement(AcknowledgeActionID, problemID, slackapi.NewTextBlockObject(slackapi.PlainTextType, "Acknowledge", false, false)))
+}
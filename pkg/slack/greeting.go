@@ -0,0 +1,64 @@
+package slack
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var greetings = []string{
+	"Guys real talk :point_up:,",
+	"It's me again, the lovely bot from the neighborhood and",
+	"Alright, so",
+	"Yo bois :dark_sunglasses:,",
+	"Sorry to interrupt,",
+	"I'm back :v:,",
+	"Yes I know I'm annoying :grin:, but",
+	"Where is the cluster admin :face_with_monocle:, because",
+	"I just wanted to chill :expressionless: and then I checked the cluster one more time and",
+	"What would you do without me? I just checked the cluster again and",
+}
+
+// customGreetings replace the built-in greetings if not empty
+var customGreetings []string
+var customGreetingsMutex sync.RWMutex
+
+// SetGreetings replaces the built-in greetings, an empty list restores them
+func SetGreetings(greetings []string) {
+	customGreetingsMutex.Lock()
+	defer customGreetingsMutex.Unlock()
+
+	customGreetings = greetings
+}
+
+func getGreeting() string {
+	rand.Seed(time.Now().Unix())
+
+	customGreetingsMutex.RLock()
+	defer customGreetingsMutex.RUnlock()
+	if len(customGreetings) > 0 {
+		return customGreetings[rand.Intn(len(customGreetings))]
+	}
+
+	num := rand.Intn(len(greetings) + 1)
+	if num == len(greetings) {
+		now := time.Now()
+		if now.Weekday() == time.Sunday {
+			return "Damn sorry to interrupt your Sunday :face_with_rolling_eyes:, but"
+		} else if now.Weekday() == time.Saturday {
+			return "Yes I know it's weekend, but"
+		}
+
+		if now.Hour() < 12 {
+			return "Good morning everyone :wave:,"
+		} else if now.Hour() < 15 {
+			return "Hello everyone :wave:,"
+		} else if now.Hour() < 18 {
+			return "Good afternoon everyone :wave:,"
+		}
+
+		return "Good evening everyone :wave:,"
+	}
+
+	return greetings[num]
+}
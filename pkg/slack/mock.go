@@ -0,0 +1,94 @@
+package slack
+
+import (
+	"sync"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+// TestingT is the part of testing.T the assertions of the MockClient need
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// MockClient is a notifier that records the alerts and resolves instead of sending them to slack,
+// which can be used to test the runner without a slack token
+type MockClient struct {
+	mutex    sync.Mutex
+	alerts   []notify.Problem
+	resolves []notify.Problem
+	messages []string
+}
+
+// NewMockClient creates a new mock client
+func NewMockClient() *MockClient {
+	return &MockClient{}
+}
+
+// Alert records the alert
+func (m *MockClient) Alert(p notify.Problem) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.alerts = append(m.alerts, p)
+	return nil
+}
+
+// Resolve records the resolve
+func (m *MockClient) Resolve(p notify.Problem) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.resolves = append(m.resolves, p)
+	return nil
+}
+
+// SendMessage records the message, the channel is ignored
+func (m *MockClient) SendMessage(channel, message string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Alerts returns the recorded alerts
+func (m *MockClient) Alerts() []notify.Problem {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]notify.Problem{}, m.alerts...)
+}
+
+// Resolves returns the recorded resolves
+func (m *MockClient) Resolves() []notify.Problem {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]notify.Problem{}, m.resolves...)
+}
+
+// Messages returns the recorded messages
+func (m *MockClient) Messages() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]string{}, m.messages...)
+}
+
+// AssertAlertCount fails the test if not exactly n alerts were recorded
+func (m *MockClient) AssertAlertCount(t TestingT, n int) {
+	t.Helper()
+	if alerts := m.Alerts(); len(alerts) != n {
+		t.Errorf("expected %d alerts, got %d: %v", n, len(alerts), alerts)
+	}
+}
+
+// AssertResolveCount fails the test if not exactly n resolves were recorded
+func (m *MockClient) AssertResolveCount(t TestingT, n int) {
+	t.Helper()
+	if resolves := m.Resolves(); len(resolves) != n {
+		t.Errorf("expected %d resolves, got %d: %v", n, len(resolves), resolves)
+	}
+}
@@ -0,0 +1,50 @@
+package slack
+
+import (
+	"strings"
+	"time"
+
+	slackapi "github.com/nlopes/slack"
+)
+
+const (
+	// DefaultRetryMax is the default number of times a failed slack request is retried
+	DefaultRetryMax = 5
+	// DefaultRetryBase is the default backoff before the first retry
+	DefaultRetryBase = time.Second
+	// DefaultRetryMaxBackoff is the default maximum backoff between two retries
+	DefaultRetryMaxBackoff = time.Second * 30
+)
+
+// shouldRetry returns if the request should be retried and how long slack asked us to wait before doing so
+// (zero if the backoff should be used)
+func shouldRetry(err error) (bool, time.Duration) {
+	if err == nil {
+		return false, 0
+	}
+
+	if rateLimitedError, ok := err.(*slackapi.RateLimitedError); ok {
+		return true, rateLimitedError.RetryAfter
+	}
+
+	return isNetErrorRetryable(err), 0
+}
+
+// isNetErrorRetryable - is network error retryable.
+func isNetErrorRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if strings.Contains(err.Error(), "Connection closed by foreign host") {
+		return true
+	} else if strings.Contains(err.Error(), "net/http: TLS handshake timeout") {
+		return true
+	} else if strings.Contains(err.Error(), "i/o timeout") {
+		return true
+	} else if strings.Contains(err.Error(), "connection timed out") {
+		return true
+	}
+
+	return false
+}
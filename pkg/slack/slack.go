@@ -2,9 +2,15 @@ package slack
 
 import (
 	"errors"
-	"log"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/FabianKramm/kube-problem/pkg/log"
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/prometheus"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+	"github.com/FabianKramm/kube-problem/pkg/version"
 	slackapi "github.com/nlopes/slack"
 )
 
@@ -12,6 +18,14 @@ import (
 type Client struct {
 	API     *slackapi.Client
 	Channel string
+
+	// RichFormat sends alerts as block kit messages instead of plain text
+	RichFormat bool
+	// Interactive adds an acknowledge button to alerts, which requires a callback handler
+	Interactive bool
+
+	breaker *circuitBreaker
+	retry   retry.Policy
 }
 
 // NewClient creates a new slack client to use
@@ -26,44 +40,229 @@ func NewClient(token, channel string) (*Client, error) {
 	return &Client{
 		API:     slackapi.New(token),
 		Channel: channel,
+		breaker: newCircuitBreaker(DefaultCircuitBreakerThreshold, DefaultCircuitBreakerTimeout),
+		retry:   retry.Policy{Max: DefaultRetryMax, Base: DefaultRetryBase, MaxBackoff: DefaultRetryMaxBackoff},
 	}, nil
 }
 
+// SetCircuitBreaker configures after how many consecutive failures within a minute no more messages are
+// sent to slack and how long to wait until trying again
+func (c *Client) SetCircuitBreaker(threshold int, timeout time.Duration) {
+	c.breaker = newCircuitBreaker(threshold, timeout)
+}
+
+// SetRetry configures how often failed requests are retried and the exponential backoff between the retries,
+// which starts at base and is capped at maxBackoff
+func (c *Client) SetRetry(max int, base, maxBackoff time.Duration) {
+	c.retry = retry.Policy{Max: max, Base: base, MaxBackoff: maxBackoff}
+}
+
 // GetChannelInfo returns the channel info
 func (c *Client) GetChannelInfo() (*slackapi.Channel, error) {
 	return c.API.GetConversationInfo(c.Channel, false)
 }
 
-// SendMessage sends a new slack message to the channel
-func (c *Client) SendMessage(message string) error {
-	var err error
-	shouldRetry := true
-	for shouldRetry {
-		_, _, err = c.API.PostMessage(c.Channel, slackapi.MsgOptionText(message, false))
-		shouldRetry = isNetErrorRetryable(err)
-		if err != nil && shouldRetry {
-			log.Printf("Retry sending to slack due to error: %v", err)
+// channelFor returns the channel alerts of the given problem are sent to
+func (c *Client) channelFor(p notify.Problem) string {
+	return c.getChannel(p.Channel)
+}
+
+// getChannel returns the channel or the default channel if it is empty
+func (c *Client) getChannel(channel string) string {
+	if channel != "" {
+		return channel
+	}
+
+	return c.Channel
+}
+
+// withVersion prefixes the text of a plain text message with the version, block kit messages contain the version
+// in their header instead
+func withVersion(text string) string {
+	return fmt.Sprintf("[kube-problem %s] %s", version.Version, text)
+}
+
+// newTextOption returns the text of a plain text message prefixed with the version
+func newTextOption(text string) slackapi.MsgOption {
+	return slackapi.MsgOptionText(withVersion(text), false)
+}
+
+// getSeverityEmoji returns the emoji alerts of the given severity are prefixed with
+func getSeverityEmoji(severity string) string {
+	switch severity {
+	case "warning":
+		return ":large_yellow_circle:"
+	case "info":
+		return ":large_blue_circle:"
+	}
+
+	return ":red_circle:"
+}
+
+// Alert sends a problem message to the channel
+func (c *Client) Alert(p notify.Problem) error {
+	_, err := c.AlertThread(p)
+	return err
+}
+
+// AlertThread sends a problem message to the channel and returns the thread of the message in the form channel:timestamp
+func (c *Client) AlertThread(p notify.Problem) (string, error) {
+	text := withVersion(fmt.Sprintf("%s %s there seems to be a problem with %s: %s", getSeverityEmoji(p.Severity), getGreeting(), p.Resource(), p.Message))
+	if !c.RichFormat && !c.Interactive {
+		return c.postMessage(c.channelFor(p), slackapi.MsgOptionText(text, false))
+	}
+
+	blocks := []slackapi.Block{slackapi.NewSectionBlock(slackapi.NewTextBlockObject(slackapi.MarkdownType, text, false, false), nil, nil)}
+	if c.RichFormat {
+		text = fmt.Sprintf("There seems to be a problem with %s", p.Resource())
+		blocks = newProblemBlocks(p)
+	}
+	if c.Interactive {
+		blocks = append(blocks, newAcknowledgeBlock(p.ID))
+	}
+
+	return c.postMessage(c.channelFor(p), slackapi.MsgOptionText(text, false), slackapi.MsgOptionBlocks(blocks...))
+}
+
+// SendThreadMessage replies with the message in the given thread, which was returned by AlertThread
+func (c *Client) SendThreadMessage(thread, message string) error {
+	channel, timestamp, err := parseThread(thread)
+	if err != nil {
+		return err
+	}
+
+	return c.sendMessage(channel, newTextOption(message), slackapi.MsgOptionTS(timestamp))
+}
+
+// parseThread returns the channel and the timestamp of a thread in the form channel:timestamp
+func parseThread(thread string) (string, string, error) {
+	splitted := strings.SplitN(thread, ":", 2)
+	if len(splitted) != 2 {
+		return "", "", fmt.Errorf("invalid slack thread '%s'", thread)
+	}
+
+	return splitted[0], splitted[1], nil
+}
+
+// maxBatchProblems is the maximum number of problems that are listed in a batch message
+const maxBatchProblems = 20
+
+// AlertBatch sends a single message for multiple problems of the same type to the channel of the first problem
+func (c *Client) AlertBatch(problems []notify.Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	p := problems[0]
+	namespace, nodeGroup := p.Namespace, p.NodeGroup
+	names := []string{}
+	for _, problem := range problems {
+		if problem.Namespace != namespace {
+			namespace = ""
+		}
+		if problem.NodeGroup != nodeGroup {
+			nodeGroup = ""
 		}
+
+		names = append(names, problem.Name)
+	}
+
+	summary := fmt.Sprintf("%s %s there seem to be %d problems of type %s", getSeverityEmoji(p.Severity), getGreeting(), len(problems), p.Type)
+	if namespace != "" {
+		summary += fmt.Sprintf(" in namespace '%s'", namespace)
+	}
+	if nodeGroup != "" {
+		summary += fmt.Sprintf(" in node group '%s'", nodeGroup)
+	}
+	if p.Cluster != "" {
+		summary += fmt.Sprintf(" in cluster '%s'", p.Cluster)
 	}
+	if len(names) > maxBatchProblems {
+		names = append(names[:maxBatchProblems], fmt.Sprintf("and %d more", len(problems)-maxBatchProblems))
+	}
+	summary += ": " + strings.Join(names, ", ")
+
+	lines := []string{summary}
+	for i, problem := range problems {
+		if i == maxBatchProblems {
+			break
+		}
+
+		lines = append(lines, "> "+problem.Message)
+	}
+
+	return c.sendMessage(c.channelFor(p), newTextOption(strings.Join(lines, "\n")))
+}
+
+// Resolve sends a resolve message to the channel
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.sendMessage(c.channelFor(p), newResolveOptions(p, c.RichFormat)...)
+}
 
+// ResolveThread sends the resolve message as reply in the thread of the alert, which was returned by AlertThread
+func (c *Client) ResolveThread(thread string, p notify.Problem) error {
+	channel, timestamp, err := parseThread(thread)
+	if err != nil {
+		return err
+	}
+
+	return c.sendMessage(channel, append(newResolveOptions(p, c.RichFormat), slackapi.MsgOptionTS(timestamp))...)
+}
+
+func newResolveOptions(p notify.Problem, richFormat bool) []slackapi.MsgOption {
+	if richFormat {
+		return []slackapi.MsgOption{slackapi.MsgOptionText(fmt.Sprintf("The problem with %s is resolved", p.Resource()), false), slackapi.MsgOptionBlocks(newResolveBlocks(p)...)}
+	}
+
+	resource := fmt.Sprintf("%s '%s'", p.Kind, p.Name)
+	if p.Cluster != "" {
+		resource += fmt.Sprintf(" in cluster '%s'", p.Cluster)
+	}
+
+	return []slackapi.MsgOption{newTextOption(fmt.Sprintf("%s do you remember the problem with %s? Good news, seems like this is not a problem anymore :tada:", getGreeting(), resource))}
+}
+
+// SendMessage sends a new slack message to the channel or the default channel if channel is empty
+func (c *Client) SendMessage(channel, message string) error {
+	return c.sendMessage(c.getChannel(channel), newTextOption(message))
+}
+
+func (c *Client) sendMessage(channel string, options ...slackapi.MsgOption) error {
+	_, err := c.postMessage(channel, options...)
 	return err
 }
 
-// isNetErrorRetryable - is network error retryable.
-func isNetErrorRetryable(err error) bool {
-	if err == nil {
-		return false
+// postMessage sends the message and returns its thread in the form channel:timestamp
+func (c *Client) postMessage(channel string, options ...slackapi.MsgOption) (string, error) {
+	err := c.breaker.allow()
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	respChannel, timestamp := "", ""
+	for attempt := 0; ; attempt++ {
+		respChannel, timestamp, err = c.API.PostMessage(channel, options...)
+		retryable, retryAfter := shouldRetry(err)
+		if !retryable || attempt >= c.retry.Max {
+			break
+		}
+
+		backoff := retryAfter
+		if backoff == 0 {
+			backoff = c.retry.Backoff(attempt)
+		}
+
+		log.Warn("Retry sending to slack", "attempt", attempt+1, "retry_in", backoff, "error", err)
+		time.Sleep(backoff)
 	}
+	prometheus.CheckDuration.Observe(time.Since(start).Seconds(), "slack", "")
 
-	if strings.Contains(err.Error(), "Connection closed by foreign host") {
-		return true
-	} else if strings.Contains(err.Error(), "net/http: TLS handshake timeout") {
-		return true
-	} else if strings.Contains(err.Error(), "i/o timeout") {
-		return true
-	} else if strings.Contains(err.Error(), "connection timed out") {
-		return true
+	if err != nil {
+		c.breaker.failure()
+		return "", err
 	}
 
-	return false
+	c.breaker.success()
+	return respChannel + ":" + timestamp, nil
 }
@@ -0,0 +1,156 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/version"
+	slackapi "github.com/nlopes/slack"
+)
+
+// newTestClient returns a client that sends its requests to a test server, which records the channels of the
+// posted messages
+func newTestClient(t *testing.T) (*Client, func() []string) {
+	var (
+		channels      []string
+		channelsMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		channelsMutex.Lock()
+		channels = append(channels, req.FormValue("channel"))
+		channelsMutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true, "channel": "` + req.FormValue("channel") + `", "ts": "1"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient("token", "#alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.API = slackapi.New("token", slackapi.OptionAPIURL(server.URL+"/"))
+
+	return client, func() []string {
+		channelsMutex.Lock()
+		defer channelsMutex.Unlock()
+
+		return channels
+	}
+}
+
+func TestSendMessageChannel(t *testing.T) {
+	tests := []struct {
+		name            string
+		channel         string
+		expectedChannel string
+	}{
+		{
+			name:            "default channel",
+			expectedChannel: "#alerts",
+		},
+		{
+			name:            "channel override",
+			channel:         "#infra",
+			expectedChannel: "#infra",
+		},
+	}
+
+	for _, test := range tests {
+		client, channels := newTestClient(t)
+
+		err := client.SendMessage(test.channel, "Weekly digest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := channels(); len(got) != 1 || got[0] != test.expectedChannel {
+			t.Fatalf("%s: expected a message to %s, got %v", test.name, test.expectedChannel, got)
+		}
+	}
+}
+
+func TestAlertChannel(t *testing.T) {
+	tests := []struct {
+		name            string
+		problem         notify.Problem
+		expectedChannel string
+	}{
+		{
+			name:            "not routed",
+			problem:         notify.Problem{Type: "PodStatus", Kind: "Pod", Name: "pod"},
+			expectedChannel: "#alerts",
+		},
+		{
+			name:            "routed",
+			problem:         notify.Problem{Type: "NodeCondition", Kind: "Node", Name: "node", Channel: "#infra"},
+			expectedChannel: "#infra",
+		},
+	}
+
+	for _, test := range tests {
+		client, channels := newTestClient(t)
+
+		err := client.Alert(test.problem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := channels(); len(got) != 1 || got[0] != test.expectedChannel {
+			t.Fatalf("%s: expected an alert to %s, got %v", test.name, test.expectedChannel, got)
+		}
+	}
+}
+
+func TestPlainTextVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(c *Client) error
+	}{
+		{
+			name: "alert",
+			send: func(c *Client) error { return c.Alert(notify.Problem{Type: "PodStatus", Kind: "Pod", Name: "pod"}) },
+		},
+		{
+			name: "resolve",
+			send: func(c *Client) error { return c.Resolve(notify.Problem{Type: "PodStatus", Kind: "Pod", Name: "pod"}) },
+		},
+		{
+			name: "batch",
+			send: func(c *Client) error {
+				return c.AlertBatch([]notify.Problem{{Type: "PodStatus", Name: "a"}, {Type: "PodStatus", Name: "b"}})
+			},
+		},
+		{
+			name: "message",
+			send: func(c *Client) error { return c.SendMessage("", "Weekly digest") },
+		},
+	}
+
+	for _, test := range tests {
+		var text string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			text = req.FormValue("text")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok": true, "channel": "#alerts", "ts": "1"}`))
+		}))
+
+		client, err := NewClient("token", "#alerts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.API = slackapi.New("token", slackapi.OptionAPIURL(server.URL+"/"))
+
+		err = test.send(client)
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(text, "[kube-problem "+version.Version+"] ") {
+			t.Fatalf("%s: expected the text to start with the version, got %s", test.name, text)
+		}
+	}
+}
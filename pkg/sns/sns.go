@@ -0,0 +1,151 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	snsapi "github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+const (
+	eventTypeProblem = "problem"
+	eventTypeResolve = "resolve"
+)
+
+// maxSubjectLength is the maximum length of the subject of a sns message
+const maxSubjectLength = 100
+
+// publishTimeout is the maximum time to publish a message including the retries of the sns client
+const publishTimeout = time.Second * 30
+
+// publishAPI is the part of the sns client the notifier uses
+type publishAPI interface {
+	Publish(ctx context.Context, params *snsapi.PublishInput, optFns ...func(*snsapi.Options)) (*snsapi.PublishOutput, error)
+}
+
+// Client publishes problems as json to an aws sns topic
+type Client struct {
+	TopicARN string
+	Region   string
+
+	api publishAPI
+}
+
+// payload is the same json the webhook notifier posts, so subscribers can handle both
+type payload struct {
+	EventType string `json:"event_type"`
+
+	ID        string `json:"id"`
+	Type      string `json:"problem_type"`
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+
+	Message string    `json:"message"`
+	Occured time.Time `json:"occured"`
+
+	Reported        bool `json:"reported"`
+	OccuredCounter  int  `json:"occured_counter"`
+	ResolvedCounter int  `json:"resolved_counter"`
+}
+
+// NewClient creates a new sns client to use. The region is taken from the topic arn if it is empty and the
+// credentials are loaded from the default aws credential chain (environment, web identity, shared config or
+// instance profile)
+func NewClient(topicARN, region string) (*Client, error) {
+	if topicARN == "" {
+		return nil, errors.New("No sns topic arn provided. Is env variable SNS_TOPIC_ARN set?")
+	}
+
+	// arn:aws:sns:region:account:topic
+	splitted := strings.Split(topicARN, ":")
+	if len(splitted) != 6 || splitted[0] != "arn" || splitted[2] != "sns" {
+		return nil, fmt.Errorf("invalid sns topic arn '%s' (expected arn:aws:sns:<region>:<account>:<topic>)", topicARN)
+	}
+	if region == "" {
+		region = splitted[3]
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("Error loading aws config: %v", err)
+	}
+
+	return &Client{
+		TopicARN: topicARN,
+		Region:   region,
+
+		api: snsapi.NewFromConfig(cfg),
+	}, nil
+}
+
+// Alert publishes a problem event to the topic
+func (c *Client) Alert(p notify.Problem) error {
+	return c.publish(eventTypeProblem, p)
+}
+
+// Resolve publishes a resolve event to the topic
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.publish(eventTypeResolve, p)
+}
+
+func newPayload(eventType string, p notify.Problem) *payload {
+	return &payload{
+		EventType: eventType,
+
+		ID:        p.ID,
+		Type:      p.Type,
+		Severity:  p.Severity,
+		Kind:      p.Kind,
+		Name:      p.Name,
+		Namespace: p.Namespace,
+
+		Message: p.Message,
+		Occured: p.Occured,
+
+		Reported:        p.Reported,
+		OccuredCounter:  p.OccuredCounter,
+		ResolvedCounter: p.ResolvedCounter,
+	}
+}
+
+// getSubject returns the subject of the message, which is shown e.g. in email subscriptions
+func getSubject(p notify.Problem) string {
+	subject := fmt.Sprintf("[kube-problem] %s - %s/%s", p.Severity, p.Kind, p.Name)
+	if len(subject) > maxSubjectLength {
+		subject = subject[:maxSubjectLength]
+	}
+
+	return subject
+}
+
+// publish publishes the event to the topic. Throttled and failed requests are retried by the sns client until the
+// publish timeout is reached
+func (c *Client) publish(eventType string, p notify.Problem) error {
+	message, err := json.Marshal(newPayload(eventType, p))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	_, err = c.api.Publish(ctx, &snsapi.PublishInput{
+		TopicArn: aws.String(c.TopicARN),
+		Subject:  aws.String(getSubject(p)),
+		Message:  aws.String(string(message)),
+	})
+	if err != nil {
+		return fmt.Errorf("Error publishing to sns topic %s: %v", c.TopicARN, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	snsapi "github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+var testProblem = notify.Problem{
+	ID:        "default/pod/status",
+	Type:      "PodStatus",
+	Severity:  "critical",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+}
+
+// recordingAPI records the published messages and fails with err if it is set
+type recordingAPI struct {
+	inputs []*snsapi.PublishInput
+	err    error
+}
+
+func (a *recordingAPI) Publish(ctx context.Context, params *snsapi.PublishInput, optFns ...func(*snsapi.Options)) (*snsapi.PublishOutput, error) {
+	a.inputs = append(a.inputs, params)
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	return &snsapi.PublishOutput{MessageId: aws.String("id")}, nil
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		topicARN       string
+		region         string
+		expectedRegion string
+		expectedErr    bool
+	}{
+		{
+			name:           "region from arn",
+			topicARN:       "arn:aws:sns:eu-west-1:123456789012:alerts",
+			expectedRegion: "eu-west-1",
+		},
+		{
+			name:           "explicit region",
+			topicARN:       "arn:aws:sns:eu-west-1:123456789012:alerts",
+			region:         "us-east-1",
+			expectedRegion: "us-east-1",
+		},
+		{
+			name:        "invalid arn",
+			topicARN:    "alerts",
+			expectedErr: true,
+		},
+		{
+			name:        "missing arn",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		client, err := NewClient(test.topicARN, test.region)
+		if test.expectedErr {
+			if err == nil {
+				t.Fatalf("%s: expected an error", test.name)
+			}
+
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if client.Region != test.expectedRegion {
+			t.Fatalf("%s: expected region %s, got %s", test.name, test.expectedRegion, client.Region)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	tests := []struct {
+		name              string
+		send              func(c *Client) error
+		expectedEventType string
+	}{
+		{
+			name: "alert",
+			send: func(c *Client) error {
+				return c.Alert(testProblem)
+			},
+			expectedEventType: eventTypeProblem,
+		},
+		{
+			name: "resolve",
+			send: func(c *Client) error {
+				return c.Resolve(testProblem)
+			},
+			expectedEventType: eventTypeResolve,
+		},
+	}
+
+	for _, test := range tests {
+		api := &recordingAPI{}
+		client := &Client{TopicARN: "arn:aws:sns:eu-west-1:123456789012:alerts", Region: "eu-west-1", api: api}
+		err := test.send(client)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if len(api.inputs) != 1 {
+			t.Fatalf("%s: expected 1 published message, got %d", test.name, len(api.inputs))
+		}
+
+		input := api.inputs[0]
+		if aws.ToString(input.TopicArn) != client.TopicARN {
+			t.Fatalf("%s: unexpected topic arn %s", test.name, aws.ToString(input.TopicArn))
+		} else if aws.ToString(input.Subject) != "[kube-problem] critical - Pod/pod" {
+			t.Fatalf("%s: unexpected subject %s", test.name, aws.ToString(input.Subject))
+		}
+
+		data := payload{}
+		err = json.Unmarshal([]byte(aws.ToString(input.Message)), &data)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		} else if data.EventType != test.expectedEventType || data.ID != testProblem.ID || data.Message != testProblem.Message {
+			t.Fatalf("%s: unexpected payload %#v", test.name, data)
+		}
+	}
+}
+
+func TestPublishError(t *testing.T) {
+	client := &Client{TopicARN: "arn:aws:sns:eu-west-1:123456789012:alerts", api: &recordingAPI{err: errors.New("AuthorizationError")}}
+	err := client.Alert(testProblem)
+	if err == nil || !strings.Contains(err.Error(), "AuthorizationError") {
+		t.Fatalf("Expected the publish error to be returned, got %v", err)
+	}
+}
+
+func TestSubjectLength(t *testing.T) {
+	subject := getSubject(notify.Problem{Severity: "critical", Kind: "Pod", Name: strings.Repeat("a", 200)})
+	if len(subject) != maxSubjectLength {
+		t.Fatalf("Expected the subject to be truncated to %d characters, got %d", maxSubjectLength, len(subject))
+	}
+}
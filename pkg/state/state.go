@@ -0,0 +1,121 @@
+package state
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/kube"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigMapName is the name of the configmap the state is stored in
+const ConfigMapName = "kube-problem-state"
+
+const problemsKey = "problems"
+
+// Problem is the serializable state of a problem
+type Problem struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+
+	Message string `json:"message"`
+
+	ResolvedCounter int `json:"resolvedCounter"`
+	OccuredCounter  int `json:"occuredCounter"`
+
+	Reported    bool          `json:"reported"`
+	Occured     time.Time     `json:"occured"`
+	ReportAfter time.Duration `json:"reportAfter,omitempty"`
+	Thread      string        `json:"thread,omitempty"`
+
+	EventNamespace string `json:"eventNamespace,omitempty"`
+}
+
+// Store loads and saves problems from and to a configmap
+type Store struct {
+	client    kube.Client
+	namespace string
+
+	// resourceVersion is the last known resource version of the configmap
+	resourceVersion string
+}
+
+// NewStore creates a new store that uses a configmap in the given namespace
+func NewStore(client kube.Client, namespace string) *Store {
+	return &Store{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Load loads the problems from the configmap. If the configmap does not exist, an empty map is returned
+func (s *Store) Load() (map[string]*Problem, error) {
+	problems := map[string]*Problem{}
+
+	configMap, err := s.client.Client().CoreV1().ConfigMaps(s.namespace).Get(ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return problems, nil
+		}
+
+		return nil, errors.Wrap(err, "get state configmap")
+	}
+
+	s.resourceVersion = configMap.ResourceVersion
+	if configMap.Data[problemsKey] == "" {
+		return problems, nil
+	}
+
+	err = json.Unmarshal([]byte(configMap.Data[problemsKey]), &problems)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal state")
+	}
+
+	return problems, nil
+}
+
+// Save saves the problems to the configmap. If the configmap was changed by someone else since it
+// was loaded or saved the last time, an error is returned and the next save will overwrite it
+func (s *Store) Save(problems map[string]*Problem) error {
+	out, err := json.Marshal(problems)
+	if err != nil {
+		return errors.Wrap(err, "marshal state")
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ConfigMapName,
+			Namespace:       s.namespace,
+			ResourceVersion: s.resourceVersion,
+		},
+		Data: map[string]string{
+			problemsKey: string(out),
+		},
+	}
+
+	if s.resourceVersion == "" {
+		configMap, err = s.client.Client().CoreV1().ConfigMaps(s.namespace).Create(configMap)
+	} else {
+		configMap, err = s.client.Client().CoreV1().ConfigMaps(s.namespace).Update(configMap)
+	}
+	if err != nil {
+		if kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err) {
+			// Somebody else changed the state, refresh the resource version for the next save
+			current, getErr := s.client.Client().CoreV1().ConfigMaps(s.namespace).Get(ConfigMapName, metav1.GetOptions{})
+			if getErr == nil {
+				s.resourceVersion = current.ResourceVersion
+			}
+		}
+
+		return errors.Wrap(err, "save state configmap")
+	}
+
+	s.resourceVersion = configMap.ResourceVersion
+	return nil
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeClient is a kube client backed by a fake clientset
+type fakeClient struct {
+	clientset *fake.Clientset
+}
+
+func (c *fakeClient) Config() *rest.Config {
+	return &rest.Config{}
+}
+
+func (c *fakeClient) Client() kubernetes.Interface {
+	return c.clientset
+}
+
+func (c *fakeClient) Dynamic() dynamic.Interface {
+	return nil
+}
+
+// newFakeClient returns a client that versions configmaps and rejects updates with an outdated resource
+// version like the api server does
+func newFakeClient() *fakeClient {
+	clientset := fake.NewSimpleClientset()
+	version := 0
+
+	clientset.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		configMap := action.(k8stesting.CreateAction).GetObject().(*v1.ConfigMap)
+		version++
+		configMap.ResourceVersion = strconv.Itoa(version)
+		return false, nil, nil
+	})
+	clientset.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		configMap := action.(k8stesting.UpdateAction).GetObject().(*v1.ConfigMap)
+		current, err := clientset.Tracker().Get(v1.SchemeGroupVersion.WithResource("configmaps"), configMap.Namespace, configMap.Name)
+		if err != nil {
+			return true, nil, err
+		} else if current.(*v1.ConfigMap).ResourceVersion != configMap.ResourceVersion {
+			return true, nil, kerrors.NewConflict(v1.Resource("configmaps"), configMap.Name, nil)
+		}
+
+		version++
+		configMap.ResourceVersion = strconv.Itoa(version)
+		return false, nil, nil
+	})
+
+	return &fakeClient{clientset: clientset}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	store := NewStore(newFakeClient(), "kube-problem")
+
+	problems, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(problems) != 0 {
+		t.Fatalf("Expected no problems without a configmap, got %d", len(problems))
+	}
+
+	occured := time.Now().Truncate(time.Second)
+	err = store.Save(map[string]*Problem{
+		"default/pod/status": {
+			ID:             "default/pod/status",
+			Type:           "PodStatus",
+			Kind:           "Pod",
+			Name:           "pod",
+			Namespace:      "default",
+			Message:        "Pod default/pod has critical status CrashLoopBackOff",
+			OccuredCounter: 3,
+			Reported:       true,
+			Occured:        occured,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new store, e.g. after a restart, loads the saved problems
+	restarted := NewStore(store.client, "kube-problem")
+	problems, err = restarted.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problem := problems["default/pod/status"]
+	if len(problems) != 1 || problem == nil {
+		t.Fatalf("Expected the saved problem, got %#v", problems)
+	} else if problem.Kind != "Pod" || problem.OccuredCounter != 3 || !problem.Reported || !problem.Occured.Equal(occured) {
+		t.Fatalf("Unexpected problem %#v", problem)
+	}
+
+	// Saving again updates the configmap
+	err = restarted.Save(map[string]*Problem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err = NewStore(store.client, "kube-problem").Load()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(problems) != 0 {
+		t.Fatalf("Expected no problems after saving an empty state, got %d", len(problems))
+	}
+}
+
+func TestSaveConflict(t *testing.T) {
+	client := newFakeClient()
+	first := NewStore(client, "kube-problem")
+	second := NewStore(client, "kube-problem")
+
+	err := first.Save(map[string]*Problem{"first": {ID: "first"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second instance did not see the configmap yet, so its save fails and it refreshes the resource version
+	err = second.Save(map[string]*Problem{"second": {ID: "second"}})
+	if err == nil {
+		t.Fatal("Expected an error when creating an existing configmap")
+	}
+
+	err = second.Save(map[string]*Problem{"second": {ID: "second"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Now the first instance has an outdated resource version and must not overwrite the state
+	err = first.Save(map[string]*Problem{"first": {ID: "first"}})
+	if err == nil {
+		t.Fatal("Expected a conflict when saving with an outdated resource version")
+	}
+
+	problems, err := NewStore(client, "kube-problem").Load()
+	if err != nil {
+		t.Fatal(err)
+	} else if problems["second"] == nil || problems["first"] != nil {
+		t.Fatalf("Expected the state of the second instance, got %#v", problems)
+	}
+
+	// The next save of the first instance succeeds with the refreshed resource version
+	err = first.Save(map[string]*Problem{"first": {ID: "first"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,172 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+)
+
+const (
+	styleProblem = "attention"
+	styleResolve = "good"
+)
+
+// Client is the microsoft teams client struct
+type Client struct {
+	WebhookURL  string
+	MentionUser string
+
+	httpClient *http.Client
+}
+
+type message struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	ContentType string `json:"contentType"`
+	Content     card   `json:"content"`
+}
+
+type card struct {
+	Schema  string        `json:"$schema"`
+	Type    string        `json:"type"`
+	Version string        `json:"version"`
+	Body    []cardElement `json:"body"`
+	MSTeams *cardMSTeams  `json:"msteams,omitempty"`
+}
+
+type cardElement struct {
+	Type  string        `json:"type"`
+	Style string        `json:"style,omitempty"`
+	Items []cardElement `json:"items,omitempty"`
+	Text  string        `json:"text,omitempty"`
+	Color string        `json:"color,omitempty"`
+	Wrap  bool          `json:"wrap,omitempty"`
+}
+
+type cardMSTeams struct {
+	Width    string          `json:"width,omitempty"`
+	Entities []mentionEntity `json:"entities,omitempty"`
+}
+
+type mentionEntity struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	Mentioned mentionedEntity `json:"mentioned"`
+}
+
+type mentionedEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewClient creates a new teams client to use
+func NewClient(webhookURL, mentionUser string) (*Client, error) {
+	if webhookURL == "" {
+		return nil, errors.New("No teams webhook url provided. Is env variable TEAMS_WEBHOOK_URL set?")
+	}
+
+	return &Client{
+		WebhookURL:  webhookURL,
+		MentionUser: mentionUser,
+		httpClient:  &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+// Alert sends a red problem card to the webhook
+func (c *Client) Alert(p notify.Problem) error {
+	return c.sendMessage(c.newMessage(fmt.Sprintf("There seems to be a problem with %s: %s", p.Resource(), p.Message), styleProblem))
+}
+
+// Resolve sends a green resolve card to the webhook
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.sendMessage(c.newMessage(fmt.Sprintf("The problem with %s is resolved: %s", p.Resource(), p.Message), styleResolve))
+}
+
+func (c *Client) newMessage(text, style string) *message {
+	msTeams := &cardMSTeams{Width: "Full"}
+	if c.MentionUser != "" {
+		mention := fmt.Sprintf("<at>%s</at>", c.MentionUser)
+		text = mention + " " + text
+		msTeams.Entities = []mentionEntity{
+			{
+				Type: "mention",
+				Text: mention,
+				Mentioned: mentionedEntity{
+					ID:   c.MentionUser,
+					Name: c.MentionUser,
+				},
+			},
+		}
+	}
+
+	return &message{
+		Type: "message",
+		Attachments: []attachment{
+			{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: card{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.2",
+					Body: []cardElement{
+						{
+							Type:  "Container",
+							Style: style,
+							Items: []cardElement{
+								{
+									Type:  "TextBlock",
+									Text:  text,
+									Color: style,
+									Wrap:  true,
+								},
+							},
+						},
+					},
+					MSTeams: msTeams,
+				},
+			},
+		},
+	}
+}
+
+// sendMessage posts the message to the teams webhook
+func (c *Client) sendMessage(msg *message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(nil, retry.DefaultPolicy, "sending to teams", func() (bool, error) {
+		return c.post(body)
+	})
+}
+
+func (c *Client) post(body []byte) (bool, error) {
+	resp, err := c.httpClient.Post(c.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("teams returned status code %d: %s", resp.StatusCode, string(out))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, err
+	}
+
+	return false, err
+}
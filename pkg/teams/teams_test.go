@@ -0,0 +1,139 @@
+package teams
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "default/pod/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+}
+
+// newTestClient returns a client that posts to a test server, which records the decoded messages
+func newTestClient(t *testing.T, mentionUser string) (*Client, func() []message) {
+	var (
+		messages      []message
+		messagesMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s with content type %s", req.Method, req.Header.Get("Content-Type"))
+		}
+
+		msg := message{}
+		err := json.NewDecoder(req.Body).Decode(&msg)
+		if err != nil {
+			t.Error(err)
+		}
+
+		messagesMutex.Lock()
+		messages = append(messages, msg)
+		messagesMutex.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, mentionUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() []message {
+		messagesMutex.Lock()
+		defer messagesMutex.Unlock()
+
+		return messages
+	}
+}
+
+func TestCardPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		send          func(c *Client) error
+		expectedStyle string
+		expectedText  string
+	}{
+		{
+			name: "alert",
+			send: func(c *Client) error {
+				return c.Alert(testProblem)
+			},
+			expectedStyle: styleProblem,
+			expectedText:  "There seems to be a problem with Pod 'pod' in namespace 'default': Pod has critical status 'CrashLoopBackOff'",
+		},
+		{
+			name: "resolve",
+			send: func(c *Client) error {
+				return c.Resolve(testProblem)
+			},
+			expectedStyle: styleResolve,
+			expectedText:  "The problem with Pod 'pod' in namespace 'default' is resolved: Pod has critical status 'CrashLoopBackOff'",
+		},
+	}
+
+	for _, test := range tests {
+		client, messages := newTestClient(t, "")
+		err := test.send(client)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		sent := messages()
+		if len(sent) != 1 {
+			t.Fatalf("%s: expected 1 message, got %d", test.name, len(sent))
+		} else if sent[0].Type != "message" || len(sent[0].Attachments) != 1 {
+			t.Fatalf("%s: expected a message with a single attachment, got %#v", test.name, sent[0])
+		}
+
+		attachment := sent[0].Attachments[0]
+		if attachment.ContentType != "application/vnd.microsoft.card.adaptive" {
+			t.Fatalf("%s: unexpected content type %s", test.name, attachment.ContentType)
+		} else if attachment.Content.Type != "AdaptiveCard" || attachment.Content.Schema == "" || attachment.Content.Version == "" {
+			t.Fatalf("%s: unexpected card %#v", test.name, attachment.Content)
+		} else if len(attachment.Content.Body) != 1 || len(attachment.Content.Body[0].Items) != 1 {
+			t.Fatalf("%s: expected a container with a single text block, got %#v", test.name, attachment.Content.Body)
+		}
+
+		container := attachment.Content.Body[0]
+		if container.Style != test.expectedStyle || container.Items[0].Color != test.expectedStyle {
+			t.Fatalf("%s: expected style %s, got container style %s and text color %s", test.name, test.expectedStyle, container.Style, container.Items[0].Color)
+		} else if container.Items[0].Text != test.expectedText {
+			t.Fatalf("%s: unexpected text %s", test.name, container.Items[0].Text)
+		}
+	}
+}
+
+func TestMentionUser(t *testing.T) {
+	client, messages := newTestClient(t, "oncall@example.com")
+	err := client.Alert(notify.Problem{ID: "node/condition", Kind: "Node", Name: "node", Message: "Node is not ready"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := messages()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+
+	content := sent[0].Attachments[0].Content
+	if text := content.Body[0].Items[0].Text; text != "<at>oncall@example.com</at> There seems to be a problem with Node 'node': Node is not ready" {
+		t.Fatalf("Unexpected text %s", text)
+	} else if content.MSTeams == nil || len(content.MSTeams.Entities) != 1 {
+		t.Fatalf("Expected a single mention entity, got %#v", content.MSTeams)
+	}
+
+	entity := content.MSTeams.Entities[0]
+	if entity.Type != "mention" || entity.Text != "<at>oncall@example.com</at>" || entity.Mentioned.ID != "oncall@example.com" {
+		t.Fatalf("Unexpected mention entity %#v", entity)
+	}
+}
@@ -0,0 +1,35 @@
+package version
+
+import "fmt"
+
+// These variables are set at build time with
+// -ldflags "-X github.com/FabianKramm/kube-problem/pkg/version.Version=v1.0.0 -X ..."
+var (
+	// Version is the released version of kube-problem
+	Version = "dev"
+	// Commit is the git commit kube-problem was built from
+	Commit = "unknown"
+	// BuildDate is the date kube-problem was built at
+	BuildDate = "unknown"
+)
+
+// Info holds the build metadata
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// Get returns the build metadata
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+	}
+}
+
+// String returns the build metadata in a human readable format
+func String() string {
+	return fmt.Sprintf("kube-problem %s (commit %s, built %s)", Version, Commit, BuildDate)
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/retry"
+)
+
+const (
+	eventTypeProblem = "problem"
+	eventTypeResolve = "resolve"
+)
+
+// Client posts problems as json to a generic webhook
+type Client struct {
+	URL     string
+	Headers map[string]string
+
+	httpClient *http.Client
+}
+
+type payload struct {
+	EventType string `json:"event_type"`
+
+	ID        string `json:"id"`
+	Type      string `json:"problem_type"`
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+
+	Message string    `json:"message"`
+	Occured time.Time `json:"occured"`
+
+	Reported        bool `json:"reported"`
+	OccuredCounter  int  `json:"occured_counter"`
+	ResolvedCounter int  `json:"resolved_counter"`
+}
+
+// NewClient creates a new webhook client to use. Headers are parsed from a comma separated
+// list of key=value pairs
+func NewClient(url, headers string) (*Client, error) {
+	if url == "" {
+		return nil, errors.New("No webhook url provided. Is env variable WEBHOOK_URL set?")
+	}
+
+	parsedHeaders, err := parseHeaders(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		URL:        url,
+		Headers:    parsedHeaders,
+		httpClient: &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+func parseHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, header := range strings.Split(value, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+
+		// Header values can contain '=', e.g. base64 encoded tokens
+		splitted := strings.SplitN(header, "=", 2)
+		if len(splitted) != 2 || strings.TrimSpace(splitted[0]) == "" {
+			return nil, fmt.Errorf("invalid webhook header '%s' (expected key=value)", header)
+		}
+
+		headers[strings.TrimSpace(splitted[0])] = strings.TrimSpace(splitted[1])
+	}
+
+	return headers, nil
+}
+
+// Alert posts a problem event to the webhook
+func (c *Client) Alert(p notify.Problem) error {
+	return c.sendPayload(newPayload(eventTypeProblem, p))
+}
+
+// Resolve posts a resolve event to the webhook
+func (c *Client) Resolve(p notify.Problem) error {
+	return c.sendPayload(newPayload(eventTypeResolve, p))
+}
+
+func newPayload(eventType string, p notify.Problem) *payload {
+	return &payload{
+		EventType: eventType,
+
+		ID:        p.ID,
+		Type:      p.Type,
+		Severity:  p.Severity,
+		Kind:      p.Kind,
+		Name:      p.Name,
+		Namespace: p.Namespace,
+
+		Message: p.Message,
+		Occured: p.Occured,
+
+		Reported:        p.Reported,
+		OccuredCounter:  p.OccuredCounter,
+		ResolvedCounter: p.ResolvedCounter,
+	}
+}
+
+// sendPayload posts the payload to the webhook
+func (c *Client) sendPayload(p *payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(nil, retry.DefaultPolicy, "sending to webhook", func() (bool, error) {
+		return c.post(body)
+	})
+}
+
+func (c *Client) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("webhook returned status code %d: %s", resp.StatusCode, string(out))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, err
+	}
+
+	return false, err
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+)
+
+var testProblem = notify.Problem{
+	ID:        "default/pod/status",
+	Type:      "PodStatus",
+	Kind:      "Pod",
+	Name:      "pod",
+	Namespace: "default",
+	Severity:  "critical",
+	Message:   "Pod has critical status 'CrashLoopBackOff'",
+	Occured:   time.Date(2020, 6, 6, 2, 0, 0, 0, time.UTC),
+
+	Reported:        true,
+	OccuredCounter:  3,
+	ResolvedCounter: 1,
+}
+
+type request struct {
+	header http.Header
+	body   map[string]interface{}
+}
+
+// newTestClient returns a client that posts to a test server, which records the requests
+func newTestClient(t *testing.T, headers string) (*Client, func() []request) {
+	var (
+		requests      []request
+		requestsMutex sync.Mutex
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s with content type %s", req.Method, req.Header.Get("Content-Type"))
+		}
+
+		out, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		body := map[string]interface{}{}
+		err = json.Unmarshal(out, &body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		requestsMutex.Lock()
+		requests = append(requests, request{header: req.Header, body: body})
+		requestsMutex.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() []request {
+		requestsMutex.Lock()
+		defer requestsMutex.Unlock()
+
+		return requests
+	}
+}
+
+func TestPayloadSchema(t *testing.T) {
+	client, requests := newTestClient(t, "")
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Resolve(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := requests()
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(sent))
+	}
+
+	for i, eventType := range []string{eventTypeProblem, eventTypeResolve} {
+		expected := map[string]interface{}{
+			"event_type":       eventType,
+			"id":               "default/pod/status",
+			"problem_type":     "PodStatus",
+			"kind":             "Pod",
+			"name":             "pod",
+			"namespace":        "default",
+			"severity":         "critical",
+			"message":          "Pod has critical status 'CrashLoopBackOff'",
+			"occured":          "2020-06-06T02:00:00Z",
+			"reported":         true,
+			"occured_counter":  float64(3),
+			"resolved_counter": float64(1),
+		}
+
+		body := sent[i].body
+		if len(body) != len(expected) {
+			t.Fatalf("Expected the fields %s, got %s", keys(expected), keys(body))
+		}
+		for key, value := range expected {
+			if body[key] != value {
+				t.Fatalf("Expected %s to be %v, got %v", key, value, body[key])
+			}
+		}
+	}
+}
+
+func TestCustomHeaders(t *testing.T) {
+	client, requests := newTestClient(t, "Authorization=Basic dXNlcjpwYXNz==, X-Team = platform")
+	err := client.Alert(testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := requests()[0].header
+	if header.Get("Authorization") != "Basic dXNlcjpwYXNz==" {
+		t.Fatalf("Unexpected authorization header %s", header.Get("Authorization"))
+	} else if header.Get("X-Team") != "platform" {
+		t.Fatalf("Unexpected team header %s", header.Get("X-Team"))
+	}
+}
+
+func TestParseHeadersInvalid(t *testing.T) {
+	for _, value := range []string{"Authorization", "=value", "X-Team=platform,invalid"} {
+		_, err := parseHeaders(value)
+		if err == nil {
+			t.Fatalf("Expected an error parsing '%s'", value)
+		}
+	}
+}
+
+func keys(m map[string]interface{}) string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return strings.Join(keys, ",")
+}
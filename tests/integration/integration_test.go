@@ -0,0 +1,276 @@
+// Package integration runs the runner against a fake kubernetes cluster and checks the alerts and resolves it sends
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianKramm/kube-problem/pkg/notify"
+	"github.com/FabianKramm/kube-problem/pkg/runner"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+const testNamespace = "integration"
+
+// waitTimeout is how long to wait for an alert or resolve. Pod problems are resolved after 10 checks without the
+// problem, which takes 10 seconds with the check interval of the test namespace
+const waitTimeout = time.Second * 30
+
+// fakeCluster is a kube client backed by a fake clientset and dynamic client
+type fakeCluster struct {
+	clientset *fake.Clientset
+	dynamic   *dynamicfake.FakeDynamicClient
+}
+
+func (c *fakeCluster) Config() *rest.Config {
+	// Nothing listens here, so the metrics requests fail and the checks run without metrics
+	return &rest.Config{Host: "http://127.0.0.1:1"}
+}
+
+func (c *fakeCluster) Client() kubernetes.Interface {
+	return c.clientset
+}
+
+func (c *fakeCluster) Dynamic() dynamic.Interface {
+	return c.dynamic
+}
+
+// mockNotifier records the sent alerts and resolves by problem type
+type mockNotifier struct {
+	mutex    sync.Mutex
+	alerts   []notify.Problem
+	resolves []notify.Problem
+}
+
+func (n *mockNotifier) Alert(p notify.Problem) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.alerts = append(n.alerts, p)
+	return nil
+}
+
+func (n *mockNotifier) Resolve(p notify.Problem) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.resolves = append(n.resolves, p)
+	return nil
+}
+
+// waitFor waits until a problem of the type was sent for the named resource and returns it
+func (n *mockNotifier) waitFor(t *testing.T, sent *[]notify.Problem, problemType, name string) notify.Problem {
+	timeout := time.After(waitTimeout)
+	for {
+		n.mutex.Lock()
+		for _, p := range *sent {
+			if p.Type == problemType && p.Name == name {
+				n.mutex.Unlock()
+				return p
+			}
+		}
+		n.mutex.Unlock()
+
+		select {
+		case <-timeout:
+			t.Fatalf("No %s message was sent for %s within %v", problemType, name, waitTimeout)
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+}
+
+func (n *mockNotifier) waitForAlert(t *testing.T, problemType, name string) notify.Problem {
+	return n.waitFor(t, &n.alerts, problemType, name)
+}
+
+func (n *mockNotifier) waitForResolve(t *testing.T, problemType, name string) notify.Problem {
+	return n.waitFor(t, &n.resolves, problemType, name)
+}
+
+// startRunner starts a runner that watches the nodes and the test namespace of the cluster. Problems are alerted
+// after their first occurrence and the test namespace is checked every second
+func startRunner(t *testing.T, cluster *fakeCluster) *mockNotifier {
+	t.Setenv("NODE_CONDITION_THRESHOLD", "1")
+	t.Setenv("POD_STATUS_THRESHOLD", "1")
+	t.Setenv("POD_PENDING_THRESHOLD", "1")
+	t.Setenv("NAMESPACE_INTERVALS", testNamespace+"=1")
+
+	notifier := &mockNotifier{}
+	r, err := runner.NewRunner(cluster, notifier, true, []string{testNamespace}, nil, labels.Everything(), labels.Everything(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error)
+	go func() {
+		errChan <- r.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-errChan; err != nil {
+			t.Errorf("Runner stopped with error: %v", err)
+		}
+	})
+
+	return notifier
+}
+
+func newFakeCluster(t *testing.T) *fakeCluster {
+	clientset := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+
+	// Advertise the metrics api, because the fake clientset has no rest client for the heapster fallback
+	clientset.Resources = []*metav1.APIResourceList{{GroupVersion: "metrics.k8s.io/v1beta1"}}
+	return &fakeCluster{clientset: clientset, dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())}
+}
+
+func newPod(name string, status v1.PodStatus) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, CreationTimestamp: metav1.Now()},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app", Image: "app:1.0.0"}},
+		},
+		Status: status,
+	}
+}
+
+func crashLoopStatus() v1.PodStatus {
+	return v1.PodStatus{
+		Phase: v1.PodRunning,
+		ContainerStatuses: []v1.ContainerStatus{
+			{
+				Name:  "app",
+				State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			},
+		},
+	}
+}
+
+func runningStatus() v1.PodStatus {
+	return v1.PodStatus{
+		Phase: v1.PodRunning,
+		ContainerStatuses: []v1.ContainerStatus{
+			{
+				Name:  "app",
+				Ready: true,
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: metav1.Now()}},
+			},
+		},
+	}
+}
+
+func TestNodeCondition(t *testing.T) {
+	cluster := newFakeCluster(t)
+	_, err := cluster.clientset.CoreV1().Nodes().Create(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{
+					Type:    v1.NodeReady,
+					Status:  v1.ConditionFalse,
+					Message: "kubelet stopped posting node status",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := startRunner(t, cluster)
+	alert := notifier.waitForAlert(t, "NodeCondition", "node")
+	if alert.Kind != "Node" || alert.Message != "Node 'node' (worker) has ready status 'False': kubelet stopped posting node status" {
+		t.Fatalf("Unexpected alert %#v", alert)
+	}
+
+	// The problems of a removed node are resolved right away
+	err = cluster.clientset.CoreV1().Nodes().Delete("node", &metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier.waitForResolve(t, "NodeCondition", "node")
+}
+
+func TestPodCriticalStatus(t *testing.T) {
+	cluster := newFakeCluster(t)
+	notifier := startRunner(t, cluster)
+
+	_, err := cluster.clientset.CoreV1().Pods(testNamespace).Create(newPod("crashing", crashLoopStatus()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alert := notifier.waitForAlert(t, "PodStatus", "crashing")
+	if alert.Namespace != testNamespace || alert.Message != "Pod 'integration/crashing' has critical status 'CrashLoopBackOff'" {
+		t.Fatalf("Unexpected alert %#v", alert)
+	}
+
+	// The pod recovers, the problem is resolved after it was healthy for several checks
+	_, err = cluster.clientset.CoreV1().Pods(testNamespace).UpdateStatus(newPod("crashing", runningStatus()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier.waitForResolve(t, "PodStatus", "crashing")
+}
+
+func TestPodPending(t *testing.T) {
+	cluster := newFakeCluster(t)
+	notifier := startRunner(t, cluster)
+
+	_, err := cluster.clientset.CoreV1().Pods(testNamespace).Create(newPod("pending", v1.PodStatus{
+		Phase: v1.PodPending,
+		ContainerStatuses: []v1.ContainerStatus{
+			{
+				Name:  "app",
+				State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+			},
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alert := notifier.waitForAlert(t, "PodPending", "pending")
+	if alert.Message != "Pod 'integration/pending' is not starting with status 'ContainerCreating'" {
+		t.Fatalf("Unexpected alert %#v", alert)
+	}
+
+	// The pod starts, the problem is resolved after it was running for several checks
+	_, err = cluster.clientset.CoreV1().Pods(testNamespace).UpdateStatus(newPod("pending", runningStatus()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier.waitForResolve(t, "PodPending", "pending")
+}
+
+func TestHealthyPod(t *testing.T) {
+	cluster := newFakeCluster(t)
+	_, err := cluster.clientset.CoreV1().Pods(testNamespace).Create(newPod("healthy", runningStatus()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := startRunner(t, cluster)
+
+	// Give the runner a few check cycles
+	time.Sleep(time.Second * 3)
+
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("Expected no alerts for a healthy pod, got %#v", notifier.alerts)
+	}
+}